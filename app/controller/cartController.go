@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"net/http"
+	"sonartest_cart/app/service"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/e"
+)
+
+type CartController interface {
+	AddItemToCart(w http.ResponseWriter, r *http.Request)
+	ViewCart(w http.ResponseWriter, r *http.Request)
+	UpdateCartItem(w http.ResponseWriter, r *http.Request)
+	RemoveCartItem(w http.ResponseWriter, r *http.Request)
+	ClearCart(w http.ResponseWriter, r *http.Request)
+}
+
+type CartControllerImpl struct {
+	cartService service.CartService
+}
+
+func NewCartController(cartService service.CartService) CartController {
+	return &CartControllerImpl{
+		cartService: cartService,
+	}
+}
+
+func (c *CartControllerImpl) AddItemToCart(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.cartService.AddItemToCart(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to add item to cart")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *CartControllerImpl) ViewCart(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.cartService.ViewCart(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to view cart")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *CartControllerImpl) UpdateCartItem(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.cartService.UpdateCartItem(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to update cart item")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *CartControllerImpl) RemoveCartItem(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.cartService.RemoveCartItem(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to remove cart item")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *CartControllerImpl) ClearCart(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.cartService.ClearCart(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to clear cart")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"errors"
+	"sonartest_cart/app/dto"
+	"sonartest_cart/app/service/mocks"
+	"sonartest_cart/pkg/e"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestAddItemToCart(t *testing.T) {
+	cartMock := new(mocks.CartService)
+	con := NewCartController(cartMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.CartItemResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp: &dto.CartItemResponse{
+				UserID:     1,
+				ProductID:  3,
+				Quantity:   2,
+				BrandName:  "ACME",
+				Price:      10,
+				TotalPrice: 20,
+			},
+			want: `{"status":"ok","result":{"userid":1,"productid":3,"quantity":2,"brandname":"ACME","price":10,"totalprice":20}}`,
+		},
+		{
+			name:   "fail_insufficient_stock",
+			Error:  e.NewError(e.ErrInsufficientStock, "insufficient stock", errors.New("only 1 in stock")),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400017,"message":"failed to add item to cart","details":["only 1 in stock"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/cart", nil)
+
+			cartMock.Mock.On("AddItemToCart", req).Once().Return(test.resp, test.Error)
+
+			con.AddItemToCart(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestViewCart(t *testing.T) {
+	cartMock := new(mocks.CartService)
+	con := NewCartController(cartMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.ViewCartResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp: &dto.ViewCartResponse{
+				UserID:        1,
+				Items:         []dto.ViewCart{{ProductID: 3, Quantity: 2, Price: 10, BrandName: "ACME", TotalAmount: 20}},
+				TotalQuantity: 2,
+				TotalAmount:   20,
+			},
+			want: `{"status":"ok","result":{"userid":1,"items":[{"product_id":3,"quantity":2,"price":10,"brandname":"ACME","totalamount":20}],"totalquantity":2,"totalamount":20}}`,
+		},
+		{
+			name:   "fail_user_blocked",
+			Error:  e.NewError(e.ErrUserBlocked, "user is blocked or inactive", nil),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400028,"message":"failed to view cart","details":["failed to view cart"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/cart", nil)
+
+			cartMock.Mock.On("ViewCart", req).Once().Return(test.resp, test.Error)
+
+			con.ViewCart(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestUpdateCartItem(t *testing.T) {
+	cartMock := new(mocks.CartService)
+	con := NewCartController(cartMock)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/cart/3", nil)
+	cartMock.Mock.On("UpdateCartItem", req).Once().Return(nil, e.NewError(e.ErrCartNotFound, "item is not in the cart", errors.New("record not found")))
+
+	con.UpdateCartItem(res, req)
+
+	assert.Equal(t, 404, res.Code)
+	assert.Equal(t, `{"status":"notok","error":{"code":404004,"message":"failed to update cart item","details":["record not found"]}}`, res.Body.String())
+}
+
+func TestRemoveCartItem(t *testing.T) {
+	cartMock := new(mocks.CartService)
+	con := NewCartController(cartMock)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("DELETE", "/cart/3", nil)
+	cartMock.Mock.On("RemoveCartItem", req).Once().Return(&dto.ViewCartResponse{UserID: 1, Items: []dto.ViewCart{}}, nil)
+
+	con.RemoveCartItem(res, req)
+
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `{"status":"ok","result":{"userid":1,"items":[],"totalquantity":0,"totalamount":0}}`, res.Body.String())
+}
+
+func TestClearCart(t *testing.T) {
+	cartMock := new(mocks.CartService)
+	con := NewCartController(cartMock)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("DELETE", "/cart", nil)
+	cartMock.Mock.On("ClearCart", req).Once().Return(nil, e.NewError(e.ErrClearCart, "error while clearing cart", errors.New("db error")))
+
+	con.ClearCart(res, req)
+
+	assert.Equal(t, 400, res.Code)
+	assert.Equal(t, `{"status":"notok","error":{"code":400031,"message":"failed to clear cart","details":["db error"]}}`, res.Body.String())
+}
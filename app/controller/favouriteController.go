@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"net/http"
+	"sonartest_cart/app/service"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/e"
+)
+
+type FavouriteController interface {
+	ToggleFavourite(w http.ResponseWriter, r *http.Request)
+	GetFavourites(w http.ResponseWriter, r *http.Request)
+}
+
+type FavouriteControllerImpl struct {
+	favouriteService service.FavouriteService
+}
+
+func NewFavouriteController(favouriteService service.FavouriteService) FavouriteController {
+	return &FavouriteControllerImpl{
+		favouriteService: favouriteService,
+	}
+}
+
+func (c *FavouriteControllerImpl) ToggleFavourite(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.favouriteService.ToggleFavourite(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to update favourites")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *FavouriteControllerImpl) GetFavourites(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.favouriteService.GetFavourites(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to get favourites")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
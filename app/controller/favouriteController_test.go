@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"errors"
+	"sonartest_cart/app/dto"
+	"sonartest_cart/app/service/mocks"
+	"sonartest_cart/pkg/e"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestToggleFavourite(t *testing.T) {
+	favMock := new(mocks.FavouriteService)
+	con := NewFavouriteController(favMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.FavoriteToggleResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp:   &dto.FavoriteToggleResponse{BrandID: 3, Favorite: true},
+			want:   `{"status":"ok","result":{"brand_id":3,"favourite":true}}`,
+		},
+		{
+			name:   "fail_brand_not_found",
+			Error:  e.NewError(e.ErrBrandNotFound, "brand not found", errors.New("record not found")),
+			status: 404,
+			want:   `{"status":"notok","error":{"code":404006,"message":"failed to update favourites","details":["record not found"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/favourites", nil)
+
+			favMock.Mock.On("ToggleFavourite", req).Once().Return(test.resp, test.Error)
+
+			con.ToggleFavourite(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestGetFavourites(t *testing.T) {
+	favMock := new(mocks.FavouriteService)
+	con := NewFavouriteController(favMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   []dto.FavoriteBrandResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp: []dto.FavoriteBrandResponse{
+				{BrandID: 3, BrandName: "ACME", Price: 10.5, Stock: 4, ImageLink: "http://img/acme.png"},
+			},
+			want: `{"status":"ok","result":[{"brand_id":3,"brand_name":"ACME","price":10.5,"stock":4,"Image_link":"http://img/acme.png"}]}`,
+		},
+		{
+			name:   "fail_get_favourites",
+			Error:  e.NewError(e.ErrGetFavorites, "error while getting favourites", errors.New("db error")),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400034,"message":"failed to get favourites","details":["db error"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/favourites", nil)
+
+			favMock.Mock.On("GetFavourites", req).Once().Return(test.resp, test.Error)
+
+			con.GetFavourites(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"net/http"
+	"sonartest_cart/app/service"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/e"
+)
+
+type OrderController interface {
+	PlaceOrder(w http.ResponseWriter, r *http.Request)
+	GetOrderHistory(w http.ResponseWriter, r *http.Request)
+	GetCustomerOrderHistory(w http.ResponseWriter, r *http.Request)
+}
+
+type OrderControllerImpl struct {
+	orderService service.OrderService
+}
+
+func NewOrderController(orderService service.OrderService) OrderController {
+	return &OrderControllerImpl{
+		orderService: orderService,
+	}
+}
+
+func (c *OrderControllerImpl) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.orderService.PlaceOrder(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to place order")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusCreated, resp)
+}
+
+func (c *OrderControllerImpl) GetOrderHistory(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.orderService.GetOrderHistory(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to get order history")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *OrderControllerImpl) GetCustomerOrderHistory(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.orderService.GetCustomerOrderHistory(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to get order history")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
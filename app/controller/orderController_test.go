@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"errors"
+	"sonartest_cart/app/dto"
+	"sonartest_cart/app/service/mocks"
+	"sonartest_cart/pkg/e"
+
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestPlaceOrder(t *testing.T) {
+	orderMock := new(mocks.OrderService)
+	con := NewOrderController(orderMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.ItemOrderedResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 201,
+			resp: &dto.ItemOrderedResponse{
+				OrderID:     11,
+				TotalPrice:  20.5,
+				UserDetails: dto.UserDetailsResponse{Username: "testuser"},
+				Items:       []dto.OrderItemResponse{{ProductID: 3, Quantity: 2, CategoryID: 2, BrandName: "ACME", Price: 10.25}},
+			},
+			want: `{"status":"ok","result":{"order_id":11,"total_price":20.5,"user_details":{"username":"testuser","address":"","country":"","pincode":"","phone_number":"","email":""},"items":[{"product_id":3,"quantity":2,"category_id":2,"brand_name":"ACME","price":10.25}]}}`,
+		},
+		{
+			name:   "fail_insufficient_stock",
+			Error:  e.NewError(e.ErrInsufficientStock, "insufficient stock", errors.New("insufficient stock: brand 3 (requested 2, available 1)")),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400017,"message":"failed to place order","details":["insufficient stock: brand 3 (requested 2, available 1)"]}}`,
+		},
+		{
+			name:   "fail_transaction",
+			Error:  e.NewError(e.ErrTransactionError, "error while placing order", errors.New("commit failed")),
+			status: 500,
+			want:   `{"status":"notok","error":{"code":500004,"message":"failed to place order","details":["commit failed"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/orders", nil)
+
+			orderMock.Mock.On("PlaceOrder", req).Once().Return(test.resp, test.Error)
+
+			con.PlaceOrder(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	orderMock := new(mocks.OrderService)
+	con := NewOrderController(orderMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.OrderHistoryResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp: &dto.OrderHistoryResponse{
+				UserID: 1,
+				Orders: []dto.OrderHistoryItem{
+					{
+						OrderID:       10,
+						Status:        "placed",
+						TotalPrice:    20.5,
+						TotalQuantity: 2,
+						CreatedAt:     time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC),
+						Items:         []dto.OrderItemResponse{{ProductID: 3, Quantity: 2, CategoryID: 2, BrandName: "ACME", Price: 10.25}},
+					},
+				},
+				NextCursor: "abc",
+			},
+			want: `{"status":"ok","result":{"userid":1,"orders":[{"order_id":10,"status":"placed","total_price":20.5,"total_quantity":2,"created_at":"2024-01-20T10:00:00Z","items":[{"product_id":3,"quantity":2,"category_id":2,"brand_name":"ACME","price":10.25}]}],"next_cursor":"abc"}}`,
+		},
+		{
+			name:   "fail_get_history",
+			Error:  e.NewError(e.ErrGetOrderHistory, "error while getting order history", errors.New("db error")),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400012,"message":"failed to get order history","details":["db error"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/orders", nil)
+
+			orderMock.Mock.On("GetOrderHistory", req).Once().Return(test.resp, test.Error)
+
+			con.GetOrderHistory(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package controller
+
+import (
+	"net/http"
+	"sonartest_cart/app/service"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/e"
+)
+
+type ProductController interface {
+	CreateCategory(w http.ResponseWriter, r *http.Request)
+	ListCategories(w http.ResponseWriter, r *http.Request)
+	GetCategoryByID(w http.ResponseWriter, r *http.Request)
+	GetCategoryByName(w http.ResponseWriter, r *http.Request)
+	UpdateCategory(w http.ResponseWriter, r *http.Request)
+	UpdateBrand(w http.ResponseWriter, r *http.Request)
+}
+
+type ProductControllerImpl struct {
+	productService service.ProductService
+}
+
+func NewProductController(productService service.ProductService) ProductController {
+	return &ProductControllerImpl{
+		productService: productService,
+	}
+}
+
+func (c *ProductControllerImpl) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.productService.CreateCategory(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to create category")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusCreated, resp)
+}
+
+func (c *ProductControllerImpl) ListCategories(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.productService.ListCategories(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to list categories")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *ProductControllerImpl) GetCategoryByID(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.productService.GetCategoryByID(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to get category")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *ProductControllerImpl) GetCategoryByName(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.productService.GetCategoryByName(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to get category")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *ProductControllerImpl) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.productService.UpdateCategory(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to update category")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *ProductControllerImpl) UpdateBrand(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.productService.UpdateBrand(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to update brand")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
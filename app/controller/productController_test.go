@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"errors"
+	"sonartest_cart/app/dto"
+	"sonartest_cart/app/service/mocks"
+	"sonartest_cart/pkg/e"
+
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-playground/assert/v2"
+)
+
+func TestCreateCategory(t *testing.T) {
+	productMock := new(mocks.ProductService)
+	con := NewProductController(productMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.CreateProductResponds
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 201,
+			resp: &dto.CreateProductResponds{
+				ProductID: 1,
+				Category:  "PHONES",
+				Brands:    []dto.BrandResponse{{BrandName: "ACME", Price: 10, StockCount: 2, ImageLink: "http://img"}},
+			},
+			want: `{"status":"ok","result":{"product_id":1,"category_name":"PHONES","description":"","brands":[{"brand_name":"ACME","price":10,"stock_count":2,"imagelink":"http://img"}]}}`,
+		},
+		{
+			name:   "fail_create",
+			Error:  e.NewError(e.ErrCreateProduct, "error while creating category", errors.New("duplicate key")),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400003,"message":"failed to create category","details":["duplicate key"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/categories", nil)
+
+			productMock.Mock.On("CreateCategory", req).Once().Return(test.resp, test.Error)
+
+			con.CreateCategory(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestListCategories(t *testing.T) {
+	productMock := new(mocks.ProductService)
+	con := NewProductController(productMock)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/categories", nil)
+	productMock.Mock.On("ListCategories", req).Once().Return([]dto.CatagoryListResponse{{CatagoryID: 1, CatagoryName: "PHONES"}}, nil)
+
+	con.ListCategories(res, req)
+
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `{"status":"ok","result":[{"catagoryid":1,"catagoryname":"PHONES","description":""}]}`, res.Body.String())
+}
+
+func TestGetCategoryByID(t *testing.T) {
+	productMock := new(mocks.ProductService)
+	con := NewProductController(productMock)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/categories/1", nil)
+	productMock.Mock.On("GetCategoryByID", req).Once().Return(nil, e.NewError(e.ErrCategoryNotFound, "category not found", errors.New("record not found")))
+
+	con.GetCategoryByID(res, req)
+
+	assert.Equal(t, 404, res.Code)
+	assert.Equal(t, `{"status":"notok","error":{"code":404005,"message":"failed to get category","details":["record not found"]}}`, res.Body.String())
+}
+
+func TestGetCategoryByName(t *testing.T) {
+	productMock := new(mocks.ProductService)
+	con := NewProductController(productMock)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/categories/name/phones", nil)
+	productMock.Mock.On("GetCategoryByName", req).Once().Return(&dto.CategoryDetailResponse{
+		CategoryID:   1,
+		CategoryName: "PHONES",
+		Brands:       []dto.BrandDetailResponse{{BrandName: "ACME", BrandId: 10, Price: 10, StockCount: 2, CategoryID: 1, CategoryName: "PHONES"}},
+	}, nil)
+
+	con.GetCategoryByName(res, req)
+
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `{"status":"ok","result":{"categoryid":1,"categoryname":"PHONES","description":"","brands":[{"brandname":"ACME","brandid":10,"price":10,"stockcount":2,"category_id":1,"categoryname":"PHONES","imagelink":""}]}}`, res.Body.String())
+}
+
+func TestUpdateCategory(t *testing.T) {
+	productMock := new(mocks.ProductService)
+	con := NewProductController(productMock)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/categories/1", nil)
+	productMock.Mock.On("UpdateCategory", req).Once().Return(&dto.CatagoryListResponse{CatagoryID: 1, CatagoryName: "TABLETS"}, nil)
+
+	con.UpdateCategory(res, req)
+
+	assert.Equal(t, 200, res.Code)
+	assert.Equal(t, `{"status":"ok","result":{"catagoryid":1,"catagoryname":"TABLETS","description":""}}`, res.Body.String())
+}
+
+func TestUpdateBrand(t *testing.T) {
+	productMock := new(mocks.ProductService)
+	con := NewProductController(productMock)
+
+	res := httptest.NewRecorder()
+	req := httptest.NewRequest("PUT", "/brands/10", nil)
+	productMock.Mock.On("UpdateBrand", req).Once().Return(nil, e.NewError(e.ErrUpdateBrand, "error while updating brand", errors.New("db error")))
+
+	con.UpdateBrand(res, req)
+
+	assert.Equal(t, 400, res.Code)
+	assert.Equal(t, `{"status":"notok","error":{"code":400025,"message":"failed to update brand","details":["db error"]}}`, res.Body.String())
+}
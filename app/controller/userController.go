@@ -10,6 +10,20 @@ import (
 type UserController interface {
 	LoginUser(w http.ResponseWriter, r *http.Request)
 	UserDetails(w http.ResponseWriter, r *http.Request)
+	BlockUser(w http.ResponseWriter, r *http.Request)
+	UnblockUser(w http.ResponseWriter, r *http.Request)
+	ListUsers(w http.ResponseWriter, r *http.Request)
+	UpdateUserDetails(w http.ResponseWriter, r *http.Request)
+	GetMyProfile(w http.ResponseWriter, r *http.Request)
+	RefreshToken(w http.ResponseWriter, r *http.Request)
+	Logout(w http.ResponseWriter, r *http.Request)
+	AssignRole(w http.ResponseWriter, r *http.Request)
+	ListRoles(w http.ResponseWriter, r *http.Request)
+	VerifyMail(w http.ResponseWriter, r *http.Request)
+	ResendVerificationMail(w http.ResponseWriter, r *http.Request)
+	ForgotPassword(w http.ResponseWriter, r *http.Request)
+	ResetPassword(w http.ResponseWriter, r *http.Request)
+	ChangePassword(w http.ResponseWriter, r *http.Request)
 }
 
 type UserControllerImpl struct {
@@ -26,7 +40,7 @@ func (c *UserControllerImpl) UserDetails(w http.ResponseWriter, r *http.Request)
 	resp, err := c.userService.SaveUserDetails(r)
 	if err != nil {
 		apiErr := e.NewAPIError(err, "failed to create user")
-		api.Fail(w, apiErr.StatusCode, apiErr.Code, apiErr.Message, err.Error())
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
 		return
 	}
 	api.Success(w, http.StatusOK, resp)
@@ -36,7 +50,147 @@ func (c *UserControllerImpl) LoginUser(w http.ResponseWriter, r *http.Request) {
 	resp, err := c.userService.LoginUser(r)
 	if err != nil {
 		apiErr := e.NewAPIError(err, "failed to login user")
-		api.Fail(w, apiErr.StatusCode, apiErr.Code, apiErr.Message, err.Error())
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) BlockUser(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.BlockUser(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to block user")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) UnblockUser(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.UnblockUser(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to unblock user")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) ListUsers(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.ListUsers(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to list users")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) UpdateUserDetails(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.UpdateUserDetails(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to update user")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) GetMyProfile(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.GetMyProfile(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to get profile")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.RefreshToken(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to refresh token")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) Logout(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.Logout(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to logout")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) AssignRole(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.AssignRole(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to assign role")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) ListRoles(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.ListRoles(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to list roles")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) VerifyMail(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.VerifyMail(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to verify mail address")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) ResendVerificationMail(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.ResendVerificationMail(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to send verification mail")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.ForgotPassword(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to send password reset mail")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.ResetPassword(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to reset password")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
+		return
+	}
+	api.Success(w, http.StatusOK, resp)
+}
+
+func (c *UserControllerImpl) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.userService.ChangePassword(r)
+	if err != nil {
+		apiErr := e.NewAPIError(err, "failed to change password")
+		api.Error(w, r, apiErr.StatusCode, apiErr.Code, apiErr.Message, err)
 		return
 	}
 	api.Success(w, http.StatusOK, resp)
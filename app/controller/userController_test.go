@@ -107,3 +107,325 @@ func TestLoginUser(t *testing.T) {
 		})
 	}
 }
+
+func TestBlockUser(t *testing.T) {
+	userMock := new(mocks.UserService)
+	con := NewUserController(userMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.UserStatusResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp:   &dto.UserStatusResponse{UserID: 2, Status: false},
+			want:   `{"status":"ok","result":{"userid":2,"status":false}}`,
+		},
+		{
+			name:   "fail_user_not_found",
+			Error:  e.NewError(e.ErrUserNotFound, "user not found", errors.New("record not found")),
+			status: 404,
+			want:   `{"status":"notok","error":{"code":404001,"message":"failed to block user","details":["record not found"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/admin/users/2/block", nil)
+
+			userMock.Mock.On("BlockUser", req).Once().Return(test.resp, test.Error)
+
+			con.BlockUser(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	userMock := new(mocks.UserService)
+	con := NewUserController(userMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   []dto.AllUserDetails
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp: []dto.AllUserDetails{
+				{UserID: 2, UserName: "johndoe", Mail: "john@example.com", Address: "123 Street", Pincode: "123456", Phone: "+919876543210", Status: true, Role: "customer"},
+			},
+			want: `{"status":"ok","result":[{"userid":2,"username":"johndoe","mail":"john@example.com","mail_verified":false,"address":"123 Street","country":"","pincode":"123456","phonenumber":"+919876543210","status":true,"role":"customer"}]}`,
+		},
+		{
+			name:   "fail_list_users",
+			Error:  e.NewError(e.ErrGetUserDetails, "error while listing users", errors.New("db error")),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400011,"message":"failed to list users","details":["db error"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/admin/users", nil)
+
+			userMock.Mock.On("ListUsers", req).Once().Return(test.resp, test.Error)
+
+			con.ListUsers(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestGetMyProfile(t *testing.T) {
+	userMock := new(mocks.UserService)
+	con := NewUserController(userMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.AllUserDetails
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp:   &dto.AllUserDetails{UserID: 2, UserName: "johndoe", Mail: "john@example.com", Status: true, Role: "customer"},
+			want:   `{"status":"ok","result":{"userid":2,"username":"johndoe","mail":"john@example.com","mail_verified":false,"address":"","country":"","pincode":"","phonenumber":"","status":true,"role":"customer"}}`,
+		},
+		{
+			name:   "fail_user_not_found",
+			Error:  e.NewError(e.ErrUserNotFound, "user not found", errors.New("record not found")),
+			status: 404,
+			want:   `{"status":"notok","error":{"code":404001,"message":"failed to get profile","details":["record not found"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("GET", "/me", nil)
+
+			userMock.Mock.On("GetMyProfile", req).Once().Return(test.resp, test.Error)
+
+			con.GetMyProfile(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	userMock := new(mocks.UserService)
+	con := NewUserController(userMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.LoginResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp:   &dto.LoginResponse{Token: "access", RefreshToken: "refresh", ExpiresIn: 900},
+			want:   `{"status":"ok","result":{"token":"access","refresh_token":"refresh","expires_in":900}}`,
+		},
+		{
+			name:   "fail_reused_token",
+			Error:  e.NewError(e.ErrRefreshTokenReused, "refresh token was already used", errors.New("refresh token was already used")),
+			status: 401,
+			want:   `{"status":"notok","error":{"code":401002,"message":"failed to refresh token","details":["refresh token was already used"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/token/refresh", nil)
+
+			userMock.Mock.On("RefreshToken", req).Once().Return(test.resp, test.Error)
+
+			con.RefreshToken(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	userMock := new(mocks.UserService)
+	con := NewUserController(userMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.LogoutResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp:   &dto.LogoutResponse{LoggedOut: true},
+			want:   `{"status":"ok","result":{"logged_out":true}}`,
+		},
+		{
+			name:   "fail_logout",
+			Error:  e.NewError(e.ErrLogout, "error while revoking access token", errors.New("db error")),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400036,"message":"failed to logout","details":["db error"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/logout", nil)
+
+			userMock.Mock.On("Logout", req).Once().Return(test.resp, test.Error)
+
+			con.Logout(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestAssignRole(t *testing.T) {
+	userMock := new(mocks.UserService)
+	con := NewUserController(userMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.UserRoleResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp:   &dto.UserRoleResponse{UserID: 2, Role: "warehouse", Permissions: []string{"catalog:write", "orders:read"}},
+			want:   `{"status":"ok","result":{"userid":2,"role":"warehouse","permissions":["catalog:write","orders:read"]}}`,
+		},
+		{
+			name:   "fail_role_not_found",
+			Error:  e.NewError(e.ErrRoleNotFound, "role not found", errors.New("record not found")),
+			status: 404,
+			want:   `{"status":"notok","error":{"code":404007,"message":"failed to assign role","details":["record not found"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/admin/users/2/role", nil)
+
+			userMock.Mock.On("AssignRole", req).Once().Return(test.resp, test.Error)
+
+			con.AssignRole(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestVerifyMail(t *testing.T) {
+	userMock := new(mocks.UserService)
+	con := NewUserController(userMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.VerifyMailResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp:   &dto.VerifyMailResponse{UserID: 2, MailVerified: true},
+			want:   `{"status":"ok","result":{"userid":2,"mail_verified":true}}`,
+		},
+		{
+			name:   "fail_invalid_token",
+			Error:  e.NewError(e.ErrInvalidMailToken, "invalid verification token", errors.New("mail token is invalid, expired or already used")),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400043,"message":"failed to verify mail address","details":["mail token is invalid, expired or already used"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("POST", "/verify-email", nil)
+
+			userMock.Mock.On("VerifyMail", req).Once().Return(test.resp, test.Error)
+
+			con.VerifyMail(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	userMock := new(mocks.UserService)
+	con := NewUserController(userMock)
+
+	tests := []struct {
+		name   string
+		status int
+		want   string
+		resp   *dto.PasswordChangedResponse
+		Error  error
+	}{
+		{
+			name:   "success_case",
+			status: 200,
+			resp:   &dto.PasswordChangedResponse{UserID: 2, SessionsRevoked: true},
+			want:   `{"status":"ok","result":{"userid":2,"sessions_revoked":true}}`,
+		},
+		{
+			name:   "fail_wrong_password",
+			Error:  e.NewError(e.ErrWrongPassword, "current password is wrong", errors.New("current password is wrong")),
+			status: 400,
+			want:   `{"status":"notok","error":{"code":400046,"message":"failed to change password","details":["current password is wrong"]}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			res := httptest.NewRecorder()
+			req := httptest.NewRequest("PUT", "/me/password", nil)
+
+			userMock.Mock.On("ChangePassword", req).Once().Return(test.resp, test.Error)
+
+			con.ChangePassword(res, req)
+
+			assert.Equal(t, test.status, res.Code)
+			assert.Equal(t, test.want, res.Body.String())
+		})
+	}
+}
@@ -7,8 +7,9 @@ type User struct {
 	Username    string    `gorm:"column:username;unique;not null"`
 	Password    string    `gorm:"column:password;not null"`
 	Address     string    `gorm:"column:address;not null"`
-	Pincode     int64     `gorm:"column:pincode;not null"`
-	Phonenumber int64     `gorm:"column:phone_number; not null"`
+	Country     string    `gorm:"column:country;default:IN;not null"` // ISO 3166 alpha-2
+	Pincode     string    `gorm:"column:pincode;not null"`
+	Phonenumber string    `gorm:"column:phone_number; not null"` // E.164
 	CreatedAt   time.Time `gorm:"column:created_at;autoCreateTime"`
 	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime"`
 	// IsDeleted   bool       `gorm:"column:is_deleted;default:false"`
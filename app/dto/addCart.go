@@ -1,17 +1,17 @@
 package dto
 
 import (
-	"encoding/json"
 	"net/http"
 
-	"github.com/go-playground/validator"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
 )
 
 type AddItemToCart struct {
 	//UserID     int64 `json:"userid"`
 	CategoryID int64 `json:"category_id"`
-	Quantity   int64 `json:"quantity"`
-	BrandId    int64 `json:"brandid"`
+	Quantity   int64 `json:"quantity" validate:"required,gt=0"`
+	BrandId    int64 `json:"brandid" validate:"required"`
 }
 
 type CartItemResponse struct {
@@ -24,8 +24,7 @@ type CartItemResponse struct {
 }
 
 func (args *AddItemToCart) Parse(r *http.Request) error {
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&args)
+	err := api.DecodeJSON(r, args)
 	if err != nil {
 		return err
 	}
@@ -33,8 +32,7 @@ func (args *AddItemToCart) Parse(r *http.Request) error {
 }
 
 func (args *AddItemToCart) Validate() error {
-	validate := validator.New()
-	err := validate.Struct(args)
+	err := validation.Struct(args)
 	if err != nil {
 		return err
 	}
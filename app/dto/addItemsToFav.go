@@ -1,20 +1,19 @@
 package dto
 
 import (
-	"encoding/json"
 	"net/http"
 
-	"github.com/go-playground/validator"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
 )
 
 type UserFavoriteBrandRequest struct {
 	BrandID  int64 `json:"brandid" validate:"required"`
-	Favorite bool  `json:"favourite" validate:"required"`
+	Favorite bool  `json:"favourite"` // false is a valid value, so it can't be "required"
 }
 
 func (args *UserFavoriteBrandRequest) Parse(r *http.Request) error {
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&args)
+	err := api.DecodeJSON(r, args)
 	if err != nil {
 		return err
 	}
@@ -22,8 +21,7 @@ func (args *UserFavoriteBrandRequest) Parse(r *http.Request) error {
 }
 
 func (args *UserFavoriteBrandRequest) Validate() error {
-	validate := validator.New()
-	err := validate.Struct(args)
+	err := validation.Struct(args)
 	if err != nil {
 		return err
 	}
@@ -6,5 +6,6 @@ type BrandDetailResponse struct {
 	Price        float64 `json:"price" `
 	StockCount   int64   `json:"stockcount"`
 	CategoryID   int64   `json:"category_id"`
-	CategoryName string  `json:"categoryname"`
+	CategoryName string  `json:"categoryname,omitempty"`
+	ImageLink    string  `json:"imagelink"`
 }
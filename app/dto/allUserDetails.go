@@ -1,9 +1,14 @@
 package dto
 
 type AllUserDetails struct {
-	//UserID   int64  `json:"userid"`
-	UserName string `json:"username"`
-	Mail     string `json:"mail"`
-	Address  string `json:"address"`
-	Phone    int64  `json:"phonenumber"`
+	UserID       int64  `json:"userid"`
+	UserName     string `json:"username"`
+	Mail         string `json:"mail"`
+	MailVerified bool   `json:"mail_verified"`
+	Address      string `json:"address"`
+	Country      string `json:"country"`
+	Pincode      string `json:"pincode"`
+	Phone        string `json:"phonenumber"`
+	Status       bool   `json:"status"`
+	Role         string `json:"role"`
 }
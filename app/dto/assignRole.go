@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AssignRoleRequest gives a user another role, only the roles table decides
+// which names exist
+type AssignRoleRequest struct {
+	UserID int64  `json:"-"`
+	Role   string `json:"role" validate:"required,max=64"`
+}
+
+func (args *AssignRoleRequest) Parse(r *http.Request) error {
+	strID := chi.URLParam(r, "userid")
+	intID, err := strconv.Atoi(strID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID to assign a role to")
+	}
+
+	if err := api.DecodeJSON(r, args); err != nil {
+		return err
+	}
+	args.UserID = int64(intID)
+	args.Role = strings.ToLower(strings.TrimSpace(args.Role))
+
+	return nil
+}
+
+func (args *AssignRoleRequest) Validate() error {
+	err := validation.Struct(args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type UserRoleResponse struct {
+	UserID      int64    `json:"userid"`
+	Role        string   `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleDetails struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
@@ -22,3 +22,8 @@ func (args *BlockUserRequest) Parse(r *http.Request) error {
 
 	return nil
 }
+
+type UserStatusResponse struct {
+	UserID int64 `json:"userid"`
+	Status bool  `json:"status"`
+}
@@ -13,10 +13,10 @@ type SearchProductByNameRequest struct {
 }
 
 type CategoryDetailResponse struct {
-	CategoryID   int64                `json:"categoryid"`
-	CategoryName string               `json:"categoryname"`
-	Description  string               `json:"description"`
-	Brands       []BrandDetailRequest `json:"brands"`
+	CategoryID   int64                 `json:"categoryid"`
+	CategoryName string                `json:"categoryname"`
+	Description  string                `json:"description"`
+	Brands       []BrandDetailResponse `json:"brands"`
 }
 
 type BrandDetailResponses struct {
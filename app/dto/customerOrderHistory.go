@@ -1,13 +1,26 @@
 package dto
 
 import (
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+
+	"sonartest_cart/pkg/validation"
 
 	"github.com/go-chi/chi/v5"
 )
 
+const (
+	DefaultOrderHistoryLimit = 20
+	MaxOrderHistoryLimit     = 100
+
+	dateLayout = "2006-01-02"
+)
+
 type SearchByCustomerIdRequest struct {
 	UserId int64 `json:"id"`
 }
@@ -23,4 +36,128 @@ func (args *SearchByCustomerIdRequest) Parse(r *http.Request) error {
 	}
 	args.UserId = int64(intID)
 	return nil
-}
\ No newline at end of file
+}
+
+// OrderHistoryRequest holds the query params of the order history endpoints:
+// ?from=&to=&cursor=&limit=. from and to take a date (2006-01-02) or RFC3339
+// time, a plain date in "to" includes that whole day.
+type OrderHistoryRequest struct {
+	From   *time.Time   `json:"from"`
+	To     *time.Time   `json:"to"`
+	Cursor *OrderCursor `json:"cursor"`
+	Limit  int          `json:"limit" validate:"gte=1,lte=100"`
+}
+
+// OrderCursor points at the last order of the previous page
+type OrderCursor struct {
+	CreatedAt time.Time
+	OrderID   int64
+}
+
+func (args *OrderHistoryRequest) Parse(r *http.Request) error {
+	q := r.URL.Query()
+
+	if v := q.Get("from"); v != "" {
+		from, _, err := parseHistoryTime(v)
+		if err != nil {
+			return fmt.Errorf("invalid from: %w", err)
+		}
+		args.From = &from
+	}
+
+	if v := q.Get("to"); v != "" {
+		to, dateOnly, err := parseHistoryTime(v)
+		if err != nil {
+			return fmt.Errorf("invalid to: %w", err)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		args.To = &to
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := DecodeOrderCursor(v)
+		if err != nil {
+			return err
+		}
+		args.Cursor = cursor
+	}
+
+	args.Limit = DefaultOrderHistoryLimit
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid limit: %w", err)
+		}
+		args.Limit = limit
+	}
+
+	return nil
+}
+
+func (args *OrderHistoryRequest) Validate() error {
+	err := validation.Struct(args)
+	if err != nil {
+		return err
+	}
+	if args.From != nil && args.To != nil && !args.From.Before(*args.To) {
+		return errors.New("from must be before to")
+	}
+	return nil
+}
+
+func parseHistoryTime(v string) (time.Time, bool, error) {
+	if t, err := time.Parse(dateLayout, v); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return t, false, nil
+}
+
+// EncodeOrderCursor builds the opaque cursor handed out as next_cursor
+func EncodeOrderCursor(createdAt time.Time, orderID int64) string {
+	raw := fmt.Sprintf("%d:%d", createdAt.UnixNano(), orderID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeOrderCursor(cursor string) (*OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	parts := strings.Split(string(raw), ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	orderID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+	return &OrderCursor{
+		CreatedAt: time.Unix(0, nanos).UTC(),
+		OrderID:   orderID,
+	}, nil
+}
+
+type OrderHistoryItem struct {
+	OrderID       int64               `json:"order_id"`
+	Status        string              `json:"status"`
+	TotalPrice    float64             `json:"total_price"`
+	TotalQuantity int64               `json:"total_quantity"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Items         []OrderItemResponse `json:"items"`
+}
+
+type OrderHistoryResponse struct {
+	UserID     int64              `json:"userid"`
+	Orders     []OrderHistoryItem `json:"orders"`
+	NextCursor string             `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,91 @@
+package dto
+
+import (
+	"net/http"
+	"strings"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
+)
+
+// ForgotPasswordRequest asks for a password reset mail. The answer is the
+// same whether the user exists or not.
+type ForgotPasswordRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+func (args *ForgotPasswordRequest) Parse(r *http.Request) error {
+	err := api.DecodeJSON(r, args)
+	if err != nil {
+		return err
+	}
+	args.Username = strings.TrimSpace(args.Username)
+	return nil
+}
+
+func (args *ForgotPasswordRequest) Validate() error {
+	err := validation.Struct(args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type ForgotPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+// ResetPasswordRequest carries the token from the reset link and the new password
+type ResetPasswordRequest struct {
+	Token string `json:"token" validate:"required,max=128"`
+	// Password is checked against the password policy by the service
+	Password string `json:"password" validate:"required"`
+}
+
+func (args *ResetPasswordRequest) Parse(r *http.Request) error {
+	err := api.DecodeJSON(r, args)
+	if err != nil {
+		return err
+	}
+	args.Token = strings.TrimSpace(args.Token)
+	return nil
+}
+
+func (args *ResetPasswordRequest) Validate() error {
+	err := validation.Struct(args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// ChangePasswordRequest is sent by a logged in user, the current password
+// is required so a stolen token alone can't take over the account
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" validate:"required"`
+	// NewPassword is checked against the password policy by the service
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+func (args *ChangePasswordRequest) Parse(r *http.Request) error {
+	err := api.DecodeJSON(r, args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (args *ChangePasswordRequest) Validate() error {
+	err := validation.Struct(args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// PasswordChangedResponse answers a reset or change, every session of the
+// user has ended so they have to log in again
+type PasswordChangedResponse struct {
+	UserID          int64 `json:"userid"`
+	SessionsRevoked bool  `json:"sessions_revoked"`
+}
@@ -1,15 +1,17 @@
 package dto
 
 import (
-	"encoding/json"
 	"net/http"
 
-	"github.com/go-playground/validator"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
 )
 
+// PlaceOrderFromCart checks out the cart of the logged in user, a user only
+// ever has one cart so the body may be empty
 type PlaceOrderFromCart struct {
 	//UserID int64 `json:"userid"`
-	CartID int64 `json:""cartid"`
+	//CartID int64 `json:"cartid"`
 }
 
 // type ItemOrderedResponse struct {
@@ -29,8 +31,9 @@ type PlaceOrderFromCart struct {
 type UserDetailsResponse struct {
 	Username    string `json:"username"`
 	Address     string `json:"address"`
-	Pincode     int64  `json:"pincode"`
-	PhoneNumber int64  `json:"phone_number"`
+	Country     string `json:"country"`
+	Pincode     string `json:"pincode"`
+	PhoneNumber string `json:"phone_number"`
 	Email       string `json:"email"`
 }
 
@@ -50,8 +53,7 @@ type ItemOrderedResponse struct {
 }
 
 func (args *PlaceOrderFromCart) Parse(r *http.Request) error {
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&args)
+	err := api.DecodeOptionalJSON(r, args)
 	if err != nil {
 		return err
 	}
@@ -59,8 +61,7 @@ func (args *PlaceOrderFromCart) Parse(r *http.Request) error {
 }
 
 func (args *PlaceOrderFromCart) Validate() error {
-	validate := validator.New()
-	err := validate.Struct(args)
+	err := validation.Struct(args)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"net/http"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
+)
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (args *RefreshTokenRequest) Parse(r *http.Request) error {
+	err := api.DecodeJSON(r, args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (args *RefreshTokenRequest) Validate() error {
+	err := validation.Struct(args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// LogoutRequest optionally carries the refresh token so its whole family is
+// revoked too, the access token itself comes from the Authorization header
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (args *LogoutRequest) Parse(r *http.Request) error {
+	err := api.DecodeOptionalJSON(r, args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type LogoutResponse struct {
+	LoggedOut bool `json:"logged_out"`
+}
@@ -1,18 +1,18 @@
 package dto
 
 import (
-	"encoding/json"
 	"net/http"
 	"strings"
 
-	"github.com/go-playground/validator"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
 )
 
 type CreateCategoryDetailRequest struct {
 	CategoryID   int64                `json:"categoryid"`
 	CategoryName string               `json:"categoryname" validate:"required"`
 	Description  string               `json:"description"`
-	Brands       []BrandDetailRequest `json:"brands" validate:"required"`
+	Brands       []BrandDetailRequest `json:"brands" validate:"required,min=1,dive"`
 }
 
 type BrandDetailRequest struct {
@@ -41,8 +41,7 @@ type BrandResponse struct {
 }
 
 func (args *CreateCategoryDetailRequest) Parse(r *http.Request) error {
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&args)
+	err := api.DecodeJSON(r, args)
 	if err != nil {
 		return err
 	}
@@ -54,8 +53,7 @@ func (args *CreateCategoryDetailRequest) Parse(r *http.Request) error {
 }
 
 func (args *CreateCategoryDetailRequest) Validate() error {
-	validate := validator.New()
-	err := validate.Struct(args)
+	err := validation.Struct(args)
 	if err != nil {
 		return err
 	}
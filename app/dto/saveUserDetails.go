@@ -1,21 +1,33 @@
 package dto
 
 import (
-	"encoding/json"
 	"net/http"
+	"strings"
 
-	"github.com/go-playground/validator"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
 )
 
+// DefaultCountry is assumed when a request leaves out the country, the shop
+// started out in India
+const DefaultCountry = "IN"
+
+// UserDetailSaveRequest is the public signup, the id and role are never
+// taken from the body
 type UserDetailSaveRequest struct {
-	UserID   int64  `json:"userid"`
-	UserName string `json:"username" validate:"required"`
-	Mail     string `json:"mail" validate:"required"`
-	Address  string `json:"address" validate:"required"`
-	Pincode  int64  `json:"pincode" validate:"required"`
-	Phone    int64  `json:"phonenumber" validate:"required"`
+	UserName string `json:"username" validate:"required,username"`
+	Mail     string `json:"mail" validate:"required,email,max=254"`
+	Address  string `json:"address" validate:"required,max=500"`
+	Country  string `json:"country" validate:"required,country"`
+	Pincode  string `json:"pincode" validate:"required,postcode=Country"`
+	Phone    string `json:"phonenumber" validate:"required,e164"`
+	// Password is checked against the password policy by the service
 	Password string `json:"password" validate:"required"`
-	IsAdmin  bool   `json:"isadmin"`
+	// Role is set by the service, signups are always customers
+	Role string `json:"-"`
+	// MailVerified is only set by operators creating users, signups verify
+	// their mail address by following a link
+	MailVerified bool `json:"-"`
 }
 
 type SaveUserResponse struct {
@@ -23,19 +35,35 @@ type SaveUserResponse struct {
 }
 
 func (args *UserDetailSaveRequest) Parse(r *http.Request) error {
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&args)
+	err := api.DecodeJSON(r, args)
 	if err != nil {
 		return err
 	}
+	args.UserName = strings.TrimSpace(args.UserName)
+	args.Mail = strings.TrimSpace(args.Mail)
+	args.Country, args.Pincode, args.Phone = normalizeContact(args.Country, args.Pincode, args.Phone)
 	return nil
 }
 
 func (args *UserDetailSaveRequest) Validate() error {
-	validate := validator.New()
-	err := validate.Struct(args)
+	err := validation.Struct(args)
 	if err != nil {
 		return err
 	}
 	return nil
 }
+
+// phoneFormatting is what people put between the digits of a phone number
+var phoneFormatting = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+// normalizeContact brings country, postal code and phone number into the
+// form they are validated and stored in
+func normalizeContact(country, pincode, phone string) (string, string, string) {
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country == "" {
+		country = DefaultCountry
+	}
+	pincode = strings.ToUpper(strings.TrimSpace(pincode))
+	phone = phoneFormatting.Replace(strings.TrimSpace(phone))
+	return country, pincode, phone
+}
@@ -1,19 +1,24 @@
 package dto
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
 
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
+
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator"
 )
 
+// UpdateBrand only changes the fields that were sent, price and stock are
+// pointers so they can be set to 0
 type UpdateBrand struct {
-	BrandId   int64   `json:"brand_id"`
-	BrandName string  `json:"brand_name"`
-	Price     float64 `json:"price"`
+	BrandId    int64    `json:"brand_id"`
+	BrandName  string   `json:"brand_name"`
+	Price      *float64 `json:"price" validate:"omitempty,gte=0"`
+	StockCount *int64   `json:"stock_count" validate:"omitempty,gte=0"`
+	ImageLink  string   `json:"imagelink"`
 }
 
 func (args *UpdateBrand) Parse(r *http.Request) error {
@@ -29,21 +34,23 @@ func (args *UpdateBrand) Parse(r *http.Request) error {
 		return fmt.Errorf("invalid id: %v", err)
 	}
 
-	// Store the parsed ID into your struct if needed
-	args.BrandId = int64(intID)
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&args)
+	err = api.DecodeJSON(r, args)
 	if err != nil {
 		return err
 	}
+
+	// Store the parsed ID after decoding so the body cannot override the path
+	args.BrandId = int64(intID)
 	return nil
 }
 
 func (args *UpdateBrand) Validate() error {
-	validate := validator.New()
-	err := validate.Struct(args)
+	err := validation.Struct(args)
 	if err != nil {
 		return err
 	}
+	if args.BrandName == "" && args.Price == nil && args.StockCount == nil && args.ImageLink == "" {
+		return fmt.Errorf("nothing to update")
+	}
 	return nil
 }
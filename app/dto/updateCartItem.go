@@ -0,0 +1,56 @@
+package dto
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type UpdateCartItemRequest struct {
+	BrandID  int64 `json:"brandid"`
+	Quantity int64 `json:"quantity" validate:"required,gt=0"`
+}
+
+type RemoveCartItemRequest struct {
+	BrandID int64 `json:"brandid"`
+}
+
+func (args *UpdateCartItemRequest) Parse(r *http.Request) error {
+	strID := chi.URLParam(r, "brandid")
+	intID, err := strconv.Atoi(strID)
+	if err != nil {
+		return fmt.Errorf("invalid brand id: %v", err)
+	}
+
+	err = api.DecodeJSON(r, args)
+	if err != nil {
+		return err
+	}
+	// the path wins over anything sent in the body
+	args.BrandID = int64(intID)
+	return nil
+}
+
+func (args *UpdateCartItemRequest) Validate() error {
+	err := validation.Struct(args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (args *RemoveCartItemRequest) Parse(r *http.Request) error {
+	strID := chi.URLParam(r, "brandid")
+	intID, err := strconv.Atoi(strID)
+	if err != nil {
+		return fmt.Errorf("invalid brand id: %v", err)
+	}
+	args.BrandID = int64(intID)
+
+	return nil
+}
@@ -1,18 +1,20 @@
 package dto
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator"
 )
 
 type UpdateCategory struct {
 	CategoryID   int64  `json:"category_id"`
-	CategoryName string `json:"categoryname"`
+	CategoryName string `json:"categoryname" validate:"required"`
 }
 
 func (args *UpdateCategory) Parse(r *http.Request) error {
@@ -28,19 +30,21 @@ func (args *UpdateCategory) Parse(r *http.Request) error {
 		return fmt.Errorf("invalid id: %v", err)
 	}
 
-	// Store the parsed ID into your struct if needed
-	args.CategoryID = int64(intID)
-	decoder := json.NewDecoder(r.Body)
-	err = decoder.Decode(&args)
+	err = api.DecodeJSON(r, args)
 	if err != nil {
 		return err
 	}
+
+	// Store the parsed ID after decoding so the body cannot override the path
+	args.CategoryID = int64(intID)
+
+	// category names are stored upper-cased, same as on create
+	args.CategoryName = strings.ToUpper(args.CategoryName)
 	return nil
 }
 
 func (args *UpdateCategory) Validate() error {
-	validate := validator.New()
-	err := validate.Struct(args)
+	err := validation.Struct(args)
 	if err != nil {
 		return err
 	}
@@ -1,22 +1,25 @@
 package dto
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator"
 )
 
+// UpdateUserDetailRequest is used by admins to update a user's profile, passwords are not changed here
 type UpdateUserDetailRequest struct {
 	UserID   int64  `json:"userid"`
-	UserName string `json:"username" validate:"required"`
-	Mail     string `json:"mail" validate:"required"`
-	Address  string `json:"address" validate:"required"`
-	Pincode  int64  `json:"pincode" validate:"required"`
-	Phone    int64  `json:"phonenumber" validate:"required"`
-	Password string `json:"password" validate:"required"`
+	UserName string `json:"username" validate:"required,username"`
+	Mail     string `json:"mail" validate:"required,email,max=254"`
+	Address  string `json:"address" validate:"required,max=500"`
+	Country  string `json:"country" validate:"required,country"`
+	Pincode  string `json:"pincode" validate:"required,postcode=Country"`
+	Phone    string `json:"phonenumber" validate:"required,e164"`
 }
 
 func (args *UpdateUserDetailRequest) Parse(r *http.Request) error {
@@ -25,18 +28,22 @@ func (args *UpdateUserDetailRequest) Parse(r *http.Request) error {
 	if err != nil {
 		return err
 	}
-	args.UserID = int64(intID)
 
-	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+	if err := api.DecodeJSON(r, args); err != nil {
 		return err
 	}
+	// the path decides which user is updated
+	args.UserID = int64(intID)
+
+	args.UserName = strings.TrimSpace(args.UserName)
+	args.Mail = strings.TrimSpace(args.Mail)
+	args.Country, args.Pincode, args.Phone = normalizeContact(args.Country, args.Pincode, args.Phone)
 
 	return nil
 }
 
 func (args *UpdateUserDetailRequest) Validate() error {
-	validate := validator.New()
-	err := validate.Struct(args)
+	err := validation.Struct(args)
 	if err != nil {
 		return err
 	}
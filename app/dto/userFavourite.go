@@ -7,3 +7,8 @@ type FavoriteBrandResponse struct {
 	Stock     int64   `json:"stock"`
 	ImageLink string  `json:"Image_link"`
 }
+
+type FavoriteToggleResponse struct {
+	BrandID  int64 `json:"brand_id"`
+	Favorite bool  `json:"favourite"`
+}
@@ -1,10 +1,11 @@
 package dto
 
 import (
-	"encoding/json"
 	"net/http"
+	"strings"
 
-	"github.com/go-playground/validator"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
 )
 
 type LoginRequest struct {
@@ -13,21 +14,23 @@ type LoginRequest struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"` // access token lifetime in seconds
 }
 
 func (args *LoginRequest) Parse(r *http.Request) error {
-	decoder := json.NewDecoder(r.Body)
-	err := decoder.Decode(&args)
+	err := api.DecodeJSON(r, args)
 	if err != nil {
 		return err
 	}
+	// looked up and locked out the way it was stored at signup
+	args.Username = strings.TrimSpace(args.Username)
 	return nil
 }
 
 func (args *LoginRequest) Validate() error {
-	validate := validator.New()
-	err := validate.Struct(args)
+	err := validation.Struct(args)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"net/http"
+	"strings"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/validation"
+)
+
+// VerifyMailRequest carries the token from the verification link
+type VerifyMailRequest struct {
+	Token string `json:"token" validate:"required,max=128"`
+}
+
+func (args *VerifyMailRequest) Parse(r *http.Request) error {
+	err := api.DecodeJSON(r, args)
+	if err != nil {
+		return err
+	}
+	args.Token = strings.TrimSpace(args.Token)
+	return nil
+}
+
+func (args *VerifyMailRequest) Validate() error {
+	err := validation.Struct(args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type VerifyMailResponse struct {
+	UserID       int64 `json:"userid"`
+	MailVerified bool  `json:"mail_verified"`
+}
+
+// ResendVerificationRequest asks for a new verification mail. The answer is
+// the same whether the user exists or not.
+type ResendVerificationRequest struct {
+	Username string `json:"username" validate:"required"`
+}
+
+func (args *ResendVerificationRequest) Parse(r *http.Request) error {
+	err := api.DecodeJSON(r, args)
+	if err != nil {
+		return err
+	}
+	args.Username = strings.TrimSpace(args.Username)
+	return nil
+}
+
+func (args *ResendVerificationRequest) Validate() error {
+	err := validation.Struct(args)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type ResendVerificationResponse struct {
+	Message string `json:"message"`
+}
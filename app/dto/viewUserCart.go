@@ -7,3 +7,10 @@ type ViewCart struct {
 	BrandName   string  `json:"brandname"`
 	TotalAmount float64 `json:"totalamount"`
 }
+
+type ViewCartResponse struct {
+	UserID        int64      `json:"userid"`
+	Items         []ViewCart `json:"items"`
+	TotalQuantity int64      `json:"totalquantity"`
+	TotalAmount   float64    `json:"totalamount"`
+}
@@ -1,55 +1,156 @@
 package gormdb
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"time"
 
-	"github.com/joho/godotenv"
+	"sonartest_cart/pkg/config"
+
+	"github.com/rs/zerolog/log"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
-func ConnectDb() (*gorm.DB, error) {
+const (
+	OpConnect     = "connect"
+	OpSchemaCheck = "schema check"
+)
 
-	// Load environment variables from .env file
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatalf("Error loading .env file: %v", err)
+// ConnectError is returned when the database can't be used. Err is the last
+// underlying error, it never contains the DSN or the password.
+type ConnectError struct {
+	Op       string
+	Attempts int
+	Err      error
+}
+
+func (e *ConnectError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("database %s failed after %d attempts: %v", e.Op, e.Attempts, e.Err)
 	}
+	return fmt.Sprintf("database %s failed: %v", e.Op, e.Err)
+}
 
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	dbname := os.Getenv("DB_NAME")
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
 
-	dsn := fmt.Sprintf("user=%s password=%s host=%s port=%s dbname=%s sslmode=disable", user, password, host, port, dbname)
-	fmt.Println("Connection string:", dsn)
+// dialector is swapped in tests
+var dialector = func(dsn string) gorm.Dialector {
+	return postgres.Open(dsn)
+}
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// ConnectDb opens the database and refuses to start when the schema is not
+// at the version this build expects, run `migrate up` first.
+func ConnectDb(ctx context.Context, cfg config.DBConfig) (*gorm.DB, error) {
+	db, err := Open(ctx, cfg)
 	if err != nil {
-		log.Fatal("connection error", err)
+		return nil, err
 	}
 
-	err1 := Automigration(db)
-	if err1 != nil {
-		log.Fatalf("error on automigration %v", err1)
+	migrator, err := NewMigrator(db)
+	if err == nil {
+		err = migrator.CheckVersion(ctx)
+	}
+	if err != nil {
+		Close(db)
+		return nil, &ConnectError{Op: OpSchemaCheck, Attempts: 1, Err: err}
+	}
+
+	return db, nil
+}
+
+// Open connects to the database without looking at the schema. It retries
+// with exponential backoff so the service can start alongside the database.
+func Open(ctx context.Context, cfg config.DBConfig) (*gorm.DB, error) {
+	attempts := cfg.ConnectAttempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		log.Info().Str("database", cfg.Name).Str("host", cfg.Host).Int("port", cfg.Port).
+			Msgf("Connecting to database (attempt %d/%d)", attempt, attempts)
+
+		db, err := open(ctx, cfg)
+		if err == nil {
+			log.Info().Str("database", cfg.Name).Msg("Connected to database")
+			return db, nil
+		}
+		lastErr = err
+
+		if attempt == attempts {
+			break
+		}
+		wait := backoff(attempt, cfg.ConnectBackoff, cfg.ConnectMaxBackoff)
+		log.Warn().Err(err).Dur("retry_in", wait).Msg("Database not reachable, retrying")
+
+		select {
+		case <-ctx.Done():
+			return nil, &ConnectError{Op: OpConnect, Attempts: attempt, Err: ctx.Err()}
+		case <-time.After(wait):
+		}
+	}
+	return nil, &ConnectError{Op: OpConnect, Attempts: attempts, Err: lastErr}
+}
+
+func open(ctx context.Context, cfg config.DBConfig) (*gorm.DB, error) {
+	gormCfg := gormConfig()
+	gormCfg.DisableAutomaticPing = true
+	db, err := gorm.Open(dialector(cfg.DSN()), gormCfg)
+	if err != nil {
+		return nil, err
 	}
-	log.Println("Migration successfully completed")
 
 	sqlDb, err := db.DB()
 	if err != nil {
-		log.Fatal("connection error", err)
+		return nil, err
 	}
+	configurePool(sqlDb, cfg)
 
 	// Test the connection
-	err = sqlDb.Ping()
-	if err != nil {
-		log.Fatalf("Failed to connect to the database: %v\n", err)
+	if err := sqlDb.PingContext(ctx); err != nil {
+		sqlDb.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// gormConfig is shared by every driver: errors are translated to gorm's
+// sentinel errors and every statement is traced
+func gormConfig() *gorm.Config {
+	tracing := NewTracingPlugin()
+	return &gorm.Config{
+		TranslateError: true,
+		Plugins:        map[string]gorm.Plugin{tracing.Name(): tracing},
 	}
+}
 
-	fmt.Println("Successfully connected to the database!!!")
+func configurePool(sqlDb *sql.DB, cfg config.DBConfig) {
+	sqlDb.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDb.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDb.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDb.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+}
 
-	return db, nil
+// backoff doubles the wait after every failed attempt, capped at max
+func backoff(attempt int, initial, max time.Duration) time.Duration {
+	wait := initial
+	for i := 1; i < attempt && wait < max; i++ {
+		wait *= 2
+	}
+	if max > 0 && wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// Close releases the connection pool
+func Close(db *gorm.DB) {
+	if sqlDb, err := db.DB(); err == nil {
+		sqlDb.Close()
+	}
 }
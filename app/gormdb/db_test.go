@@ -0,0 +1,121 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"sonartest_cart/pkg/config"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// failingPings swaps the dialector for sqlmock connections whose ping fails
+// for the first failures attempts
+func failingPings(t *testing.T, failures int) *int {
+	calls := 0
+	orig := dialector
+	t.Cleanup(func() { dialector = orig })
+
+	dialector = func(dsn string) gorm.Dialector {
+		calls++
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		if calls <= failures {
+			mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+		} else {
+			mock.ExpectPing()
+		}
+		return postgres.New(postgres.Config{Conn: db})
+	}
+	return &calls
+}
+
+func testDBConfig() config.DBConfig {
+	return config.DBConfig{
+		Host:              "localhost",
+		Port:              5432,
+		User:              "postgres",
+		Password:          "hunter2",
+		Name:              "cart",
+		SSLMode:           "disable",
+		MaxOpenConns:      7,
+		MaxIdleConns:      3,
+		ConnectAttempts:   3,
+		ConnectBackoff:    time.Millisecond,
+		ConnectMaxBackoff: 2 * time.Millisecond,
+	}
+}
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "first_attempt", failures: 0, wantCalls: 1},
+		{name: "retries_until_reachable", failures: 2, wantCalls: 3},
+		{name: "gives_up", failures: 3, wantCalls: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := failingPings(t, tt.failures)
+
+			db, err := Open(context.Background(), testDBConfig())
+			assert.Equal(t, tt.wantCalls, *calls)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				sqlDb, err := db.DB()
+				require.NoError(t, err)
+				assert.Equal(t, 7, sqlDb.Stats().MaxOpenConnections)
+				return
+			}
+
+			var connErr *ConnectError
+			require.ErrorAs(t, err, &connErr)
+			assert.Equal(t, OpConnect, connErr.Op)
+			assert.Equal(t, 3, connErr.Attempts)
+			assert.EqualError(t, err, "database connect failed after 3 attempts: connection refused")
+			assert.NotContains(t, err.Error(), "hunter2")
+		})
+	}
+}
+
+func TestOpenCancelled(t *testing.T) {
+	failingPings(t, 10)
+	cfg := testDBConfig()
+	cfg.ConnectBackoff = time.Hour
+	cfg.ConnectMaxBackoff = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	_, err := Open(ctx, cfg)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 1, want: 100 * time.Millisecond},
+		{attempt: 2, want: 200 * time.Millisecond},
+		{attempt: 4, want: 800 * time.Millisecond},
+		{attempt: 5, want: time.Second},
+		{attempt: 50, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, backoff(tt.attempt, 100*time.Millisecond, time.Second), "attempt %d", tt.attempt)
+	}
+}
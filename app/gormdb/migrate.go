@@ -0,0 +1,365 @@
+package gormdb
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var embeddedMigrations embed.FS
+
+// MigrationsDir is where `migrate create` writes new files, relative to the repo root
+const MigrationsDir = "app/gormdb/migrations"
+
+// migrationLockKey is the pg_advisory_xact_lock key, any constant unique to this service works
+const migrationLockKey int64 = 7_263_540_133
+
+var (
+	ErrSchemaOutdated  = errors.New("database schema is outdated, run `migrate up`")
+	ErrSchemaTooNew    = errors.New("database schema is newer than this build")
+	ErrIrreversible    = errors.New("migration has no down step")
+	ErrNothingToRevert = errors.New("no applied migrations to revert")
+)
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one versioned schema change. SQL migrations come from the
+// migrations directory, Go migrations are listed in goMigrations.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error // nil when the migration can't be reverted
+}
+
+// MigrationStatus tells whether a migration has been applied
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Unknown   bool // applied in the database but not part of this build
+}
+
+type schemaMigration struct {
+	Version   int64     `gorm:"column:version;primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// goMigrations holds migrations that can't be expressed in plain SQL,
+// e.g. data backfills. They share the version sequence with the SQL files.
+var goMigrations []Migration
+
+// Migrator applies and reverts migrations. Every run happens in a single
+// transaction holding an advisory lock, so concurrent replicas wait for each
+// other and a failed migration leaves nothing half applied.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a Migrator for the embedded SQL and the Go migrations
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(embeddedMigrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations = append(migrations, goMigrations...)
+	return newMigrator(db, migrations)
+}
+
+func newMigrator(db *gorm.DB, migrations []Migration) (*Migrator, error) {
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].Version == migrations[i-1].Version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].Version)
+		}
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LoadMigrations reads <version>_<name>.up.sql and .down.sql pairs from dir
+func LoadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has files named %q and %q", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = sqlStep(string(data))
+		} else {
+			m.Down = sqlStep(string(data))
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d_%s has no up file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func sqlStep(sql string) func(tx *gorm.DB) error {
+	return func(tx *gorm.DB) error {
+		return tx.Exec(sql).Error
+	}
+}
+
+// Up applies every pending migration and returns the ones it applied
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(tx *gorm.DB, done map[int64]schemaMigration) error {
+		for _, mg := range m.migrations {
+			if _, ok := done[mg.Version]; ok {
+				continue
+			}
+			if err := mg.Up(tx); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mg.Version, mg.Name, err)
+			}
+			row := schemaMigration{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now()}
+			if err := tx.Create(&row).Error; err != nil {
+				return err
+			}
+			applied = append(applied, mg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return applied, nil
+}
+
+// Down reverts the last n applied migrations, newest first
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("down needs a positive count, got %d", n)
+	}
+
+	known := make(map[int64]Migration, len(m.migrations))
+	for _, mg := range m.migrations {
+		known[mg.Version] = mg
+	}
+
+	var reverted []Migration
+	err := m.locked(ctx, func(tx *gorm.DB, done map[int64]schemaMigration) error {
+		versions := make([]int64, 0, len(done))
+		for v := range done {
+			versions = append(versions, v)
+		}
+		if len(versions) == 0 {
+			return ErrNothingToRevert
+		}
+		sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+		if n > len(versions) {
+			n = len(versions)
+		}
+
+		for _, v := range versions[:n] {
+			mg, ok := known[v]
+			if !ok {
+				return fmt.Errorf("%w: version %d", ErrSchemaTooNew, v)
+			}
+			if mg.Down == nil {
+				return fmt.Errorf("%w: %d_%s", ErrIrreversible, mg.Version, mg.Name)
+			}
+			if err := mg.Down(tx); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", mg.Version, mg.Name, err)
+			}
+			if err := tx.Where("version = ?", v).Delete(&schemaMigration{}).Error; err != nil {
+				return err
+			}
+			reverted = append(reverted, mg)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reverted, nil
+}
+
+// Status lists every known migration and any unknown applied version
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	db := m.db.WithContext(ctx)
+
+	done := map[int64]schemaMigration{}
+	if db.Migrator().HasTable(&schemaMigration{}) {
+		var err error
+		if done, err = m.appliedVersions(db); err != nil {
+			return nil, err
+		}
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		s := MigrationStatus{Version: mg.Version, Name: mg.Name}
+		if row, ok := done[mg.Version]; ok {
+			appliedAt := row.AppliedAt
+			s.AppliedAt = &appliedAt
+			delete(done, mg.Version)
+		}
+		statuses = append(statuses, s)
+	}
+	for _, row := range done {
+		appliedAt := row.AppliedAt
+		statuses = append(statuses, MigrationStatus{Version: row.Version, Name: row.Name, AppliedAt: &appliedAt, Unknown: true})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// SchemaVersion is the newest applied migration and the newest one this build knows
+type SchemaVersion struct {
+	Current int64 `json:"current"`
+	Latest  int64 `json:"latest"`
+}
+
+// Version reports the schema version of the database
+func (m *Migrator) Version(ctx context.Context) (SchemaVersion, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return SchemaVersion{}, err
+	}
+
+	var v SchemaVersion
+	for _, s := range statuses {
+		if s.AppliedAt != nil && s.Version > v.Current {
+			v.Current = s.Version
+		}
+	}
+	if len(m.migrations) > 0 {
+		v.Latest = m.migrations[len(m.migrations)-1].Version
+	}
+	return v, nil
+}
+
+// CheckVersion makes sure the database is exactly at the version this build expects
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	for _, s := range statuses {
+		if s.Unknown {
+			return fmt.Errorf("%w: version %d_%s is applied", ErrSchemaTooNew, s.Version, s.Name)
+		}
+		if s.AppliedAt == nil {
+			return fmt.Errorf("%w: version %d_%s is pending", ErrSchemaOutdated, s.Version, s.Name)
+		}
+	}
+	return nil
+}
+
+// locked runs fn in a transaction that holds the migration lock
+func (m *Migrator) locked(ctx context.Context, fn func(tx *gorm.DB, done map[int64]schemaMigration) error) error {
+	return m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockKey).Error; err != nil {
+			return fmt.Errorf("acquiring migration lock: %w", err)
+		}
+		if err := tx.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+	version    bigint PRIMARY KEY,
+	name       text NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`).Error; err != nil {
+			return err
+		}
+		// read after taking the lock, another replica may have just migrated
+		done, err := m.appliedVersions(tx)
+		if err != nil {
+			return err
+		}
+		return fn(tx, done)
+	})
+}
+
+func (m *Migrator) appliedVersions(db *gorm.DB) (map[int64]schemaMigration, error) {
+	var rows []schemaMigration
+	if err := db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	done := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		done[row.Version] = row
+	}
+	return done, nil
+}
+
+// CreateMigration writes an empty up/down pair numbered after the newest file in dir
+func CreateMigration(dir, name string) ([]string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(name, "_")
+	name = strings.Trim(name, "_")
+	if name == "" {
+		return nil, errors.New("migration name is required")
+	}
+
+	migrations, err := LoadMigrations(os.DirFS(dir), ".")
+	if err != nil {
+		return nil, err
+	}
+	var version int64 = 1
+	if len(migrations) > 0 {
+		version = migrations[len(migrations)-1].Version + 1
+	}
+	for _, mg := range goMigrations {
+		if mg.Version >= version {
+			version = mg.Version + 1
+		}
+	}
+
+	var files []string
+	for _, direction := range []string{"up", "down"} {
+		file := filepath.Join(dir, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+		content := fmt.Sprintf("-- %04d_%s %s\n", version, name, direction)
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
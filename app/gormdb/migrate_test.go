@@ -0,0 +1,399 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"sonartest_cart/pkg/rbac"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+	return gdb, mock
+}
+
+// execMigration runs a single statement in each direction
+func execMigration(version int64, name string) Migration {
+	return Migration{
+		Version: version,
+		Name:    name,
+		Up:      sqlStep(fmt.Sprintf("CREATE TABLE t%d (id int)", version)),
+		Down:    sqlStep(fmt.Sprintf("DROP TABLE t%d", version)),
+	}
+}
+
+func expectLock(mock sqlmock.Sqlmock, applied ...int64) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`^SELECT pg_advisory_xact_lock\(\$1\)$`).
+		WithArgs(migrationLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`^CREATE TABLE IF NOT EXISTS schema_migrations`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+	for _, v := range applied {
+		rows.AddRow(v, fmt.Sprintf("m%d", v), time.Now())
+	}
+	mock.ExpectQuery(`^SELECT \* FROM "schema_migrations" ORDER BY version$`).WillReturnRows(rows)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []int64
+		wantErr string
+	}{
+		{
+			name: "pairs_sorted_by_version",
+			files: fstest.MapFS{
+				"m/0010_add_index.up.sql":    {Data: []byte("CREATE INDEX")},
+				"m/0002_users.up.sql":        {Data: []byte("CREATE TABLE")},
+				"m/0002_users.down.sql":      {Data: []byte("DROP TABLE")},
+				"m/README.md":                {Data: []byte("ignored")},
+				"m/0010_add_index.down.sql":  {Data: []byte("DROP INDEX")},
+				"m/0011_backfill.up.sql.bak": {Data: []byte("ignored")},
+			},
+			want: []int64{2, 10},
+		},
+		{
+			name:    "down_without_up",
+			files:   fstest.MapFS{"m/0001_users.down.sql": {Data: []byte("DROP TABLE")}},
+			wantErr: "migration 1_users has no up file",
+		},
+		{
+			name: "mismatched_names",
+			files: fstest.MapFS{
+				"m/0001_users.up.sql":   {Data: []byte("CREATE TABLE")},
+				"m/0001_members.up.sql": {Data: []byte("CREATE TABLE")},
+				"m/0001_users.down.sql": {Data: []byte("DROP TABLE")},
+			},
+			wantErr: "migration 1 has files named",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadMigrations(tt.files, "m")
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			var versions []int64
+			for _, m := range got {
+				versions = append(versions, m.Version)
+				assert.NotNil(t, m.Up)
+				assert.NotNil(t, m.Down)
+			}
+			assert.Equal(t, tt.want, versions)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := LoadMigrations(embeddedMigrations, "migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+	assert.Equal(t, int64(1), migrations[0].Version)
+	assert.Equal(t, "baseline", migrations[0].Name)
+
+	baseline, err := embeddedMigrations.ReadFile("migrations/0001_baseline.up.sql")
+	require.NoError(t, err)
+	for _, table := range []string{"userdetails", "categories", "brands", "cartitems", "orders", "orderitems", "favouritebrands", "refreshtokens", "revokedtokens"} {
+		assert.Contains(t, string(baseline), "CREATE TABLE IF NOT EXISTS "+table+" (")
+	}
+	assert.Contains(t, string(baseline), "idx_orders_user_created ON orders (user_id, created_at)")
+}
+
+func TestEmbeddedContactMigration(t *testing.T) {
+	migrations, err := LoadMigrations(embeddedMigrations, "migrations")
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, len(migrations), 2)
+	assert.Equal(t, int64(2), migrations[1].Version)
+	assert.Equal(t, "user_contact_strings", migrations[1].Name)
+
+	up, err := embeddedMigrations.ReadFile("migrations/0002_user_contact_strings.up.sql")
+	require.NoError(t, err)
+	assert.Contains(t, string(up), "ALTER COLUMN pincode TYPE text")
+	assert.Contains(t, string(up), "ALTER COLUMN phone_number TYPE text")
+	assert.Contains(t, string(up), "ADD COLUMN IF NOT EXISTS country")
+}
+
+func TestEmbeddedRolesMigration(t *testing.T) {
+	up, err := embeddedMigrations.ReadFile("migrations/0003_roles.up.sql")
+	require.NoError(t, err)
+
+	// the grants of a new postgres database have to match what sqlite is seeded with
+	for _, role := range rbac.DefaultRoles {
+		assert.Contains(t, string(up), fmt.Sprintf("('%s', '%s')", role.Name, role.Description))
+		for _, p := range role.Permissions {
+			assert.Contains(t, string(up), fmt.Sprintf("('%s', '%s')", role.Name, p))
+		}
+	}
+	assert.Contains(t, string(up), "UPDATE userdetails SET role = 'admin' WHERE isadmin")
+	assert.Contains(t, string(up), "DROP COLUMN isadmin")
+}
+
+func TestEmbeddedMailVerificationMigration(t *testing.T) {
+	up, err := embeddedMigrations.ReadFile("migrations/0004_mail_verification.up.sql")
+	require.NoError(t, err)
+
+	// existing users must not be locked out by the new login policy
+	assert.Contains(t, string(up), "UPDATE userdetails SET mail_verified_at = now()")
+	assert.Contains(t, string(up), "CREATE TABLE IF NOT EXISTS mailtokens (")
+	assert.Contains(t, string(up), "idx_mailtokens_token_hash ON mailtokens (token_hash)")
+}
+
+func TestEmbeddedPasswordChangeMigration(t *testing.T) {
+	up, err := embeddedMigrations.ReadFile("migrations/0005_password_change.up.sql")
+	require.NoError(t, err)
+	assert.Contains(t, string(up), "ADD COLUMN password_changed_at timestamptz")
+	assert.Contains(t, string(up), "CREATE TABLE IF NOT EXISTS auditlogs (")
+
+	down, err := embeddedMigrations.ReadFile("migrations/0005_password_change.down.sql")
+	require.NoError(t, err)
+	assert.Contains(t, string(down), "DROP COLUMN password_changed_at")
+}
+
+func TestMigratorUp(t *testing.T) {
+	db, mock := newMockDB(t)
+	m, err := newMigrator(db, []Migration{execMigration(2, "m2"), execMigration(1, "m1"), execMigration(3, "m3")})
+	require.NoError(t, err)
+
+	t.Run("applies_pending_in_order", func(t *testing.T) {
+		expectLock(mock, 1)
+		for _, v := range []int64{2, 3} {
+			mock.ExpectExec(fmt.Sprintf(`^CREATE TABLE t%d`, v)).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectExec(`^INSERT INTO "schema_migrations" \("version","name","applied_at"\) VALUES \(\$1,\$2,\$3\)$`).
+				WithArgs(v, fmt.Sprintf("m%d", v), sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectCommit()
+
+		applied, err := m.Up(context.Background())
+		require.NoError(t, err)
+		require.Len(t, applied, 2)
+		assert.Equal(t, int64(2), applied[0].Version)
+		assert.Equal(t, int64(3), applied[1].Version)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("failure_rolls_back", func(t *testing.T) {
+		expectLock(mock, 1)
+		mock.ExpectExec(`^CREATE TABLE t2`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`^INSERT INTO "schema_migrations"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`^CREATE TABLE t3`).WillReturnError(errors.New("syntax error"))
+		mock.ExpectRollback()
+
+		applied, err := m.Up(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "migration 3_m3: syntax error")
+		assert.Nil(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("up_to_date", func(t *testing.T) {
+		expectLock(mock, 1, 2, 3)
+		mock.ExpectCommit()
+
+		applied, err := m.Up(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigratorDown(t *testing.T) {
+	irreversible := execMigration(3, "m3")
+	irreversible.Down = nil
+
+	tests := []struct {
+		name       string
+		migrations []Migration
+		applied    []int64
+		n          int
+		mock       func(mock sqlmock.Sqlmock)
+		want       []int64
+		wantErr    error
+	}{
+		{
+			name:       "reverts_newest_first",
+			migrations: []Migration{execMigration(1, "m1"), execMigration(2, "m2"), execMigration(3, "m3")},
+			applied:    []int64{1, 2, 3},
+			n:          2,
+			mock: func(mock sqlmock.Sqlmock) {
+				for _, v := range []int64{3, 2} {
+					mock.ExpectExec(fmt.Sprintf(`^DROP TABLE t%d$`, v)).WillReturnResult(sqlmock.NewResult(0, 0))
+					mock.ExpectExec(`^DELETE FROM "schema_migrations" WHERE version = \$1$`).
+						WithArgs(v).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+				mock.ExpectCommit()
+			},
+			want: []int64{3, 2},
+		},
+		{
+			name:       "irreversible",
+			migrations: []Migration{execMigration(1, "m1"), irreversible},
+			applied:    []int64{1, 3},
+			n:          1,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectRollback()
+			},
+			wantErr: ErrIrreversible,
+		},
+		{
+			name:       "unknown_version",
+			migrations: []Migration{execMigration(1, "m1")},
+			applied:    []int64{1, 9},
+			n:          1,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectRollback()
+			},
+			wantErr: ErrSchemaTooNew,
+		},
+		{
+			name:       "nothing_applied",
+			migrations: []Migration{execMigration(1, "m1")},
+			n:          1,
+			mock: func(mock sqlmock.Sqlmock) {
+				mock.ExpectRollback()
+			},
+			wantErr: ErrNothingToRevert,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			m, err := newMigrator(db, tt.migrations)
+			require.NoError(t, err)
+
+			expectLock(mock, tt.applied...)
+			tt.mock(mock)
+
+			reverted, err := m.Down(context.Background(), tt.n)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			var versions []int64
+			for _, mg := range reverted {
+				versions = append(versions, mg.Version)
+			}
+			assert.Equal(t, tt.want, versions)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	expectStatus := func(mock sqlmock.Sqlmock, hasTable bool, applied ...int64) {
+		count := 0
+		if hasTable {
+			count = 1
+		}
+		mock.ExpectQuery(`information_schema.tables`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+		if !hasTable {
+			return
+		}
+		rows := sqlmock.NewRows([]string{"version", "name", "applied_at"})
+		for _, v := range applied {
+			rows.AddRow(v, fmt.Sprintf("m%d", v), time.Now())
+		}
+		mock.ExpectQuery(`^SELECT \* FROM "schema_migrations" ORDER BY version$`).WillReturnRows(rows)
+	}
+
+	tests := []struct {
+		name     string
+		hasTable bool
+		applied  []int64
+		wantErr  error
+	}{
+		{name: "current", hasTable: true, applied: []int64{1, 2}},
+		{name: "fresh_database", hasTable: false, wantErr: ErrSchemaOutdated},
+		{name: "pending", hasTable: true, applied: []int64{1}, wantErr: ErrSchemaOutdated},
+		{name: "newer", hasTable: true, applied: []int64{1, 2, 3}, wantErr: ErrSchemaTooNew},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock := newMockDB(t)
+			m, err := newMigrator(db, []Migration{execMigration(1, "m1"), execMigration(2, "m2")})
+			require.NoError(t, err)
+
+			expectStatus(mock, tt.hasTable, tt.applied...)
+
+			err = m.CheckVersion(context.Background())
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMigratorVersion(t *testing.T) {
+	db, mock := newMockDB(t)
+	m, err := newMigrator(db, []Migration{execMigration(1, "m1"), execMigration(2, "m2")})
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`information_schema.tables`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`^SELECT \* FROM "schema_migrations" ORDER BY version$`).
+		WillReturnRows(sqlmock.NewRows([]string{"version", "name", "applied_at"}).AddRow(1, "m1", time.Now()))
+
+	got, err := m.Version(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion{Current: 1, Latest: 2}, got)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNewMigratorDuplicateVersion(t *testing.T) {
+	_, err := newMigrator(nil, []Migration{execMigration(1, "a"), execMigration(1, "b")})
+	assert.EqualError(t, err, "duplicate migration version 1")
+}
+
+func TestCreateMigration(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "0007_existing.up.sql"), []byte("SELECT 1"), 0o644))
+
+	files, err := CreateMigration(dir, "Add user email index")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "0008_add_user_email_index.up.sql"),
+		filepath.Join(dir, "0008_add_user_email_index.down.sql"),
+	}, files)
+
+	data, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "-- 0008_add_user_email_index up"))
+
+	_, err = CreateMigration(dir, "!!!")
+	assert.EqualError(t, err, "migration name is required")
+}
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS revokedtokens;
+DROP TABLE IF EXISTS refreshtokens;
+DROP TABLE IF EXISTS favouritebrands;
+DROP TABLE IF EXISTS orderitems;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS cartitems;
+DROP TABLE IF EXISTS brands;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS userdetails;
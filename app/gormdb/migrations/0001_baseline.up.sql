@@ -0,0 +1,99 @@
+-- Baseline schema, matches what AutoMigrate created before versioned
+-- migrations existed. IF NOT EXISTS makes it a no-op on those databases.
+
+CREATE TABLE IF NOT EXISTS userdetails (
+    id           bigserial PRIMARY KEY,
+    username     text NOT NULL CONSTRAINT uni_userdetails_username UNIQUE,
+    password     text NOT NULL,
+    address      text NOT NULL,
+    pincode      bigint NOT NULL,
+    phone_number bigint NOT NULL,
+    mail         text NOT NULL,
+    status       boolean NOT NULL DEFAULT true,
+    updated_at   timestamptz,
+    isadmin      boolean NOT NULL DEFAULT false
+);
+
+CREATE TABLE IF NOT EXISTS categories (
+    id            bigserial PRIMARY KEY,
+    category_name text NOT NULL CONSTRAINT uni_categories_category_name UNIQUE,
+    description   text,
+    created_at    timestamptz,
+    updated_at    timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS brands (
+    id          bigserial PRIMARY KEY,
+    category_id bigint NOT NULL CONSTRAINT fk_categories_brands REFERENCES categories (id),
+    brand_name  text NOT NULL,
+    price       decimal NOT NULL,
+    stock_count bigint NOT NULL,
+    image_link  text,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_brands_category_id ON brands (category_id);
+
+CREATE TABLE IF NOT EXISTS cartitems (
+    id          bigserial PRIMARY KEY,
+    user_id     bigint NOT NULL,
+    category_id bigint NOT NULL,
+    brand_id    bigint NOT NULL,
+    quantity    bigint NOT NULL,
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cartitems_user_brand ON cartitems (user_id, brand_id);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id          bigserial PRIMARY KEY,
+    user_id     bigint NOT NULL,
+    total_price decimal NOT NULL,
+    status      text NOT NULL DEFAULT 'placed',
+    created_at  timestamptz,
+    updated_at  timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_orders_user_id ON orders (user_id);
+CREATE INDEX IF NOT EXISTS idx_orders_user_created ON orders (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS orderitems (
+    id          bigserial PRIMARY KEY,
+    order_id    bigint NOT NULL CONSTRAINT fk_orders_items REFERENCES orders (id),
+    brand_id    bigint NOT NULL,
+    category_id bigint NOT NULL,
+    brand_name  text NOT NULL,
+    price       decimal NOT NULL,
+    quantity    bigint NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_orderitems_order_id ON orderitems (order_id);
+
+CREATE TABLE IF NOT EXISTS favouritebrands (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    brand_id   bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_favouritebrands_user_brand ON favouritebrands (user_id, brand_id);
+
+CREATE TABLE IF NOT EXISTS refreshtokens (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    family_id  text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    revoked_at timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_refreshtokens_user_id ON refreshtokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refreshtokens_family_id ON refreshtokens (family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refreshtokens_token_hash ON refreshtokens (token_hash);
+
+CREATE TABLE IF NOT EXISTS revokedtokens (
+    jti        text PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    expires_at timestamptz NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_revokedtokens_user_id ON revokedtokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_revokedtokens_expires_at ON revokedtokens (expires_at);
@@ -0,0 +1,7 @@
+ALTER TABLE userdetails DROP COLUMN IF EXISTS country;
+
+-- anything but digits is dropped, a value without digits becomes 0
+ALTER TABLE userdetails ALTER COLUMN phone_number TYPE bigint
+    USING COALESCE(NULLIF(regexp_replace(phone_number, '[^0-9]', '', 'g'), ''), '0')::bigint;
+ALTER TABLE userdetails ALTER COLUMN pincode TYPE bigint
+    USING COALESCE(NULLIF(regexp_replace(pincode, '[^0-9]', '', 'g'), ''), '0')::bigint;
@@ -0,0 +1,9 @@
+-- Phone numbers and postal codes are text: bigint dropped leading zeros and
+-- can't hold the + of E.164 numbers. Existing values are kept as they are,
+-- they get validated the next time the profile is saved.
+
+ALTER TABLE userdetails ALTER COLUMN pincode TYPE text USING pincode::text;
+ALTER TABLE userdetails ALTER COLUMN phone_number TYPE text USING phone_number::text;
+
+-- postal codes are checked per country, every existing user is in India
+ALTER TABLE userdetails ADD COLUMN IF NOT EXISTS country text NOT NULL DEFAULT 'IN';
@@ -0,0 +1,7 @@
+-- only admins keep elevated rights, support and warehouse users become customers
+ALTER TABLE userdetails ADD COLUMN isadmin boolean NOT NULL DEFAULT false;
+UPDATE userdetails SET isadmin = true WHERE role = 'admin';
+ALTER TABLE userdetails DROP COLUMN role;
+
+DROP TABLE IF EXISTS rolepermissions;
+DROP TABLE IF EXISTS roles;
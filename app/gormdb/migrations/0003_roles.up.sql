@@ -0,0 +1,39 @@
+-- Roles replace the isadmin flag. Which role holds which permission is data,
+-- the grants below match rbac.DefaultRoles.
+
+CREATE TABLE IF NOT EXISTS roles (
+    name        text PRIMARY KEY,
+    description text NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS rolepermissions (
+    role       text NOT NULL REFERENCES roles (name) ON DELETE CASCADE,
+    permission text NOT NULL,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO roles (name, description) VALUES
+    ('customer', 'Shops on the site'),
+    ('admin', 'Manages everything, including roles'),
+    ('support', 'Helps customers with their accounts and orders'),
+    ('warehouse', 'Maintains the catalog and ships orders')
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO rolepermissions (role, permission) VALUES
+    ('admin', 'catalog:write'),
+    ('admin', 'users:read'),
+    ('admin', 'users:write'),
+    ('admin', 'users:block'),
+    ('admin', 'orders:read'),
+    ('admin', 'roles:assign'),
+    ('support', 'users:read'),
+    ('support', 'users:block'),
+    ('support', 'orders:read'),
+    ('warehouse', 'catalog:write'),
+    ('warehouse', 'orders:read')
+ON CONFLICT (role, permission) DO NOTHING;
+
+ALTER TABLE userdetails ADD COLUMN role text NOT NULL DEFAULT 'customer'
+    CONSTRAINT fk_userdetails_role REFERENCES roles (name);
+UPDATE userdetails SET role = 'admin' WHERE isadmin;
+ALTER TABLE userdetails DROP COLUMN isadmin;
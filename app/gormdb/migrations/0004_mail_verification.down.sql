@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS mailtokens;
+
+ALTER TABLE userdetails DROP COLUMN mail_verified_at;
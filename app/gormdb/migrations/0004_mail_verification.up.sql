@@ -0,0 +1,17 @@
+-- Users confirm their mail address by following a link with a single-use
+-- token. Everybody who signed up before is taken as verified.
+
+ALTER TABLE userdetails ADD COLUMN mail_verified_at timestamptz;
+UPDATE userdetails SET mail_verified_at = now();
+
+CREATE TABLE IF NOT EXISTS mailtokens (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    purpose    text NOT NULL,
+    token_hash text NOT NULL,
+    expires_at timestamptz NOT NULL,
+    used_at    timestamptz,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_mailtokens_user_id ON mailtokens (user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mailtokens_token_hash ON mailtokens (token_hash);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS auditlogs;
+
+ALTER TABLE userdetails DROP COLUMN password_changed_at;
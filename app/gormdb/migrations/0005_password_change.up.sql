@@ -0,0 +1,14 @@
+-- Changing or resetting the password ends every session started before,
+-- and each change is written to the audit log.
+
+ALTER TABLE userdetails ADD COLUMN password_changed_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS auditlogs (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    actor_id   bigint NOT NULL,
+    action     text NOT NULL,
+    ip         text NOT NULL DEFAULT '',
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_auditlogs_user_id ON auditlogs (user_id);
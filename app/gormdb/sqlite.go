@@ -0,0 +1,60 @@
+package gormdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"sonartest_cart/app/internal"
+
+	"github.com/glebarez/sqlite"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+// MemorySQLitePath is a SQLite database that lives as long as the process.
+// The shared cache lets every pooled connection see the same data.
+const MemorySQLitePath = "file:sonartest_cart?mode=memory&cache=shared"
+
+// models is every table, in dependency order
+var models = []interface{}{
+	&internal.Role{}, &internal.Rolepermission{},
+	&internal.Userdetail{},
+	&internal.Category{}, &internal.Brand{},
+	&internal.Cartitem{},
+	&internal.Order{}, &internal.Orderitem{},
+	&internal.Favouritebrand{},
+	&internal.Refreshtoken{}, &internal.Revokedtoken{},
+	&internal.Mailtoken{},
+	&internal.Auditlog{},
+}
+
+// OpenSQLite opens a SQLite database for local development and tests. The
+// versioned migrations are postgres SQL, so the schema comes from the models.
+func OpenSQLite(path string) (*gorm.DB, error) {
+	log.Info().Str("path", path).Msg("Opening sqlite database")
+
+	db, err := gorm.Open(sqlite.Open(withPragmas(path)), gormConfig())
+	if err != nil {
+		return nil, &ConnectError{Op: OpConnect, Attempts: 1, Err: err}
+	}
+	if err := db.AutoMigrate(models...); err != nil {
+		Close(db)
+		return nil, &ConnectError{Op: OpSchemaCheck, Attempts: 1, Err: fmt.Errorf("sqlite schema: %w", err)}
+	}
+	if err := internal.SeedRoles(context.Background(), db); err != nil {
+		Close(db)
+		return nil, &ConnectError{Op: OpSchemaCheck, Attempts: 1, Err: fmt.Errorf("sqlite roles: %w", err)}
+	}
+	return db, nil
+}
+
+// withPragmas turns on foreign keys and waits for locks instead of failing
+// with SQLITE_BUSY when requests write concurrently
+func withPragmas(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
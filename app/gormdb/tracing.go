@@ -0,0 +1,116 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const (
+	tracerName = "sonartest_cart/app/gormdb"
+
+	// parentCtxKey keeps the context a statement started with, so the next
+	// statement of the same session is not nested under an ended span
+	parentCtxKey = "tracing:parent_ctx"
+)
+
+// TracingPlugin is a gorm plugin that creates a client span for every
+// statement. Spans are children of whatever span is in the statement
+// context, repositories pass it in with db.WithContext(ctx). Statements
+// outside a trace, like the schema setup at startup, are not traced.
+type TracingPlugin struct {
+	tracer trace.Tracer
+}
+
+// NewTracingPlugin uses the global tracer provider set up by tracing.Setup
+func NewTracingPlugin() *TracingPlugin {
+	return newTracingPlugin(otel.GetTracerProvider())
+}
+
+func newTracingPlugin(provider trace.TracerProvider) *TracingPlugin {
+	return &TracingPlugin{tracer: provider.Tracer(tracerName)}
+}
+
+func (p *TracingPlugin) Name() string {
+	return "tracing"
+}
+
+type registrar interface {
+	Register(name string, fn func(*gorm.DB)) error
+}
+
+func (p *TracingPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	hooks := []struct {
+		op            string
+		before, after registrar
+	}{
+		{"create", cb.Create().Before("gorm:create"), cb.Create().After("gorm:create")},
+		{"query", cb.Query().Before("gorm:query"), cb.Query().After("gorm:query")},
+		{"update", cb.Update().Before("gorm:update"), cb.Update().After("gorm:update")},
+		{"delete", cb.Delete().Before("gorm:delete"), cb.Delete().After("gorm:delete")},
+		{"row", cb.Row().Before("gorm:row"), cb.Row().After("gorm:row")},
+		{"raw", cb.Raw().Before("gorm:raw"), cb.Raw().After("gorm:raw")},
+	}
+
+	for _, h := range hooks {
+		if err := h.before.Register("tracing:before_"+h.op, p.before(h.op)); err != nil {
+			return err
+		}
+		if err := h.after.Register("tracing:after_"+h.op, p.after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *TracingPlugin) before(op string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		parent := db.Statement.Context
+		if parent == nil || !trace.SpanContextFromContext(parent).IsValid() {
+			db.InstanceSet(parentCtxKey, nil)
+			return
+		}
+		db.InstanceSet(parentCtxKey, parent)
+
+		name := "gorm." + op
+		if db.Statement.Table != "" {
+			name += " " + db.Statement.Table
+		}
+		ctx, _ := p.tracer.Start(parent, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(db.Dialector.Name()),
+				semconv.DBCollectionName(db.Statement.Table),
+			),
+		)
+		db.Statement.Context = ctx
+	}
+}
+
+func (p *TracingPlugin) after(db *gorm.DB) {
+	parent, ok := db.InstanceGet(parentCtxKey)
+	if !ok || parent == nil {
+		return
+	}
+	span := trace.SpanFromContext(db.Statement.Context)
+	db.Statement.Context = parent.(context.Context)
+
+	// the SQL keeps its placeholders, values never reach the trace
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	// a missing row is an answer, not a failure
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+	span.End()
+}
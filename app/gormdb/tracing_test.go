@@ -0,0 +1,84 @@
+package gormdb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"gorm.io/gorm"
+)
+
+type tracedRow struct {
+	ID   int64
+	Name string
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) attribute.Value {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracingPlugin(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	gdb, mock := newMockDB(t)
+	require.NoError(t, gdb.Use(newTracingPlugin(provider)))
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "UserService.GetMyProfile")
+
+	mock.ExpectQuery(`^SELECT \* FROM "traced_rows" WHERE id = \$1`).
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(7, "secret-name"))
+	mock.ExpectQuery(`^SELECT \* FROM "traced_rows" WHERE id = \$1`).
+		WithArgs(8, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM "traced_rows"`).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	db := gdb.WithContext(ctx)
+	var row tracedRow
+	require.NoError(t, db.Where("id = ?", 7).First(&row).Error)
+	var other tracedRow
+	assert.ErrorIs(t, db.Where("id = ?", 8).First(&other).Error, gorm.ErrRecordNotFound)
+	assert.Error(t, db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&tracedRow{}).Error)
+	parent.End()
+
+	// no span without a trace to attach it to
+	mock.ExpectExec(`^DELETE FROM "traced_rows"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	require.NoError(t, gdb.Exec(`DELETE FROM "traced_rows"`).Error)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 4)
+	found, missing, failed := spans[0], spans[1], spans[2]
+
+	for _, span := range []sdktrace.ReadOnlySpan{found, missing, failed} {
+		// consecutive statements are siblings under the service span
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID())
+		assert.Equal(t, "postgres", attr(span, "db.system").AsString())
+	}
+
+	assert.Equal(t, "gorm.query traced_rows", found.Name())
+	assert.Equal(t, `SELECT * FROM "traced_rows" WHERE id = $1 ORDER BY "traced_rows"."id" LIMIT $2`, attr(found, "db.query.text").AsString())
+	assert.Equal(t, int64(1), attr(found, "db.rows_affected").AsInt64())
+	assert.Equal(t, codes.Unset, found.Status().Code)
+
+	assert.Equal(t, codes.Unset, missing.Status().Code, "not found is not an error")
+
+	assert.Equal(t, "gorm.delete traced_rows", failed.Name())
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Equal(t, "connection reset", failed.Status().Description)
+}
@@ -4,11 +4,15 @@ import (
 	"context"
 	"sonartest_cart/pkg/middleware"
 	"errors"
+	"time"
 )
 
 type ContextHelper interface {
 	GetUserID(ctx context.Context) (int64, error)
 	GetUsername(ctx context.Context) (string, error)
+	GetTokenID(ctx context.Context) (string, error)
+	GetTokenExpiry(ctx context.Context) (time.Time, error)
+	GetPermissions(ctx context.Context) ([]string, error)
 }
 
 type contextHelperImpl struct{}
@@ -32,3 +36,28 @@ func (h *contextHelperImpl) GetUsername(ctx context.Context) (string, error) {
 	}
 	return username, nil
 }
+
+func (h *contextHelperImpl) GetTokenID(ctx context.Context) (string, error) {
+	jti, ok := ctx.Value(middleware.TokenIDKey).(string)
+	if !ok || jti == "" {
+		return "", errors.New("token ID not found in context")
+	}
+	return jti, nil
+}
+
+func (h *contextHelperImpl) GetTokenExpiry(ctx context.Context) (time.Time, error) {
+	exp, ok := ctx.Value(middleware.ExpiresKey).(time.Time)
+	if !ok {
+		return time.Time{}, errors.New("token expiry not found in context")
+	}
+	return exp, nil
+}
+
+// GetPermissions returns the permissions granted by the token, nil for a customer
+func (h *contextHelperImpl) GetPermissions(ctx context.Context) ([]string, error) {
+	perms, ok := ctx.Value(middleware.PermissionsKey).([]string)
+	if !ok {
+		return nil, errors.New("permissions not found in context")
+	}
+	return perms, nil
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Audited actions
+const (
+	AuditPasswordChanged = "password_changed"
+	AuditPasswordReset   = "password_reset"
+)
+
+type AuditRepo interface {
+	Record(ctx context.Context, entry *Auditlog) error
+}
+
+type AuditRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewAuditRepo(db *gorm.DB) AuditRepo {
+	return &AuditRepoImpl{
+		db: db,
+	}
+}
+
+// Auditlog records a security relevant change of a user account. ActorID is
+// who made the change, the user themselves unless an admin acted for them.
+type Auditlog struct {
+	ID        int64     `gorm:"primaryKey"`
+	UserID    int64     `gorm:"column:user_id;not null;index"`
+	ActorID   int64     `gorm:"column:actor_id;not null"`
+	Action    string    `gorm:"column:action;not null"`
+	IP        string    `gorm:"column:ip;not null;default:''"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Auditlog) TableName() string {
+	return "auditlogs"
+}
+
+func (r *AuditRepoImpl) Record(ctx context.Context, entry *Auditlog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
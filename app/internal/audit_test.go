@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestAuditRepo(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	sqlDb, err := db.DB()
+	require.NoError(t, err)
+	// every connection to :memory: is its own database
+	sqlDb.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDb.Close() })
+	require.NoError(t, db.AutoMigrate(&Auditlog{}))
+
+	repo := NewAuditRepo(db)
+	require.NoError(t, repo.Record(context.Background(), &Auditlog{UserID: 2, ActorID: 2, Action: AuditPasswordReset, IP: "192.0.2.1"}))
+
+	var entries []Auditlog
+	require.NoError(t, db.Find(&entries).Error)
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(2), entries[0].UserID)
+	assert.Equal(t, AuditPasswordReset, entries[0].Action)
+	assert.Equal(t, "192.0.2.1", entries[0].IP)
+	assert.False(t, entries[0].CreatedAt.IsZero())
+}
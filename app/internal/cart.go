@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrCartStockExceeded is returned when adding to a cart line would take it past the stock
+var ErrCartStockExceeded = errors.New("cart line would exceed the stock")
+
+type CartRepo interface {
+	AddItem(ctx context.Context, userID, categoryID, brandID, quantity, maxQuantity int64) (int64, error)
+	GetCartItems(ctx context.Context, userID int64) ([]CartLine, error)
+	UpdateQuantity(ctx context.Context, userID, brandID, quantity int64) error
+	RemoveItem(ctx context.Context, userID, brandID int64) error
+	ClearCart(ctx context.Context, userID int64) (int64, error)
+	CountIdleCarts(ctx context.Context, idleSince time.Time) (int64, error)
+}
+
+type CartRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewCartRepo(db *gorm.DB) CartRepo {
+	return &CartRepoImpl{
+		db: db,
+	}
+}
+
+// Cartitem is one line of a user's cart, a user has at most one line per brand
+type Cartitem struct {
+	ID         int64     `gorm:"primaryKey"`
+	UserID     int64     `gorm:"column:user_id;not null;uniqueIndex:idx_cartitems_user_brand"`
+	CategoryID int64     `gorm:"column:category_id;not null"`
+	BrandID    int64     `gorm:"column:brand_id;not null;uniqueIndex:idx_cartitems_user_brand"`
+	Quantity   int64     `gorm:"column:quantity;not null"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Cartitem) TableName() string {
+	return "cartitems"
+}
+
+// CartLine is a cart item joined with the current brand details
+type CartLine struct {
+	BrandID    int64   `gorm:"column:brand_id"`
+	CategoryID int64   `gorm:"column:category_id"`
+	Quantity   int64   `gorm:"column:quantity"`
+	BrandName  string  `gorm:"column:brand_name"`
+	Price      float64 `gorm:"column:price"`
+}
+
+// AddItem adds quantity of a brand to the cart, merging with an existing line.
+// The merged line may hold at most maxQuantity, otherwise nothing changes and
+// ErrCartStockExceeded is returned. It returns the resulting quantity of that line.
+func (r *CartRepoImpl) AddItem(ctx context.Context, userID, categoryID, brandID, quantity, maxQuantity int64) (int64, error) {
+	item := Cartitem{
+		UserID:     userID,
+		CategoryID: categoryID,
+		BrandID:    brandID,
+		Quantity:   quantity,
+	}
+
+	// the guard sits in the upsert so concurrent adds can't overshoot either
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "brand_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"quantity":   gorm.Expr("cartitems.quantity + excluded.quantity"),
+			"updated_at": gorm.Expr("excluded.updated_at"),
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			gorm.Expr("cartitems.quantity + excluded.quantity <= ?", maxQuantity),
+		}},
+	}).Create(&item)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, ErrCartStockExceeded
+	}
+
+	var line Cartitem
+	if err := r.db.WithContext(ctx).Table("cartitems").Where("user_id = ? AND brand_id = ?", userID, brandID).First(&line).Error; err != nil {
+		return 0, err
+	}
+	return line.Quantity, nil
+}
+
+func (r *CartRepoImpl) GetCartItems(ctx context.Context, userID int64) ([]CartLine, error) {
+	var lines []CartLine
+	err := r.db.WithContext(ctx).Table("cartitems").
+		Select("cartitems.brand_id, cartitems.category_id, cartitems.quantity, brands.brand_name, brands.price").
+		Joins("JOIN brands ON brands.id = cartitems.brand_id").
+		Where("cartitems.user_id = ?", userID).
+		Order("cartitems.id").
+		Scan(&lines).Error
+	if err != nil {
+		return nil, err
+	}
+	return lines, nil
+}
+
+func (r *CartRepoImpl) UpdateQuantity(ctx context.Context, userID, brandID, quantity int64) error {
+	result := r.db.WithContext(ctx).Model(&Cartitem{}).
+		Where("user_id = ? AND brand_id = ?", userID, brandID).
+		Update("quantity", quantity)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *CartRepoImpl) RemoveItem(ctx context.Context, userID, brandID int64) error {
+	result := r.db.WithContext(ctx).Where("user_id = ? AND brand_id = ?", userID, brandID).Delete(&Cartitem{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ClearCart empties the cart and returns how many items were removed
+func (r *CartRepoImpl) ClearCart(ctx context.Context, userID int64) (int64, error) {
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&Cartitem{})
+	return result.RowsAffected, result.Error
+}
+
+// CountIdleCarts counts the non-empty carts none of whose lines changed since
+// idleSince, i.e. the carts their users abandoned
+func (r *CartRepoImpl) CountIdleCarts(ctx context.Context, idleSince time.Time) (int64, error) {
+	idle := r.db.Model(&Cartitem{}).
+		Select("user_id").
+		Group("user_id").
+		Having("MAX(updated_at) < ?", idleSince)
+
+	var count int64
+	err := r.db.WithContext(ctx).Table("(?) AS idle_carts", idle).Count(&count).Error
+	return count, err
+}
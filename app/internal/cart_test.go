@@ -0,0 +1,314 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newCartRepoMock(t *testing.T) (CartRepo, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	return NewCartRepo(gdb), mock
+}
+
+func TestAddItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    int64
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-merges-quantity",
+			want:    5,
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^INSERT INTO "cartitems" \("user_id","category_id","brand_id","quantity","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\) ON CONFLICT \("user_id","brand_id"\) DO UPDATE SET "quantity"=cartitems.quantity \+ excluded.quantity,"updated_at"=excluded.updated_at WHERE cartitems.quantity \+ excluded.quantity <= \$7 RETURNING "id"$`).
+					WithArgs(int64(1), int64(2), int64(3), int64(2), sqlmock.AnyArg(), sqlmock.AnyArg(), int64(5)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`^SELECT \* FROM "cartitems" WHERE user_id = \$1 AND brand_id = \$2 ORDER BY "cartitems"."id" LIMIT \$3$`).
+					WithArgs(int64(1), int64(3), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "brand_id", "quantity"}).AddRow(7, 1, 3, 5))
+			},
+		},
+		{
+			name:    "insert-error",
+			want:    0,
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^INSERT INTO "cartitems"`).
+					WillReturnError(fmt.Errorf("insert failed"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	repo, mock := newCartRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.AddItem(context.Background(), 1, 2, 3, 2, 5)
+			if (err != nil) != test.wantErr {
+				t.Errorf("AddItem() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("AddItem() = %d, want %d", got, test.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestAddItemStockGuard(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	sqlDb, err := db.DB()
+	require.NoError(t, err)
+	// every connection to :memory: is its own database
+	sqlDb.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDb.Close() })
+	require.NoError(t, db.AutoMigrate(&Cartitem{}))
+	repo := NewCartRepo(db)
+
+	got, err := repo.AddItem(context.Background(), 1, 2, 3, 3, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), got)
+
+	// adding the same brand again must not take the line past the stock
+	_, err = repo.AddItem(context.Background(), 1, 2, 3, 3, 5)
+	assert.ErrorIs(t, err, ErrCartStockExceeded)
+
+	got, err = repo.AddItem(context.Background(), 1, 2, 3, 2, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), got)
+}
+
+func TestGetCartItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    []CartLine
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name: "success-case",
+			want: []CartLine{
+				{BrandID: 3, CategoryID: 2, Quantity: 2, BrandName: "ACME", Price: 10.5},
+			},
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				rows := sqlmock.NewRows([]string{"brand_id", "category_id", "quantity", "brand_name", "price"}).
+					AddRow(3, 2, 2, "ACME", 10.5)
+				mock.ExpectQuery(`^SELECT cartitems.brand_id, cartitems.category_id, cartitems.quantity, brands.brand_name, brands.price FROM "cartitems" JOIN brands ON brands.id = cartitems.brand_id WHERE cartitems.user_id = \$1 ORDER BY cartitems.id$`).
+					WithArgs(int64(1)).
+					WillReturnRows(rows)
+			},
+		},
+		{
+			name:    "database-error",
+			want:    nil,
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT cartitems.brand_id`).
+					WithArgs(int64(1)).
+					WillReturnError(fmt.Errorf("database error"))
+			},
+		},
+	}
+
+	repo, mock := newCartRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.GetCartItems(context.Background(), 1)
+			if (err != nil) != test.wantErr {
+				t.Errorf("GetCartItems() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("GetCartItems() returned %d lines, want %d", len(got), len(test.want))
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Errorf("GetCartItems()[%d] = %+v, want %+v", i, got[i], test.want[i])
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestUpdateQuantity(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr error
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-case",
+			wantErr: nil,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "cartitems" SET "quantity"=\$1,"updated_at"=\$2 WHERE user_id = \$3 AND brand_id = \$4$`).
+					WithArgs(int64(4), sqlmock.AnyArg(), int64(1), int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "item-not-in-cart",
+			wantErr: gorm.ErrRecordNotFound,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "cartitems"`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+	}
+
+	repo, mock := newCartRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			err := repo.UpdateQuantity(context.Background(), 1, 3, 4)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("UpdateQuantity() error = %v, want %v", err, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRemoveItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr error
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-case",
+			wantErr: nil,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^DELETE FROM "cartitems" WHERE user_id = \$1 AND brand_id = \$2$`).
+					WithArgs(int64(1), int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "item-not-in-cart",
+			wantErr: gorm.ErrRecordNotFound,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^DELETE FROM "cartitems"`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+	}
+
+	repo, mock := newCartRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			err := repo.RemoveItem(context.Background(), 1, 3)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("RemoveItem() error = %v, want %v", err, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestClearCart(t *testing.T) {
+	repo, mock := newCartRepoMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^DELETE FROM "cartitems" WHERE user_id = \$1$`).
+		WithArgs(int64(1)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	removed, err := repo.ClearCart(context.Background(), 1)
+	if err != nil {
+		t.Errorf("ClearCart() unexpected error: %v", err)
+	}
+	if removed != 3 {
+		t.Errorf("ClearCart() removed = %d, want 3", removed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCountIdleCarts(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	sqlDb, err := db.DB()
+	require.NoError(t, err)
+	sqlDb.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDb.Close() })
+	require.NoError(t, db.AutoMigrate(&Cartitem{}))
+
+	now := time.Now()
+	for _, item := range []Cartitem{
+		// two old lines are one abandoned cart
+		{UserID: 1, BrandID: 1, Quantity: 1, UpdatedAt: now.Add(-48 * time.Hour)},
+		{UserID: 1, BrandID: 2, Quantity: 1, UpdatedAt: now.Add(-30 * time.Hour)},
+		// a recent line keeps the cart alive
+		{UserID: 2, BrandID: 1, Quantity: 1, UpdatedAt: now.Add(-48 * time.Hour)},
+		{UserID: 2, BrandID: 2, Quantity: 1, UpdatedAt: now.Add(-time.Hour)},
+		{UserID: 3, BrandID: 1, Quantity: 1, UpdatedAt: now.Add(-72 * time.Hour)},
+	} {
+		require.NoError(t, db.Create(&item).Error)
+	}
+
+	count, err := NewCartRepo(db).CountIdleCarts(context.Background(), now.Add(-24*time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FavouriteRepo interface {
+	AddFavourite(ctx context.Context, userID, brandID int64) error
+	RemoveFavourite(ctx context.Context, userID, brandID int64) error
+	GetFavourites(ctx context.Context, userID int64) ([]Brand, error)
+}
+
+type FavouriteRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewFavouriteRepo(db *gorm.DB) FavouriteRepo {
+	return &FavouriteRepoImpl{
+		db: db,
+	}
+}
+
+type Favouritebrand struct {
+	ID        int64     `gorm:"primaryKey"`
+	UserID    int64     `gorm:"column:user_id;not null;uniqueIndex:idx_favouritebrands_user_brand"`
+	BrandID   int64     `gorm:"column:brand_id;not null;uniqueIndex:idx_favouritebrands_user_brand"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Favouritebrand) TableName() string {
+	return "favouritebrands"
+}
+
+// AddFavourite is idempotent, favouriting the same brand twice keeps one row
+func (r *FavouriteRepoImpl) AddFavourite(ctx context.Context, userID, brandID int64) error {
+	fav := Favouritebrand{
+		UserID:  userID,
+		BrandID: brandID,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "brand_id"}},
+		DoNothing: true,
+	}).Create(&fav).Error
+}
+
+// RemoveFavourite is idempotent, removing a brand that isn't a favourite is not an error
+func (r *FavouriteRepoImpl) RemoveFavourite(ctx context.Context, userID, brandID int64) error {
+	return r.db.WithContext(ctx).Where("user_id = ? AND brand_id = ?", userID, brandID).Delete(&Favouritebrand{}).Error
+}
+
+// GetFavourites returns the favourite brands with their current price and stock
+func (r *FavouriteRepoImpl) GetFavourites(ctx context.Context, userID int64) ([]Brand, error) {
+	var brands []Brand
+	err := r.db.WithContext(ctx).Table("brands").
+		Select("brands.*").
+		Joins("JOIN favouritebrands ON favouritebrands.brand_id = brands.id").
+		Where("favouritebrands.user_id = ?", userID).
+		Order("favouritebrands.id").
+		Scan(&brands).Error
+	if err != nil {
+		return nil, err
+	}
+	return brands, nil
+}
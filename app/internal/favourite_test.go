@@ -0,0 +1,193 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newFavouriteRepoMock(t *testing.T) (FavouriteRepo, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	return NewFavouriteRepo(gdb), mock
+}
+
+func TestAddFavourite(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success",
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^INSERT INTO "favouritebrands" \("user_id","brand_id","created_at"\) VALUES \(\$1,\$2,\$3\) ON CONFLICT \("user_id","brand_id"\) DO NOTHING RETURNING "id"$`).
+					WithArgs(int64(1), int64(3), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "already-favourite",
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^INSERT INTO "favouritebrands"`).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "insert-error",
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^INSERT INTO "favouritebrands"`).
+					WillReturnError(fmt.Errorf("insert failed"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	repo, mock := newFavouriteRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			err := repo.AddFavourite(context.Background(), 1, 3)
+			if (err != nil) != test.wantErr {
+				t.Errorf("AddFavourite() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRemoveFavourite(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success",
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^DELETE FROM "favouritebrands" WHERE user_id = \$1 AND brand_id = \$2$`).
+					WithArgs(int64(1), int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "not-a-favourite",
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^DELETE FROM "favouritebrands"`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "delete-error",
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^DELETE FROM "favouritebrands"`).
+					WillReturnError(fmt.Errorf("delete failed"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	repo, mock := newFavouriteRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			err := repo.RemoveFavourite(context.Background(), 1, 3)
+			if (err != nil) != test.wantErr {
+				t.Errorf("RemoveFavourite() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetFavourites(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    int
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success",
+			want:    2,
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT brands.\* FROM "brands" JOIN favouritebrands ON favouritebrands.brand_id = brands.id WHERE favouritebrands.user_id = \$1 ORDER BY favouritebrands.id$`).
+					WithArgs(int64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "brand_name", "price", "stock_count", "image_link"}).
+						AddRow(3, "ACME", 10.5, 4, "http://img/acme.png").
+						AddRow(4, "Globex", 7, 0, ""))
+			},
+		},
+		{
+			name:    "query-error",
+			want:    0,
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT brands.\* FROM "brands"`).
+					WillReturnError(fmt.Errorf("select failed"))
+			},
+		},
+	}
+
+	repo, mock := newFavouriteRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.GetFavourites(context.Background(), 1)
+			if (err != nil) != test.wantErr {
+				t.Errorf("GetFavourites() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if len(got) != test.want {
+				t.Errorf("GetFavourites() returned %d brands, want %d", len(got), test.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
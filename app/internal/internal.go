@@ -1,17 +1,28 @@
 package internal
 
 import (
-	"fmt"
+	"context"
 	"sonartest_cart/app/dto"
+	"sonartest_cart/pkg/auth"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type UserRepo interface {
-	SaveUserDetails(args *dto.UserDetailSaveRequest) (int64, error)
-	GetUserByUsername(username string) (*Userdetail, error)
-	IsUserActive(userID int64) (bool, error)
+	SaveUserDetails(ctx context.Context, args *dto.UserDetailSaveRequest) (int64, error)
+	GetUserByUsername(ctx context.Context, username string) (*Userdetail, error)
+	GetUserByID(ctx context.Context, userID int64) (*Userdetail, error)
+	IsUserActive(ctx context.Context, userID int64) (bool, error)
+	UserStatus(ctx context.Context, userID int64) (bool, time.Time, error)
+	UpdatePassword(ctx context.Context, userID int64, passwordHash string) error
+	ChangePassword(ctx context.Context, userID int64, passwordHash string) error
+	UpdateUserStatus(ctx context.Context, userID int64, status bool) error
+	ListUsers(ctx context.Context) ([]Userdetail, error)
+	UpdateUserDetails(ctx context.Context, args *dto.UpdateUserDetailRequest) error
+	UpdateUserRole(ctx context.Context, userID int64, role string) error
+	MarkMailVerified(ctx context.Context, userID int64) error
+	IsMailVerified(ctx context.Context, userID int64) (bool, error)
 }
 
 type UserRepoImpl struct {
@@ -29,55 +40,193 @@ type Userdetail struct {
 	Username    string    `gorm:"column:username;unique;not null"`
 	Password    string    `gorm:"column:password;not null"`
 	Address     string    `gorm:"column:address;not null"`
-	Pincode     int64     `gorm:"column:pincode;not null"`
-	Phonenumber int64     `gorm:"column:phone_number; not null"`
+	Country     string    `gorm:"column:country;default:IN;not null"` // ISO 3166 alpha-2
+	Pincode     string    `gorm:"column:pincode;not null"`
+	Phonenumber string    `gorm:"column:phone_number; not null"` // E.164
 	Mail        string    `gorm:"column:mail;not null"`
 	Status      bool      `gorm:"column:status;default:true;not null"` // Boolean field, default true
 	UpdatedAt   time.Time `gorm:"column:updated_at;autoUpdateTime"`
-	IsAdmin     bool      `gorm:"column:isadmin;default:false;not null"` // default false for user, true is used when  admin logins
+	Role        string    `gorm:"column:role;default:customer;not null"` // name of a row in roles
+	// MailVerifiedAt is when the user followed the verification link, nil until then
+	MailVerifiedAt *time.Time `gorm:"column:mail_verified_at"`
+	// PasswordChangedAt is when the user last changed or reset their
+	// password, tokens issued before are no longer accepted
+	PasswordChangedAt *time.Time `gorm:"column:password_changed_at"`
 }
 
 func (Userdetail) TableName() string {
 	return "userdetails"
 }
 
-func (r *UserRepoImpl) SaveUserDetails(args *dto.UserDetailSaveRequest) (int64, error) {
+func (r *UserRepoImpl) SaveUserDetails(ctx context.Context, args *dto.UserDetailSaveRequest) (int64, error) {
 
 	user := Userdetail{
-		ID:          args.UserID,
 		Address:     args.Address,
 		Mail:        args.Mail,
 		Username:    args.UserName,
 		Password:    args.Password,
+		Country:     args.Country,
 		Pincode:     args.Pincode,
 		Phonenumber: args.Phone,
-		IsAdmin:     args.IsAdmin,
+		Role:        args.Role,
+	}
+	if args.MailVerified {
+		now := time.Now()
+		user.MailVerifiedAt = &now
 	}
 	//GORM's Create method to insert the new user
-	if err := r.db.Table("userdetails").Create(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Table("userdetails").Create(&user).Error; err != nil {
 		return 0, err
 	}
 	return user.ID, nil
 }
 
-func (r *UserRepoImpl) GetUserByUsername(username string) (*Userdetail, error) {
+func (r *UserRepoImpl) GetUserByUsername(ctx context.Context, username string) (*Userdetail, error) {
+	var user Userdetail
+	if err := r.db.WithContext(ctx).Table("userdetails").Where("username = ?", username).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *UserRepoImpl) GetUserByID(ctx context.Context, userID int64) (*Userdetail, error) {
 	var user Userdetail
-	if err := r.db.Table("userdetails").Where("username = ?", username).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Table("userdetails").Where("id = ?", userID).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepoImpl) IsUserActive(userID int64) (bool, error) {
+func (r *UserRepoImpl) IsUserActive(ctx context.Context, userID int64) (bool, error) {
 	var user Userdetail
 
 	// Fetch the user details by userID
-	if err := r.db.Table("userdetails").Where("id = ?", userID).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Table("userdetails").Where("id = ?", userID).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return false, fmt.Errorf("user not found")
+			return false, auth.ErrUserNotFound
 		}
 		return false, err
 	}
 
 	return user.Status, nil
 }
+
+// UserStatus reports whether the user is active and when they last changed
+// their password, the zero time if they never did
+func (r *UserRepoImpl) UserStatus(ctx context.Context, userID int64) (bool, time.Time, error) {
+	user, err := r.GetUserByID(ctx, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return false, time.Time{}, auth.ErrUserNotFound
+		}
+		return false, time.Time{}, err
+	}
+	if user.PasswordChangedAt == nil {
+		return user.Status, time.Time{}, nil
+	}
+	return user.Status, *user.PasswordChangedAt, nil
+}
+
+func (r *UserRepoImpl) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	result := r.db.WithContext(ctx).Model(&Userdetail{}).Where("id = ?", userID).Update("password", passwordHash)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ChangePassword stores a password the user chose. Unlike UpdatePassword,
+// which only rehashes the same password, it ends the sessions started before.
+func (r *UserRepoImpl) ChangePassword(ctx context.Context, userID int64, passwordHash string) error {
+	result := r.db.WithContext(ctx).Model(&Userdetail{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password":            passwordHash,
+		"password_changed_at": time.Now(),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateUserStatus blocks (false) or unblocks (true) a user
+func (r *UserRepoImpl) UpdateUserStatus(ctx context.Context, userID int64, status bool) error {
+	result := r.db.WithContext(ctx).Model(&Userdetail{}).Where("id = ?", userID).Update("status", status)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *UserRepoImpl) ListUsers(ctx context.Context) ([]Userdetail, error) {
+	var users []Userdetail
+	if err := r.db.WithContext(ctx).Table("userdetails").Order("id").Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UpdateUserDetails updates the profile fields of a user, the password is left
+// untouched. A new mail address is no longer verified.
+func (r *UserRepoImpl) UpdateUserDetails(ctx context.Context, args *dto.UpdateUserDetailRequest) error {
+	result := r.db.WithContext(ctx).Model(&Userdetail{}).Where("id = ?", args.UserID).Updates(map[string]interface{}{
+		"username":     args.UserName,
+		"mail":         args.Mail,
+		"address":      args.Address,
+		"country":      args.Country,
+		"pincode":      args.Pincode,
+		"phone_number": args.Phone,
+		// SET compares with the old mail, whatever order the columns are in
+		"mail_verified_at": gorm.Expr("CASE WHEN mail = ? THEN mail_verified_at END", args.Mail),
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateUserRole gives the user another role, the role has to exist
+func (r *UserRepoImpl) UpdateUserRole(ctx context.Context, userID int64, role string) error {
+	result := r.db.WithContext(ctx).Model(&Userdetail{}).Where("id = ?", userID).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkMailVerified records that the user confirmed their mail address, the
+// first confirmation is kept
+func (r *UserRepoImpl) MarkMailVerified(ctx context.Context, userID int64) error {
+	result := r.db.WithContext(ctx).Model(&Userdetail{}).
+		Where("id = ?", userID).
+		Update("mail_verified_at", gorm.Expr("COALESCE(mail_verified_at, ?)", time.Now()))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *UserRepoImpl) IsMailVerified(ctx context.Context, userID int64) (bool, error) {
+	user, err := r.GetUserByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return user.MailVerifiedAt != nil, nil
+}
@@ -1,6 +1,7 @@
 package internal
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"testing"
@@ -24,32 +25,34 @@ func TestSaveUserDetails(t *testing.T) {
 		{
 			name: "success-case",
 			req: &dto.UserDetailSaveRequest{
-				UserID:   1,
 				UserName: "johndoe",
 				Password: "securepwd",
 				Address:  "123 Street",
-				Pincode:  123456,
-				Phone:    9876543210,
+				Country:  "IN",
+				Pincode:  "123456",
+				Phone:    "+919876543210",
 				Mail:     "john@example.com",
-				IsAdmin:  false,
+				Role:     "customer",
 			},
 			wantID:  1,
 			wantErr: false,
 			query: func(mock sqlmock.Sqlmock) {
 				mock.ExpectBegin()
 				// Match the exact SQL pattern GORM generates
-				mock.ExpectQuery(`^INSERT INTO "userdetails" \("username","password","address","pincode","phone_number","mail","status","updated_at","isadmin","id"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10\) RETURNING "id"$`).
+				mock.ExpectQuery(`^INSERT INTO "userdetails" \("username","password","address","country","pincode","phone_number","mail","status","updated_at","role","mail_verified_at","password_changed_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7,\$8,\$9,\$10,\$11,\$12\) RETURNING "id"$`).
 					WithArgs(
 						"johndoe",          // Username (now first)
 						"securepwd",        // Password
 						"123 Street",       // Address
-						int64(123456),      // Pincode
-						int64(9876543210),  // Phone
+						"IN",               // Country
+						"123456",           // Pincode
+						"+919876543210",    // Phone
 						"john@example.com", // Mail
 						true,               // Status
 						sqlmock.AnyArg(),   // UpdatedAt
-						false,              // IsAdmin
-						1,                  // ID (now last)
+						"customer",         // Role
+						nil,                // MailVerifiedAt, signups verify later
+						nil,                // PasswordChangedAt
 					).
 					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 				mock.ExpectCommit()
@@ -58,7 +61,6 @@ func TestSaveUserDetails(t *testing.T) {
 		{
 			name: "failure-case",
 			req: &dto.UserDetailSaveRequest{
-				UserID:   2,
 				UserName: "janedoe",
 			},
 			wantID:  0,
@@ -91,7 +93,7 @@ func TestSaveUserDetails(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			test.query(mock)
 
-			gotID, err := repo.SaveUserDetails(test.req)
+			gotID, err := repo.SaveUserDetails(context.Background(), test.req)
 			if (err != nil) != test.wantErr {
 				t.Errorf("unexpected error: %v", err)
 			}
@@ -122,21 +124,21 @@ func TestGetUserByUsername(t *testing.T) {
 				Username:    "johndoe",
 				Password:    "securepwd",
 				Address:     "123 Street",
-				Pincode:     123456,
-				Phonenumber: 9876543210,
+				Pincode:     "123456",
+				Phonenumber: "+919876543210",
 				Mail:        "john@example.com",
 				Status:      true,
 				UpdatedAt:   time.Now(),
-				IsAdmin:     false,
+				Role:        "customer",
 			},
 			wantErr: false,
 			query: func(mock sqlmock.Sqlmock) {
 				rows := sqlmock.NewRows([]string{
 					"id", "username", "password", "address", "pincode",
-					"phone_number", "mail", "status", "updated_at", "isadmin",
+					"phone_number", "mail", "status", "updated_at", "role",
 				}).AddRow(
-					1, "johndoe", "securepwd", "123 Street", 123456,
-					9876543210, "john@example.com", true, time.Now(), false,
+					1, "johndoe", "securepwd", "123 Street", "123456",
+					"+919876543210", "john@example.com", true, time.Now(), "customer",
 				)
 
 				mock.ExpectQuery(`^SELECT \* FROM "userdetails" WHERE username = \$1 ORDER BY "userdetails"."id" LIMIT \$2$`).
@@ -187,7 +189,7 @@ func TestGetUserByUsername(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			test.query(mock)
 
-			gotUser, err := repo.GetUserByUsername(test.username)
+			gotUser, err := repo.GetUserByUsername(context.Background(), test.username)
 
 			// Check error expectations first
 			if (err != nil) != test.wantErr {
@@ -304,7 +306,7 @@ func TestIsUserActive(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			test.query(mock)
 
-			got, err := repo.IsUserActive(test.userID)
+			got, err := repo.IsUserActive(context.Background(), test.userID)
 
 			if (err != nil) != test.wantErr {
 				t.Errorf("IsUserActive() error = %v, wantErr %v", err, test.wantErr)
@@ -327,3 +329,336 @@ func TestIsUserActive(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdatePassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  int64
+		hash    string
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-case",
+			userID:  1,
+			hash:    "$2a$10$hash",
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails" SET "password"=\$1,"updated_at"=\$2 WHERE id = \$3$`).
+					WithArgs("$2a$10$hash", sqlmock.AnyArg(), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "user-not-found",
+			userID:  999,
+			hash:    "$2a$10$hash",
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails" SET "password"=\$1,"updated_at"=\$2 WHERE id = \$3$`).
+					WithArgs("$2a$10$hash", sqlmock.AnyArg(), int64(999)).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "database-error",
+			userID:  1,
+			hash:    "$2a$10$hash",
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails"`).
+					WillReturnError(fmt.Errorf("database error"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	repo := NewUserRepo(gdb)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			err := repo.UpdatePassword(context.Background(), test.userID, test.hash)
+			if (err != nil) != test.wantErr {
+				t.Errorf("UpdatePassword() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetUserByID(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	repo := NewUserRepo(gdb)
+
+	rows := sqlmock.NewRows([]string{"id", "username", "mail"}).AddRow(1, "johndoe", "john@example.com")
+	mock.ExpectQuery(`^SELECT \* FROM "userdetails" WHERE id = \$1 ORDER BY "userdetails"."id" LIMIT \$2$`).
+		WithArgs(int64(1), 1).
+		WillReturnRows(rows)
+
+	user, err := repo.GetUserByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("GetUserByID() unexpected error: %v", err)
+	}
+	if user.Username != "johndoe" || user.Mail != "john@example.com" {
+		t.Errorf("GetUserByID() = %+v", user)
+	}
+
+	mock.ExpectQuery(`^SELECT \* FROM "userdetails" WHERE id = \$1`).
+		WithArgs(int64(2), 1).
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	if _, err := repo.GetUserByID(context.Background(), 2); err != gorm.ErrRecordNotFound {
+		t.Errorf("GetUserByID() error = %v, want record not found", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func newUserRepoMock(t *testing.T) (UserRepo, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	return NewUserRepo(gdb), mock
+}
+
+func TestUpdateUserStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  int64
+		status  bool
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "block-user",
+			userID:  2,
+			status:  false,
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails" SET "status"=\$1,"updated_at"=\$2 WHERE id = \$3$`).
+					WithArgs(false, sqlmock.AnyArg(), int64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "unblock-user",
+			userID:  2,
+			status:  true,
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails" SET "status"=\$1,"updated_at"=\$2 WHERE id = \$3$`).
+					WithArgs(true, sqlmock.AnyArg(), int64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "user-not-found",
+			userID:  999,
+			status:  false,
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails"`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "database-error",
+			userID:  2,
+			status:  false,
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails"`).
+					WillReturnError(fmt.Errorf("database error"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	repo, mock := newUserRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			err := repo.UpdateUserStatus(context.Background(), test.userID, test.status)
+			if (err != nil) != test.wantErr {
+				t.Errorf("UpdateUserStatus() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    int
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-case",
+			want:    2,
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT \* FROM "userdetails" ORDER BY id$`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password", "status"}).
+						AddRow(1, "admin", "hash", true).
+						AddRow(2, "johndoe", "hash", false))
+			},
+		},
+		{
+			name:    "database-error",
+			want:    0,
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT \* FROM "userdetails"`).
+					WillReturnError(fmt.Errorf("database error"))
+			},
+		},
+	}
+
+	repo, mock := newUserRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.ListUsers(context.Background())
+			if (err != nil) != test.wantErr {
+				t.Errorf("ListUsers() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if len(got) != test.want {
+				t.Errorf("ListUsers() returned %d users, want %d", len(got), test.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestUpdateUserDetails(t *testing.T) {
+	req := &dto.UpdateUserDetailRequest{
+		UserID:   2,
+		UserName: "janedoe",
+		Mail:     "jane@example.com",
+		Address:  "456 Avenue",
+		Country:  "IN",
+		Pincode:  "654321",
+		Phone:    "+919123456780",
+	}
+
+	tests := []struct {
+		name    string
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-case",
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails" SET "address"=\$1,"country"=\$2,"mail"=\$3,"mail_verified_at"=CASE WHEN mail = \$4 THEN mail_verified_at END,"phone_number"=\$5,"pincode"=\$6,"username"=\$7,"updated_at"=\$8 WHERE id = \$9$`).
+					WithArgs("456 Avenue", "IN", "jane@example.com", "jane@example.com", "+919123456780", "654321", "janedoe", sqlmock.AnyArg(), int64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "user-not-found",
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails"`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "database-error",
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "userdetails"`).
+					WillReturnError(fmt.Errorf("duplicate key value"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	repo, mock := newUserRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			err := repo.UpdateUserDetails(context.Background(), req)
+			if (err != nil) != test.wantErr {
+				t.Errorf("UpdateUserDetails() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
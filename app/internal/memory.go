@@ -0,0 +1,221 @@
+package internal
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"sonartest_cart/app/dto"
+	"sonartest_cart/pkg/auth"
+	"sonartest_cart/pkg/rbac"
+
+	"gorm.io/gorm"
+)
+
+// MemoryUserRepo keeps users in a map. It follows the same contract as the
+// gorm implementation: usernames are unique (gorm.ErrDuplicatedKey), missing
+// users give gorm.ErrRecordNotFound and new users start out active customers.
+type MemoryUserRepo struct {
+	mu     sync.RWMutex
+	users  map[int64]Userdetail
+	nextID int64
+}
+
+func NewMemoryUserRepo() UserRepo {
+	return &MemoryUserRepo{
+		users: make(map[int64]Userdetail),
+	}
+}
+
+func (r *MemoryUserRepo) SaveUserDetails(_ context.Context, args *dto.UserDetailSaveRequest) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.usernameTaken(args.UserName, 0) {
+		return 0, gorm.ErrDuplicatedKey
+	}
+	role := args.Role
+	if role == "" {
+		role = rbac.RoleCustomer
+	}
+
+	r.nextID++
+	id := r.nextID
+
+	user := Userdetail{
+		ID:          id,
+		Address:     args.Address,
+		Mail:        args.Mail,
+		Username:    args.UserName,
+		Password:    args.Password,
+		Country:     args.Country,
+		Pincode:     args.Pincode,
+		Phonenumber: args.Phone,
+		Status:      true,
+		UpdatedAt:   time.Now(),
+		Role:        role,
+	}
+	if args.MailVerified {
+		verifiedAt := user.UpdatedAt
+		user.MailVerifiedAt = &verifiedAt
+	}
+	r.users[id] = user
+	return id, nil
+}
+
+func (r *MemoryUserRepo) GetUserByUsername(_ context.Context, username string) (*Userdetail, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			return &user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *MemoryUserRepo) GetUserByID(_ context.Context, userID int64) (*Userdetail, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &user, nil
+}
+
+func (r *MemoryUserRepo) IsUserActive(_ context.Context, userID int64) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return false, auth.ErrUserNotFound
+	}
+	return user.Status, nil
+}
+
+func (r *MemoryUserRepo) UserStatus(_ context.Context, userID int64) (bool, time.Time, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return false, time.Time{}, auth.ErrUserNotFound
+	}
+	if user.PasswordChangedAt == nil {
+		return user.Status, time.Time{}, nil
+	}
+	return user.Status, *user.PasswordChangedAt, nil
+}
+
+func (r *MemoryUserRepo) UpdatePassword(_ context.Context, userID int64, passwordHash string) error {
+	return r.update(userID, func(user *Userdetail) error {
+		user.Password = passwordHash
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) ChangePassword(_ context.Context, userID int64, passwordHash string) error {
+	return r.update(userID, func(user *Userdetail) error {
+		now := time.Now()
+		user.Password = passwordHash
+		user.PasswordChangedAt = &now
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) UpdateUserStatus(_ context.Context, userID int64, status bool) error {
+	return r.update(userID, func(user *Userdetail) error {
+		user.Status = status
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) ListUsers(_ context.Context) ([]Userdetail, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := make([]Userdetail, 0, len(r.users))
+	for _, user := range r.users {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	return users, nil
+}
+
+func (r *MemoryUserRepo) UpdateUserDetails(_ context.Context, args *dto.UpdateUserDetailRequest) error {
+	return r.update(args.UserID, func(user *Userdetail) error {
+		if r.usernameTaken(args.UserName, user.ID) {
+			return gorm.ErrDuplicatedKey
+		}
+		if user.Mail != args.Mail {
+			user.MailVerifiedAt = nil
+		}
+		user.Username = args.UserName
+		user.Mail = args.Mail
+		user.Address = args.Address
+		user.Country = args.Country
+		user.Pincode = args.Pincode
+		user.Phonenumber = args.Phone
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) UpdateUserRole(_ context.Context, userID int64, role string) error {
+	return r.update(userID, func(user *Userdetail) error {
+		user.Role = role
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) MarkMailVerified(_ context.Context, userID int64) error {
+	return r.update(userID, func(user *Userdetail) error {
+		if user.MailVerifiedAt == nil {
+			now := time.Now()
+			user.MailVerifiedAt = &now
+		}
+		return nil
+	})
+}
+
+func (r *MemoryUserRepo) IsMailVerified(_ context.Context, userID int64) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return false, gorm.ErrRecordNotFound
+	}
+	return user.MailVerifiedAt != nil, nil
+}
+
+// update applies fn to a copy and stores it only when fn succeeds
+func (r *MemoryUserRepo) update(userID int64, fn func(user *Userdetail) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[userID]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	if err := fn(&user); err != nil {
+		return err
+	}
+	user.UpdatedAt = time.Now()
+	r.users[userID] = user
+	return nil
+}
+
+// usernameTaken must be called with the lock held
+func (r *MemoryUserRepo) usernameTaken(username string, exceptID int64) bool {
+	for id, user := range r.users {
+		if id != exceptID && user.Username == username {
+			return true
+		}
+	}
+	return false
+}
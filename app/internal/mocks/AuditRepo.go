@@ -0,0 +1,47 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	internal "sonartest_cart/app/internal"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditRepo is an autogenerated mock type for the AuditRepo type
+type AuditRepo struct {
+	mock.Mock
+}
+
+// Record provides a mock function with given fields: ctx, entry
+func (_m *AuditRepo) Record(ctx context.Context, entry *internal.Auditlog) error {
+	ret := _m.Called(ctx, entry)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Record")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *internal.Auditlog) error); ok {
+		r0 = rf(ctx, entry)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewAuditRepo creates a new instance of AuditRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewAuditRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *AuditRepo {
+	mock := &AuditRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,181 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	internal "sonartest_cart/app/internal"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// CartRepo is an autogenerated mock type for the CartRepo type
+type CartRepo struct {
+	mock.Mock
+}
+
+// AddItem provides a mock function with given fields: ctx, userID, categoryID, brandID, quantity, maxQuantity
+func (_m *CartRepo) AddItem(ctx context.Context, userID int64, categoryID int64, brandID int64, quantity int64, maxQuantity int64) (int64, error) {
+	ret := _m.Called(ctx, userID, categoryID, brandID, quantity, maxQuantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddItem")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64, int64, int64) (int64, error)); ok {
+		return rf(ctx, userID, categoryID, brandID, quantity, maxQuantity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64, int64, int64) int64); ok {
+		r0 = rf(ctx, userID, categoryID, brandID, quantity, maxQuantity)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, int64, int64, int64) error); ok {
+		r1 = rf(ctx, userID, categoryID, brandID, quantity, maxQuantity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClearCart provides a mock function with given fields: ctx, userID
+func (_m *CartRepo) ClearCart(ctx context.Context, userID int64) (int64, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearCart")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (int64, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) int64); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CountIdleCarts provides a mock function with given fields: ctx, idleSince
+func (_m *CartRepo) CountIdleCarts(ctx context.Context, idleSince time.Time) (int64, error) {
+	ret := _m.Called(ctx, idleSince)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CountIdleCarts")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) (int64, error)); ok {
+		return rf(ctx, idleSince)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int64); ok {
+		r0 = rf(ctx, idleSince)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, idleSince)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCartItems provides a mock function with given fields: ctx, userID
+func (_m *CartRepo) GetCartItems(ctx context.Context, userID int64) ([]internal.CartLine, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCartItems")
+	}
+
+	var r0 []internal.CartLine
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]internal.CartLine, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []internal.CartLine); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]internal.CartLine)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveItem provides a mock function with given fields: ctx, userID, brandID
+func (_m *CartRepo) RemoveItem(ctx context.Context, userID int64, brandID int64) error {
+	ret := _m.Called(ctx, userID, brandID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveItem")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, brandID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateQuantity provides a mock function with given fields: ctx, userID, brandID, quantity
+func (_m *CartRepo) UpdateQuantity(ctx context.Context, userID int64, brandID int64, quantity int64) error {
+	ret := _m.Called(ctx, userID, brandID, quantity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateQuantity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, int64) error); ok {
+		r0 = rf(ctx, userID, brandID, quantity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewCartRepo creates a new instance of CartRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCartRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CartRepo {
+	mock := &CartRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
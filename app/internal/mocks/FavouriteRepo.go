@@ -0,0 +1,95 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	internal "sonartest_cart/app/internal"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// FavouriteRepo is an autogenerated mock type for the FavouriteRepo type
+type FavouriteRepo struct {
+	mock.Mock
+}
+
+// AddFavourite provides a mock function with given fields: ctx, userID, brandID
+func (_m *FavouriteRepo) AddFavourite(ctx context.Context, userID int64, brandID int64) error {
+	ret := _m.Called(ctx, userID, brandID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddFavourite")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, brandID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetFavourites provides a mock function with given fields: ctx, userID
+func (_m *FavouriteRepo) GetFavourites(ctx context.Context, userID int64) ([]internal.Brand, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFavourites")
+	}
+
+	var r0 []internal.Brand
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]internal.Brand, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []internal.Brand); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]internal.Brand)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveFavourite provides a mock function with given fields: ctx, userID, brandID
+func (_m *FavouriteRepo) RemoveFavourite(ctx context.Context, userID int64, brandID int64) error {
+	ret := _m.Called(ctx, userID, brandID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveFavourite")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) error); ok {
+		r0 = rf(ctx, userID, brandID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewFavouriteRepo creates a new instance of FavouriteRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewFavouriteRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *FavouriteRepo {
+	mock := &FavouriteRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
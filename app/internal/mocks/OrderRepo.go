@@ -0,0 +1,89 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	internal "sonartest_cart/app/internal"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OrderRepo is an autogenerated mock type for the OrderRepo type
+type OrderRepo struct {
+	mock.Mock
+}
+
+// GetOrderHistory provides a mock function with given fields: ctx, filter
+func (_m *OrderRepo) GetOrderHistory(ctx context.Context, filter internal.OrderHistoryFilter) ([]internal.Order, error) {
+	ret := _m.Called(ctx, filter)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderHistory")
+	}
+
+	var r0 []internal.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, internal.OrderHistoryFilter) ([]internal.Order, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, internal.OrderHistoryFilter) []internal.Order); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]internal.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, internal.OrderHistoryFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlaceOrder provides a mock function with given fields: ctx, userID
+func (_m *OrderRepo) PlaceOrder(ctx context.Context, userID int64) (*internal.Order, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PlaceOrder")
+	}
+
+	var r0 *internal.Order
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*internal.Order, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *internal.Order); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Order)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewOrderRepo creates a new instance of OrderRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOrderRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OrderRepo {
+	mock := &OrderRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
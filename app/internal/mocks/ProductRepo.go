@@ -0,0 +1,241 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	dto "sonartest_cart/app/dto"
+
+	internal "sonartest_cart/app/internal"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ProductRepo is an autogenerated mock type for the ProductRepo type
+type ProductRepo struct {
+	mock.Mock
+}
+
+// CreateCategory provides a mock function with given fields: ctx, args
+func (_m *ProductRepo) CreateCategory(ctx context.Context, args *dto.CreateCategoryDetailRequest) (*internal.Category, error) {
+	ret := _m.Called(ctx, args)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCategory")
+	}
+
+	var r0 *internal.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.CreateCategoryDetailRequest) (*internal.Category, error)); ok {
+		return rf(ctx, args)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.CreateCategoryDetailRequest) *internal.Category); ok {
+		r0 = rf(ctx, args)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.CreateCategoryDetailRequest) error); ok {
+		r1 = rf(ctx, args)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetBrandByID provides a mock function with given fields: ctx, brandID
+func (_m *ProductRepo) GetBrandByID(ctx context.Context, brandID int64) (*internal.Brand, error) {
+	ret := _m.Called(ctx, brandID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetBrandByID")
+	}
+
+	var r0 *internal.Brand
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*internal.Brand, error)); ok {
+		return rf(ctx, brandID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *internal.Brand); ok {
+		r0 = rf(ctx, brandID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Brand)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, brandID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCategoryByID provides a mock function with given fields: ctx, categoryID
+func (_m *ProductRepo) GetCategoryByID(ctx context.Context, categoryID int64) (*internal.Category, error) {
+	ret := _m.Called(ctx, categoryID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCategoryByID")
+	}
+
+	var r0 *internal.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*internal.Category, error)); ok {
+		return rf(ctx, categoryID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *internal.Category); ok {
+		r0 = rf(ctx, categoryID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, categoryID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCategoryByName provides a mock function with given fields: ctx, categoryName
+func (_m *ProductRepo) GetCategoryByName(ctx context.Context, categoryName string) (*internal.Category, error) {
+	ret := _m.Called(ctx, categoryName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCategoryByName")
+	}
+
+	var r0 *internal.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*internal.Category, error)); ok {
+		return rf(ctx, categoryName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *internal.Category); ok {
+		r0 = rf(ctx, categoryName)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, categoryName)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCategories provides a mock function with given fields: ctx
+func (_m *ProductRepo) ListCategories(ctx context.Context) ([]internal.Category, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCategories")
+	}
+
+	var r0 []internal.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]internal.Category, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []internal.Category); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]internal.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateBrand provides a mock function with given fields: ctx, args
+func (_m *ProductRepo) UpdateBrand(ctx context.Context, args *dto.UpdateBrand) (*internal.Brand, error) {
+	ret := _m.Called(ctx, args)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBrand")
+	}
+
+	var r0 *internal.Brand
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.UpdateBrand) (*internal.Brand, error)); ok {
+		return rf(ctx, args)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.UpdateBrand) *internal.Brand); ok {
+		r0 = rf(ctx, args)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Brand)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.UpdateBrand) error); ok {
+		r1 = rf(ctx, args)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCategory provides a mock function with given fields: ctx, args
+func (_m *ProductRepo) UpdateCategory(ctx context.Context, args *dto.UpdateCategory) (*internal.Category, error) {
+	ret := _m.Called(ctx, args)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCategory")
+	}
+
+	var r0 *internal.Category
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.UpdateCategory) (*internal.Category, error)); ok {
+		return rf(ctx, args)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.UpdateCategory) *internal.Category); ok {
+		r0 = rf(ctx, args)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Category)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.UpdateCategory) error); ok {
+		r1 = rf(ctx, args)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewProductRepo creates a new instance of ProductRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewProductRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ProductRepo {
+	mock := &ProductRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
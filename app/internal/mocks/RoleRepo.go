@@ -0,0 +1,89 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	internal "sonartest_cart/app/internal"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RoleRepo is an autogenerated mock type for the RoleRepo type
+type RoleRepo struct {
+	mock.Mock
+}
+
+// GetRole provides a mock function with given fields: ctx, name
+func (_m *RoleRepo) GetRole(ctx context.Context, name string) (*internal.Role, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetRole")
+	}
+
+	var r0 *internal.Role
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*internal.Role, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *internal.Role); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Role)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRoles provides a mock function with given fields: ctx
+func (_m *RoleRepo) ListRoles(ctx context.Context) ([]internal.Role, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRoles")
+	}
+
+	var r0 []internal.Role
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]internal.Role, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []internal.Role); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]internal.Role)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewRoleRepo creates a new instance of RoleRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRoleRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RoleRepo {
+	mock := &RoleRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
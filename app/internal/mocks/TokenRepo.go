@@ -0,0 +1,257 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	internal "sonartest_cart/app/internal"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// TokenRepo is an autogenerated mock type for the TokenRepo type
+type TokenRepo struct {
+	mock.Mock
+}
+
+// CreateMailToken provides a mock function with given fields: ctx, token
+func (_m *TokenRepo) CreateMailToken(ctx context.Context, token *internal.Mailtoken) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMailToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *internal.Mailtoken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateRefreshToken provides a mock function with given fields: ctx, token
+func (_m *TokenRepo) CreateRefreshToken(ctx context.Context, token *internal.Refreshtoken) error {
+	ret := _m.Called(ctx, token)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateRefreshToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *internal.Refreshtoken) error); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindMailToken provides a mock function with given fields: ctx, purpose, tokenHash
+func (_m *TokenRepo) FindMailToken(ctx context.Context, purpose string, tokenHash string) (*internal.Mailtoken, error) {
+	ret := _m.Called(ctx, purpose, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindMailToken")
+	}
+
+	var r0 *internal.Mailtoken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*internal.Mailtoken, error)); ok {
+		return rf(ctx, purpose, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *internal.Mailtoken); ok {
+		r0 = rf(ctx, purpose, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Mailtoken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, purpose, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsTokenRevoked provides a mock function with given fields: ctx, jti
+func (_m *TokenRepo) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ret := _m.Called(ctx, jti)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsTokenRevoked")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, jti)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, jti)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, jti)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeAccessToken provides a mock function with given fields: ctx, jti, userID, expiresAt
+func (_m *TokenRepo) RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	ret := _m.Called(ctx, jti, userID, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeAccessToken")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, time.Time) error); ok {
+		r0 = rf(ctx, jti, userID, expiresAt)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeMailTokens provides a mock function with given fields: ctx, userID, purpose
+func (_m *TokenRepo) RevokeMailTokens(ctx context.Context, userID int64, purpose string) error {
+	ret := _m.Called(ctx, userID, purpose)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeMailTokens")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, userID, purpose)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeRefreshTokenFamily provides a mock function with given fields: ctx, tokenHash, userID
+func (_m *TokenRepo) RevokeRefreshTokenFamily(ctx context.Context, tokenHash string, userID int64) error {
+	ret := _m.Called(ctx, tokenHash, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeRefreshTokenFamily")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, tokenHash, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeUserRefreshTokens provides a mock function with given fields: ctx, userID
+func (_m *TokenRepo) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RevokeUserRefreshTokens")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RotateRefreshToken provides a mock function with given fields: ctx, oldHash, newHash, expiresAt
+func (_m *TokenRepo) RotateRefreshToken(ctx context.Context, oldHash string, newHash string, expiresAt time.Time) (*internal.Refreshtoken, error) {
+	ret := _m.Called(ctx, oldHash, newHash, expiresAt)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateRefreshToken")
+	}
+
+	var r0 *internal.Refreshtoken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) (*internal.Refreshtoken, error)); ok {
+		return rf(ctx, oldHash, newHash, expiresAt)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, time.Time) *internal.Refreshtoken); ok {
+		r0 = rf(ctx, oldHash, newHash, expiresAt)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Refreshtoken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, time.Time) error); ok {
+		r1 = rf(ctx, oldHash, newHash, expiresAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UseMailToken provides a mock function with given fields: ctx, purpose, tokenHash
+func (_m *TokenRepo) UseMailToken(ctx context.Context, purpose string, tokenHash string) (*internal.Mailtoken, error) {
+	ret := _m.Called(ctx, purpose, tokenHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseMailToken")
+	}
+
+	var r0 *internal.Mailtoken
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (*internal.Mailtoken, error)); ok {
+		return rf(ctx, purpose, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *internal.Mailtoken); ok {
+		r0 = rf(ctx, purpose, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Mailtoken)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, purpose, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewTokenRepo creates a new instance of TokenRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewTokenRepo(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *TokenRepo {
+	mock := &TokenRepo{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
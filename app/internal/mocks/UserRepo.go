@@ -3,10 +3,14 @@
 package mocks
 
 import (
+	context "context"
 	dto "sonartest_cart/app/dto"
+
 	internal "sonartest_cart/app/internal"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // UserRepo is an autogenerated mock type for the UserRepo type
@@ -14,9 +18,57 @@ type UserRepo struct {
 	mock.Mock
 }
 
-// GetUserByUsername provides a mock function with given fields: username
-func (_m *UserRepo) GetUserByUsername(username string) (*internal.Userdetail, error) {
-	ret := _m.Called(username)
+// ChangePassword provides a mock function with given fields: ctx, userID, passwordHash
+func (_m *UserRepo) ChangePassword(ctx context.Context, userID int64, passwordHash string) error {
+	ret := _m.Called(ctx, userID, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, userID, passwordHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetUserByID provides a mock function with given fields: ctx, userID
+func (_m *UserRepo) GetUserByID(ctx context.Context, userID int64) (*internal.Userdetail, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetUserByID")
+	}
+
+	var r0 *internal.Userdetail
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*internal.Userdetail, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *internal.Userdetail); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*internal.Userdetail)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserByUsername provides a mock function with given fields: ctx, username
+func (_m *UserRepo) GetUserByUsername(ctx context.Context, username string) (*internal.Userdetail, error) {
+	ret := _m.Called(ctx, username)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GetUserByUsername")
@@ -24,19 +76,19 @@ func (_m *UserRepo) GetUserByUsername(username string) (*internal.Userdetail, er
 
 	var r0 *internal.Userdetail
 	var r1 error
-	if rf, ok := ret.Get(0).(func(string) (*internal.Userdetail, error)); ok {
-		return rf(username)
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*internal.Userdetail, error)); ok {
+		return rf(ctx, username)
 	}
-	if rf, ok := ret.Get(0).(func(string) *internal.Userdetail); ok {
-		r0 = rf(username)
+	if rf, ok := ret.Get(0).(func(context.Context, string) *internal.Userdetail); ok {
+		r0 = rf(ctx, username)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*internal.Userdetail)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(username)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, username)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -44,9 +96,37 @@ func (_m *UserRepo) GetUserByUsername(username string) (*internal.Userdetail, er
 	return r0, r1
 }
 
-// IsUserActive provides a mock function with given fields: userID
-func (_m *UserRepo) IsUserActive(userID int64) (bool, error) {
-	ret := _m.Called(userID)
+// IsMailVerified provides a mock function with given fields: ctx, userID
+func (_m *UserRepo) IsMailVerified(ctx context.Context, userID int64) (bool, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for IsMailVerified")
+	}
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (bool, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bool); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsUserActive provides a mock function with given fields: ctx, userID
+func (_m *UserRepo) IsUserActive(ctx context.Context, userID int64) (bool, error) {
+	ret := _m.Called(ctx, userID)
 
 	if len(ret) == 0 {
 		panic("no return value specified for IsUserActive")
@@ -54,17 +134,47 @@ func (_m *UserRepo) IsUserActive(userID int64) (bool, error) {
 
 	var r0 bool
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64) (bool, error)); ok {
-		return rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (bool, error)); ok {
+		return rf(ctx, userID)
 	}
-	if rf, ok := ret.Get(0).(func(int64) bool); ok {
-		r0 = rf(userID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bool); ok {
+		r0 = rf(ctx, userID)
 	} else {
 		r0 = ret.Get(0).(bool)
 	}
 
-	if rf, ok := ret.Get(1).(func(int64) error); ok {
-		r1 = rf(userID)
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: ctx
+func (_m *UserRepo) ListUsers(ctx context.Context) ([]internal.Userdetail, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 []internal.Userdetail
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]internal.Userdetail, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []internal.Userdetail); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]internal.Userdetail)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -72,9 +182,27 @@ func (_m *UserRepo) IsUserActive(userID int64) (bool, error) {
 	return r0, r1
 }
 
-// SaveUserDetails provides a mock function with given fields: args
-func (_m *UserRepo) SaveUserDetails(args *dto.UserDetailSaveRequest) (int64, error) {
-	ret := _m.Called(args)
+// MarkMailVerified provides a mock function with given fields: ctx, userID
+func (_m *UserRepo) MarkMailVerified(ctx context.Context, userID int64) error {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for MarkMailVerified")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SaveUserDetails provides a mock function with given fields: ctx, args
+func (_m *UserRepo) SaveUserDetails(ctx context.Context, args *dto.UserDetailSaveRequest) (int64, error) {
+	ret := _m.Called(ctx, args)
 
 	if len(ret) == 0 {
 		panic("no return value specified for SaveUserDetails")
@@ -82,17 +210,17 @@ func (_m *UserRepo) SaveUserDetails(args *dto.UserDetailSaveRequest) (int64, err
 
 	var r0 int64
 	var r1 error
-	if rf, ok := ret.Get(0).(func(*dto.UserDetailSaveRequest) (int64, error)); ok {
-		return rf(args)
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.UserDetailSaveRequest) (int64, error)); ok {
+		return rf(ctx, args)
 	}
-	if rf, ok := ret.Get(0).(func(*dto.UserDetailSaveRequest) int64); ok {
-		r0 = rf(args)
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.UserDetailSaveRequest) int64); ok {
+		r0 = rf(ctx, args)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
-	if rf, ok := ret.Get(1).(func(*dto.UserDetailSaveRequest) error); ok {
-		r1 = rf(args)
+	if rf, ok := ret.Get(1).(func(context.Context, *dto.UserDetailSaveRequest) error); ok {
+		r1 = rf(ctx, args)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -100,6 +228,113 @@ func (_m *UserRepo) SaveUserDetails(args *dto.UserDetailSaveRequest) (int64, err
 	return r0, r1
 }
 
+// UpdatePassword provides a mock function with given fields: ctx, userID, passwordHash
+func (_m *UserRepo) UpdatePassword(ctx context.Context, userID int64, passwordHash string) error {
+	ret := _m.Called(ctx, userID, passwordHash)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdatePassword")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, userID, passwordHash)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUserDetails provides a mock function with given fields: ctx, args
+func (_m *UserRepo) UpdateUserDetails(ctx context.Context, args *dto.UpdateUserDetailRequest) error {
+	ret := _m.Called(ctx, args)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserDetails")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *dto.UpdateUserDetailRequest) error); ok {
+		r0 = rf(ctx, args)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUserRole provides a mock function with given fields: ctx, userID, role
+func (_m *UserRepo) UpdateUserRole(ctx context.Context, userID int64, role string) error {
+	ret := _m.Called(ctx, userID, role)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserRole")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, string) error); ok {
+		r0 = rf(ctx, userID, role)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UpdateUserStatus provides a mock function with given fields: ctx, userID, status
+func (_m *UserRepo) UpdateUserStatus(ctx context.Context, userID int64, status bool) error {
+	ret := _m.Called(ctx, userID, status)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateUserStatus")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) error); ok {
+		r0 = rf(ctx, userID, status)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// UserStatus provides a mock function with given fields: ctx, userID
+func (_m *UserRepo) UserStatus(ctx context.Context, userID int64) (bool, time.Time, error) {
+	ret := _m.Called(ctx, userID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UserStatus")
+	}
+
+	var r0 bool
+	var r1 time.Time
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (bool, time.Time, error)); ok {
+		return rf(ctx, userID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bool); ok {
+		r0 = rf(ctx, userID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) time.Time); ok {
+		r1 = rf(ctx, userID)
+	} else {
+		r1 = ret.Get(1).(time.Time)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, int64) error); ok {
+		r2 = rf(ctx, userID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // NewUserRepo creates a new instance of UserRepo. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewUserRepo(t interface {
@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	OrderStatusPlaced = "placed"
+)
+
+var (
+	ErrEmptyCart         = errors.New("cart is empty")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrStockUpdate       = errors.New("stock update failed")
+)
+
+type OrderRepo interface {
+	PlaceOrder(ctx context.Context, userID int64) (*Order, error)
+	GetOrderHistory(ctx context.Context, filter OrderHistoryFilter) ([]Order, error)
+}
+
+// OrderHistoryFilter selects a page of a user's orders, newest first.
+// From is inclusive and To exclusive. When CursorCreatedAt is set only orders
+// strictly older than (CursorCreatedAt, CursorID) are returned.
+type OrderHistoryFilter struct {
+	UserID          int64
+	From            *time.Time
+	To              *time.Time
+	CursorCreatedAt *time.Time
+	CursorID        int64
+	Limit           int
+}
+
+type OrderRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewOrderRepo(db *gorm.DB) OrderRepo {
+	return &OrderRepoImpl{
+		db: db,
+	}
+}
+
+type Order struct {
+	ID         int64       `gorm:"primaryKey"`
+	UserID     int64       `gorm:"column:user_id;not null;index;index:idx_orders_user_created,priority:1"`
+	TotalPrice float64     `gorm:"column:total_price;not null"`
+	Status     string      `gorm:"column:status;not null;default:placed"`
+	CreatedAt  time.Time   `gorm:"column:created_at;autoCreateTime;index:idx_orders_user_created,priority:2"`
+	UpdatedAt  time.Time   `gorm:"column:updated_at;autoUpdateTime"`
+	Items      []Orderitem `gorm:"foreignKey:OrderID"`
+}
+
+func (Order) TableName() string {
+	return "orders"
+}
+
+// Orderitem snapshots the brand name and price at the time of the order
+type Orderitem struct {
+	ID         int64   `gorm:"primaryKey"`
+	OrderID    int64   `gorm:"column:order_id;not null;index"`
+	BrandID    int64   `gorm:"column:brand_id;not null"`
+	CategoryID int64   `gorm:"column:category_id;not null"`
+	BrandName  string  `gorm:"column:brand_name;not null"`
+	Price      float64 `gorm:"column:price;not null"`
+	Quantity   int64   `gorm:"column:quantity;not null"`
+}
+
+func (Orderitem) TableName() string {
+	return "orderitems"
+}
+
+// PlaceOrder turns the user's cart into an order in a single transaction.
+// The cart lines and brand rows are locked with SELECT ... FOR UPDATE (in id
+// order, so concurrent checkouts can't deadlock) and nothing is written unless
+// every line is in stock. Only the lines that went into the order are removed,
+// a line added meanwhile stays in the cart.
+func (r *OrderRepoImpl) PlaceOrder(ctx context.Context, userID int64) (*Order, error) {
+	var order *Order
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cart []Cartitem
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", userID).
+			Order("brand_id").
+			Find(&cart).Error
+		if err != nil {
+			return err
+		}
+		if len(cart) == 0 {
+			return ErrEmptyCart
+		}
+
+		brandIDs := make([]int64, 0, len(cart))
+		lineIDs := make([]int64, 0, len(cart))
+		for _, line := range cart {
+			brandIDs = append(brandIDs, line.BrandID)
+			lineIDs = append(lineIDs, line.ID)
+		}
+
+		var brands []Brand
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", brandIDs).
+			Order("id").
+			Find(&brands).Error
+		if err != nil {
+			return err
+		}
+		brandByID := make(map[int64]Brand, len(brands))
+		for _, b := range brands {
+			brandByID[b.ID] = b
+		}
+
+		// check every line first so the error lists all short brands
+		var short []string
+		for _, line := range cart {
+			brand, ok := brandByID[line.BrandID]
+			if !ok || brand.StockCount < line.Quantity {
+				short = append(short, fmt.Sprintf("brand %d (requested %d, available %d)", line.BrandID, line.Quantity, brand.StockCount))
+			}
+		}
+		if len(short) > 0 {
+			return fmt.Errorf("%w: %s", ErrInsufficientStock, strings.Join(short, ", "))
+		}
+
+		order = &Order{
+			UserID: userID,
+			Status: OrderStatusPlaced,
+			Items:  make([]Orderitem, 0, len(cart)),
+		}
+		for _, line := range cart {
+			brand := brandByID[line.BrandID]
+
+			err := tx.Model(&Brand{}).
+				Where("id = ?", brand.ID).
+				Update("stock_count", gorm.Expr("stock_count - ?", line.Quantity)).Error
+			if err != nil {
+				return fmt.Errorf("%w: brand %d: %v", ErrStockUpdate, brand.ID, err)
+			}
+
+			order.Items = append(order.Items, Orderitem{
+				BrandID:    brand.ID,
+				CategoryID: brand.CategoryID,
+				BrandName:  brand.BrandName,
+				Price:      brand.Price,
+				Quantity:   line.Quantity,
+			})
+			order.TotalPrice += brand.Price * float64(line.Quantity)
+		}
+		order.TotalPrice = math.Round(order.TotalPrice*100) / 100
+
+		if err := tx.Create(order).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id IN ?", lineIDs).Delete(&Cartitem{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetOrderHistory pages through a user's orders with keyset pagination on
+// (created_at, id), so deep pages cost the same as the first one.
+func (r *OrderRepoImpl) GetOrderHistory(ctx context.Context, filter OrderHistoryFilter) ([]Order, error) {
+	query := r.db.WithContext(ctx).Where("user_id = ?", filter.UserID)
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+	if filter.CursorCreatedAt != nil {
+		query = query.Where("(created_at, id) < (?, ?)", *filter.CursorCreatedAt, filter.CursorID)
+	}
+
+	var orders []Order
+	err := query.
+		Preload("Items", func(db *gorm.DB) *gorm.DB {
+			return db.Order("orderitems.id")
+		}).
+		Order("created_at DESC, id DESC").
+		Limit(filter.Limit).
+		Find(&orders).Error
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
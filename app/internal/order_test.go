@@ -0,0 +1,236 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestPlaceOrder(t *testing.T) {
+	cartRows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "user_id", "category_id", "brand_id", "quantity"}).
+			AddRow(1, 7, 2, 3, 2).
+			AddRow(2, 7, 2, 4, 1)
+	}
+
+	tests := []struct {
+		name      string
+		wantErr   error
+		wantTotal float64
+		query     func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:      "success-case",
+			wantErr:   nil,
+			wantTotal: 40.49,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				// the lines are locked so a concurrent add can't change them unnoticed
+				mock.ExpectQuery(`^SELECT \* FROM "cartitems" WHERE user_id = \$1 ORDER BY brand_id FOR UPDATE$`).
+					WithArgs(int64(7)).
+					WillReturnRows(cartRows())
+				mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE id IN \(\$1,\$2\) ORDER BY id FOR UPDATE$`).
+					WithArgs(int64(3), int64(4)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "brand_name", "price", "stock_count"}).
+						AddRow(3, 2, "ACME", 10.25, 5).
+						AddRow(4, 2, "Globex", 19.99, 1))
+				mock.ExpectExec(`^UPDATE "brands" SET "stock_count"=stock_count - \$1,"updated_at"=\$2 WHERE id = \$3$`).
+					WithArgs(int64(2), sqlmock.AnyArg(), int64(3)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec(`^UPDATE "brands" SET "stock_count"=stock_count - \$1,"updated_at"=\$2 WHERE id = \$3$`).
+					WithArgs(int64(1), sqlmock.AnyArg(), int64(4)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`^INSERT INTO "orders" \("user_id","total_price","status","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5\) RETURNING "id"$`).
+					WithArgs(int64(7), 40.49, "placed", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11))
+				mock.ExpectQuery(`^INSERT INTO "orderitems" \("order_id","brand_id","category_id","brand_name","price","quantity"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\),\(\$7,\$8,\$9,\$10,\$11,\$12\) ON CONFLICT \("id"\) DO UPDATE SET "order_id"="excluded"."order_id" RETURNING "id"$`).
+					WithArgs(int64(11), int64(3), int64(2), "ACME", 10.25, int64(2), int64(11), int64(4), int64(2), "Globex", 19.99, int64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(21).AddRow(22))
+				// only the ordered lines go, one added meanwhile stays in the cart
+				mock.ExpectExec(`^DELETE FROM "cartitems" WHERE id IN \(\$1,\$2\)$`).
+					WithArgs(int64(1), int64(2)).
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "empty-cart",
+			wantErr: ErrEmptyCart,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT \* FROM "cartitems" WHERE user_id = \$1`).
+					WithArgs(int64(7)).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:    "insufficient-stock-writes-nothing",
+			wantErr: ErrInsufficientStock,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT \* FROM "cartitems" WHERE user_id = \$1`).
+					WithArgs(int64(7)).
+					WillReturnRows(cartRows())
+				mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE id IN \(\$1,\$2\) ORDER BY id FOR UPDATE$`).
+					WithArgs(int64(3), int64(4)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "brand_name", "price", "stock_count"}).
+						AddRow(3, 2, "ACME", 10.25, 5).
+						AddRow(4, 2, "Globex", 19.99, 0))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:    "stock-update-error",
+			wantErr: ErrStockUpdate,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^SELECT \* FROM "cartitems" WHERE user_id = \$1`).
+					WithArgs(int64(7)).
+					WillReturnRows(cartRows())
+				mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE id IN`).
+					WithArgs(int64(3), int64(4)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "brand_name", "price", "stock_count"}).
+						AddRow(3, 2, "ACME", 10.25, 5).
+						AddRow(4, 2, "Globex", 19.99, 1))
+				mock.ExpectExec(`^UPDATE "brands"`).
+					WillReturnError(fmt.Errorf("database error"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	repo := NewOrderRepo(gdb)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.PlaceOrder(context.Background(), 7)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("PlaceOrder() error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr == nil {
+				if got.ID != 11 || got.TotalPrice != test.wantTotal || len(got.Items) != 2 {
+					t.Errorf("PlaceOrder() = %+v", got)
+				}
+				if got.Items[1].Price != 19.99 || got.Items[1].BrandName != "Globex" {
+					t.Errorf("PlaceOrder() did not snapshot brand details: %+v", got.Items[1])
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	cursorAt := time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		filter  OrderHistoryFilter
+		want    int
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "first-page",
+			filter:  OrderHistoryFilter{UserID: 7, Limit: 3},
+			want:    2,
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT \* FROM "orders" WHERE user_id = \$1 ORDER BY created_at DESC, id DESC LIMIT \$2$`).
+					WithArgs(int64(7), 3).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "total_price", "status", "created_at"}).
+						AddRow(12, 7, 20.5, "placed", cursorAt).
+						AddRow(11, 7, 10.25, "placed", from))
+				mock.ExpectQuery(`^SELECT \* FROM "orderitems" WHERE "orderitems"."order_id" IN \(\$1,\$2\) ORDER BY orderitems.id$`).
+					WithArgs(int64(12), int64(11)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "order_id", "brand_id", "brand_name", "price", "quantity"}).
+						AddRow(1, 11, 3, "ACME", 10.25, 1).
+						AddRow(2, 12, 3, "ACME", 10.25, 2))
+			},
+		},
+		{
+			name:    "date-range-and-cursor",
+			filter:  OrderHistoryFilter{UserID: 7, From: &from, To: &to, CursorCreatedAt: &cursorAt, CursorID: 12, Limit: 3},
+			want:    0,
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT \* FROM "orders" WHERE user_id = \$1 AND created_at >= \$2 AND created_at < \$3 AND \(created_at, id\) < \(\$4, \$5\) ORDER BY created_at DESC, id DESC LIMIT \$6$`).
+					WithArgs(int64(7), from, to, cursorAt, int64(12), 3).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+		{
+			name:    "database-error",
+			filter:  OrderHistoryFilter{UserID: 7, Limit: 3},
+			want:    0,
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT \* FROM "orders"`).
+					WillReturnError(fmt.Errorf("select failed"))
+			},
+		},
+	}
+
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	repo := NewOrderRepo(gdb)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.GetOrderHistory(context.Background(), test.filter)
+			if (err != nil) != test.wantErr {
+				t.Errorf("GetOrderHistory() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if len(got) != test.want {
+				t.Fatalf("GetOrderHistory() returned %d orders, want %d", len(got), test.want)
+			}
+			if test.want > 0 && (got[0].ID != 12 || len(got[0].Items) != 1 || got[0].Items[0].Quantity != 2) {
+				t.Errorf("GetOrderHistory() did not attach items: %+v", got[0])
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
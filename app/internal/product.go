@@ -0,0 +1,165 @@
+package internal
+
+import (
+	"context"
+	"sonartest_cart/app/dto"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProductRepo interface {
+	CreateCategory(ctx context.Context, args *dto.CreateCategoryDetailRequest) (*Category, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	GetCategoryByID(ctx context.Context, categoryID int64) (*Category, error)
+	GetCategoryByName(ctx context.Context, categoryName string) (*Category, error)
+	UpdateCategory(ctx context.Context, args *dto.UpdateCategory) (*Category, error)
+	GetBrandByID(ctx context.Context, brandID int64) (*Brand, error)
+	UpdateBrand(ctx context.Context, args *dto.UpdateBrand) (*Brand, error)
+}
+
+type ProductRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewProductRepo(db *gorm.DB) ProductRepo {
+	return &ProductRepoImpl{
+		db: db,
+	}
+}
+
+type Category struct {
+	ID           int64     `gorm:"primaryKey"`
+	CategoryName string    `gorm:"column:category_name;unique;not null"` // always stored upper-cased
+	Description  string    `gorm:"column:description"`
+	CreatedAt    time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt    time.Time `gorm:"column:updated_at;autoUpdateTime"`
+	Brands       []Brand   `gorm:"foreignKey:CategoryID"`
+}
+
+func (Category) TableName() string {
+	return "categories"
+}
+
+type Brand struct {
+	ID         int64     `gorm:"primaryKey"`
+	CategoryID int64     `gorm:"column:category_id;not null;index"`
+	BrandName  string    `gorm:"column:brand_name;not null"`
+	Price      float64   `gorm:"column:price;not null"`
+	StockCount int64     `gorm:"column:stock_count;not null"`
+	ImageLink  string    `gorm:"column:image_link"`
+	CreatedAt  time.Time `gorm:"column:created_at;autoCreateTime"`
+	UpdatedAt  time.Time `gorm:"column:updated_at;autoUpdateTime"`
+}
+
+func (Brand) TableName() string {
+	return "brands"
+}
+
+// CreateCategory inserts the category together with its brands in one transaction
+func (r *ProductRepoImpl) CreateCategory(ctx context.Context, args *dto.CreateCategoryDetailRequest) (*Category, error) {
+	category := Category{
+		CategoryName: args.CategoryName,
+		Description:  args.Description,
+		Brands:       make([]Brand, 0, len(args.Brands)),
+	}
+	for _, b := range args.Brands {
+		category.Brands = append(category.Brands, Brand{
+			BrandName:  b.BrandName,
+			Price:      b.Price,
+			StockCount: b.StockCount,
+			ImageLink:  b.ImageLink,
+		})
+	}
+
+	//GORM creates the has-many brands in the same transaction
+	if err := r.db.WithContext(ctx).Create(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *ProductRepoImpl) ListCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	if err := r.db.WithContext(ctx).Table("categories").Order("id").Find(&categories).Error; err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *ProductRepoImpl) GetCategoryByID(ctx context.Context, categoryID int64) (*Category, error) {
+	var category Category
+	err := r.db.WithContext(ctx).Preload("Brands", func(db *gorm.DB) *gorm.DB {
+		return db.Order("brands.id")
+	}).Where("id = ?", categoryID).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *ProductRepoImpl) GetCategoryByName(ctx context.Context, categoryName string) (*Category, error) {
+	var category Category
+	err := r.db.WithContext(ctx).Preload("Brands", func(db *gorm.DB) *gorm.DB {
+		return db.Order("brands.id")
+	}).Where("category_name = ?", categoryName).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *ProductRepoImpl) UpdateCategory(ctx context.Context, args *dto.UpdateCategory) (*Category, error) {
+	result := r.db.WithContext(ctx).Model(&Category{}).Where("id = ?", args.CategoryID).Update("category_name", args.CategoryName)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var category Category
+	if err := r.db.WithContext(ctx).Table("categories").Where("id = ?", args.CategoryID).First(&category).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *ProductRepoImpl) GetBrandByID(ctx context.Context, brandID int64) (*Brand, error) {
+	var brand Brand
+	if err := r.db.WithContext(ctx).Table("brands").Where("id = ?", brandID).First(&brand).Error; err != nil {
+		return nil, err
+	}
+	return &brand, nil
+}
+
+// UpdateBrand only touches the fields that were sent
+func (r *ProductRepoImpl) UpdateBrand(ctx context.Context, args *dto.UpdateBrand) (*Brand, error) {
+	updates := map[string]interface{}{}
+	if args.BrandName != "" {
+		updates["brand_name"] = args.BrandName
+	}
+	if args.Price != nil {
+		updates["price"] = *args.Price
+	}
+	if args.StockCount != nil {
+		updates["stock_count"] = *args.StockCount
+	}
+	if args.ImageLink != "" {
+		updates["image_link"] = args.ImageLink
+	}
+	// an empty update would affect no rows and look like a missing brand
+	if len(updates) == 0 {
+		return r.GetBrandByID(ctx, args.BrandId)
+	}
+
+	result := r.db.WithContext(ctx).Model(&Brand{}).Where("id = ?", args.BrandId).Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return r.GetBrandByID(ctx, args.BrandId)
+}
@@ -0,0 +1,376 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sonartest_cart/app/dto"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestGetBrandByID(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	repo := NewProductRepo(gdb)
+
+	rows := sqlmock.NewRows([]string{"id", "category_id", "brand_name", "price", "stock_count", "image_link", "created_at", "updated_at"}).
+		AddRow(3, 2, "ACME", 10.5, 7, "http://img", time.Now(), time.Now())
+	mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE id = \$1 ORDER BY "brands"."id" LIMIT \$2$`).
+		WithArgs(int64(3), 1).
+		WillReturnRows(rows)
+
+	brand, err := repo.GetBrandByID(context.Background(), 3)
+	if err != nil {
+		t.Fatalf("GetBrandByID() unexpected error: %v", err)
+	}
+	if brand.BrandName != "ACME" || brand.StockCount != 7 {
+		t.Errorf("GetBrandByID() = %+v", brand)
+	}
+
+	mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE id = \$1`).
+		WithArgs(int64(4), 1).
+		WillReturnError(gorm.ErrRecordNotFound)
+
+	if _, err := repo.GetBrandByID(context.Background(), 4); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("GetBrandByID() error = %v, want record not found", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func newProductRepoMock(t *testing.T) (ProductRepo, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	return NewProductRepo(gdb), mock
+}
+
+func TestCreateCategory(t *testing.T) {
+	req := &dto.CreateCategoryDetailRequest{
+		CategoryName: "PHONES",
+		Description:  "smart phones",
+		Brands: []dto.BrandDetailRequest{
+			{BrandName: "ACME", Price: 10.5, StockCount: 7, ImageLink: "http://img/acme"},
+			{BrandName: "Globex", Price: 20, StockCount: 3, ImageLink: "http://img/globex"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-case",
+			wantErr: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^INSERT INTO "categories" \("category_name","description","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4\) RETURNING "id"$`).
+					WithArgs("PHONES", "smart phones", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+				mock.ExpectQuery(`^INSERT INTO "brands" \("category_id","brand_name","price","stock_count","image_link","created_at","updated_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\),\(\$8,\$9,\$10,\$11,\$12,\$13,\$14\) ON CONFLICT \("id"\) DO UPDATE SET "category_id"="excluded"."category_id" RETURNING "id"$`).
+					WithArgs(int64(1), "ACME", 10.5, int64(7), "http://img/acme", sqlmock.AnyArg(), sqlmock.AnyArg(),
+						int64(1), "Globex", float64(20), int64(3), "http://img/globex", sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(10).AddRow(11))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "duplicate-category",
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`^INSERT INTO "categories"`).
+					WillReturnError(fmt.Errorf("duplicate key value violates unique constraint"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	repo, mock := newProductRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.CreateCategory(context.Background(), req)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("CreateCategory() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if !test.wantErr {
+				if got.ID != 1 || len(got.Brands) != 2 || got.Brands[0].ID != 10 || got.Brands[1].CategoryID != 1 {
+					t.Errorf("CreateCategory() = %+v", got)
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestListCategories(t *testing.T) {
+	repo, mock := newProductRepoMock(t)
+
+	rows := sqlmock.NewRows([]string{"id", "category_name", "description"}).
+		AddRow(1, "PHONES", "smart phones").
+		AddRow(2, "LAPTOPS", "")
+	mock.ExpectQuery(`^SELECT \* FROM "categories" ORDER BY id$`).WillReturnRows(rows)
+
+	got, err := repo.ListCategories(context.Background())
+	if err != nil {
+		t.Fatalf("ListCategories() unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[1].CategoryName != "LAPTOPS" {
+		t.Errorf("ListCategories() = %+v", got)
+	}
+
+	mock.ExpectQuery(`^SELECT \* FROM "categories"`).WillReturnError(fmt.Errorf("database error"))
+	if _, err := repo.ListCategories(context.Background()); err == nil {
+		t.Error("ListCategories() expected error")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetCategoryByID(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr error
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-case",
+			wantErr: nil,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT \* FROM "categories" WHERE id = \$1 ORDER BY "categories"."id" LIMIT \$2$`).
+					WithArgs(int64(1), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "category_name", "description"}).AddRow(1, "PHONES", "smart phones"))
+				mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE "brands"."category_id" = \$1 ORDER BY brands.id$`).
+					WithArgs(int64(1)).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "brand_name", "price", "stock_count"}).AddRow(10, 1, "ACME", 10.5, 7))
+			},
+		},
+		{
+			name:    "not-found",
+			wantErr: gorm.ErrRecordNotFound,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT \* FROM "categories" WHERE id = \$1`).
+					WithArgs(int64(1), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}))
+			},
+		},
+	}
+
+	repo, mock := newProductRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.GetCategoryByID(context.Background(), 1)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("GetCategoryByID() error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr == nil && (len(got.Brands) != 1 || got.Brands[0].BrandName != "ACME") {
+				t.Errorf("GetCategoryByID() = %+v", got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestGetCategoryByName(t *testing.T) {
+	repo, mock := newProductRepoMock(t)
+
+	mock.ExpectQuery(`^SELECT \* FROM "categories" WHERE category_name = \$1 ORDER BY "categories"."id" LIMIT \$2$`).
+		WithArgs("PHONES", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_name"}).AddRow(1, "PHONES"))
+	mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE "brands"."category_id" = \$1 ORDER BY brands.id$`).
+		WithArgs(int64(1)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "brand_name"}))
+
+	got, err := repo.GetCategoryByName(context.Background(), "PHONES")
+	if err != nil {
+		t.Fatalf("GetCategoryByName() unexpected error: %v", err)
+	}
+	if got.ID != 1 || len(got.Brands) != 0 {
+		t.Errorf("GetCategoryByName() = %+v", got)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUpdateCategory(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr error
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-case",
+			wantErr: nil,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "categories" SET "category_name"=\$1,"updated_at"=\$2 WHERE id = \$3$`).
+					WithArgs("TABLETS", sqlmock.AnyArg(), int64(1)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`^SELECT \* FROM "categories" WHERE id = \$1`).
+					WithArgs(int64(1), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "category_name"}).AddRow(1, "TABLETS"))
+			},
+		},
+		{
+			name:    "not-found",
+			wantErr: gorm.ErrRecordNotFound,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "categories"`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+	}
+
+	repo, mock := newProductRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.UpdateCategory(context.Background(), &dto.UpdateCategory{CategoryID: 1, CategoryName: "TABLETS"})
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("UpdateCategory() error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr == nil && got.CategoryName != "TABLETS" {
+				t.Errorf("UpdateCategory() = %+v", got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestUpdateBrand(t *testing.T) {
+	stock := int64(0)
+	price := 12.5
+	free := 0.0
+
+	tests := []struct {
+		name    string
+		args    *dto.UpdateBrand
+		wantErr error
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "only-sent-fields",
+			args:    &dto.UpdateBrand{BrandId: 10, Price: &price, StockCount: &stock},
+			wantErr: nil,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "brands" SET "price"=\$1,"stock_count"=\$2,"updated_at"=\$3 WHERE id = \$4$`).
+					WithArgs(12.5, int64(0), sqlmock.AnyArg(), int64(10)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE id = \$1`).
+					WithArgs(int64(10), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "brand_name", "price", "stock_count"}).AddRow(10, 1, "ACME", 12.5, 0))
+			},
+		},
+		{
+			name:    "price-can-be-zero",
+			args:    &dto.UpdateBrand{BrandId: 10, Price: &free},
+			wantErr: nil,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "brands" SET "price"=\$1,"updated_at"=\$2 WHERE id = \$3$`).
+					WithArgs(0.0, sqlmock.AnyArg(), int64(10)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectCommit()
+				mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE id = \$1`).
+					WithArgs(int64(10), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "brand_name", "price", "stock_count"}).AddRow(10, 1, "ACME", 12.5, 0))
+			},
+		},
+		{
+			name:    "empty-update-is-not-not-found",
+			args:    &dto.UpdateBrand{BrandId: 10},
+			wantErr: nil,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT \* FROM "brands" WHERE id = \$1`).
+					WithArgs(int64(10), 1).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "category_id", "brand_name", "price", "stock_count"}).AddRow(10, 1, "ACME", 12.5, 0))
+			},
+		},
+		{
+			name:    "not-found",
+			args:    &dto.UpdateBrand{BrandId: 10, BrandName: "ACME"},
+			wantErr: gorm.ErrRecordNotFound,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectExec(`^UPDATE "brands" SET "brand_name"=\$1,"updated_at"=\$2 WHERE id = \$3$`).
+					WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectCommit()
+			},
+		},
+	}
+
+	repo, mock := newProductRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.UpdateBrand(context.Background(), test.args)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("UpdateBrand() error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr == nil && got.Price != 12.5 {
+				t.Errorf("UpdateBrand() = %+v", got)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
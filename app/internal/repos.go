@@ -0,0 +1,30 @@
+package internal
+
+import "gorm.io/gorm"
+
+// Repos bundles every repository the services are built from, so a storage
+// driver can swap single implementations
+type Repos struct {
+	User      UserRepo
+	Token     TokenRepo
+	Role      RoleRepo
+	Product   ProductRepo
+	Cart      CartRepo
+	Order     OrderRepo
+	Favourite FavouriteRepo
+	Audit     AuditRepo
+}
+
+// NewRepos builds the gorm backed repositories
+func NewRepos(db *gorm.DB) Repos {
+	return Repos{
+		User:      NewUserRepo(db),
+		Token:     NewTokenRepo(db),
+		Role:      NewRoleRepo(db),
+		Product:   NewProductRepo(db),
+		Cart:      NewCartRepo(db),
+		Order:     NewOrderRepo(db),
+		Favourite: NewFavouriteRepo(db),
+		Audit:     NewAuditRepo(db),
+	}
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"context"
+
+	"sonartest_cart/pkg/rbac"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepo interface {
+	GetRole(ctx context.Context, name string) (*Role, error)
+	ListRoles(ctx context.Context) ([]Role, error)
+}
+
+type RoleRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewRoleRepo(db *gorm.DB) RoleRepo {
+	return &RoleRepoImpl{
+		db: db,
+	}
+}
+
+// Role is referenced by userdetails.role, its permissions decide which admin
+// endpoints the users holding it may call
+type Role struct {
+	Name        string           `gorm:"column:name;primaryKey"`
+	Description string           `gorm:"column:description;not null;default:''"`
+	Permissions []Rolepermission `gorm:"foreignKey:Role;references:Name;constraint:OnDelete:CASCADE"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
+
+// PermissionNames lists the permissions granted to the role
+func (r Role) PermissionNames() []rbac.Permission {
+	names := make([]rbac.Permission, len(r.Permissions))
+	for i, p := range r.Permissions {
+		names[i] = p.Permission
+	}
+	return names
+}
+
+type Rolepermission struct {
+	Role       string `gorm:"column:role;primaryKey"`
+	Permission string `gorm:"column:permission;primaryKey"`
+}
+
+func (Rolepermission) TableName() string {
+	return "rolepermissions"
+}
+
+func (r *RoleRepoImpl) GetRole(ctx context.Context, name string) (*Role, error) {
+	var role Role
+	err := r.db.WithContext(ctx).
+		Preload("Permissions", func(db *gorm.DB) *gorm.DB { return db.Order("permission") }).
+		Where("name = ?", name).
+		First(&role).Error
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *RoleRepoImpl) ListRoles(ctx context.Context) ([]Role, error) {
+	var roles []Role
+	err := r.db.WithContext(ctx).
+		Preload("Permissions", func(db *gorm.DB) *gorm.DB { return db.Order("permission") }).
+		Order("name").
+		Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// SeedRoles adds the default roles and their grants, rows that already
+// exist are skipped
+func SeedRoles(ctx context.Context, db *gorm.DB) error {
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, def := range rbac.DefaultRoles {
+			role := Role{Name: def.Name, Description: def.Description}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&role).Error; err != nil {
+				return err
+			}
+			for _, p := range def.Permissions {
+				grant := Rolepermission{Role: def.Name, Permission: p}
+				if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&grant).Error; err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
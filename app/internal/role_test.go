@@ -0,0 +1,58 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"sonartest_cart/pkg/rbac"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newSeededRoleRepo(t *testing.T) (RoleRepo, *gorm.DB) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	sqlDb, err := db.DB()
+	require.NoError(t, err)
+	// every connection to :memory: is its own database
+	sqlDb.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDb.Close() })
+
+	require.NoError(t, db.AutoMigrate(&Role{}, &Rolepermission{}))
+	require.NoError(t, SeedRoles(context.Background(), db))
+	return NewRoleRepo(db), db
+}
+
+func TestRoleRepo(t *testing.T) {
+	repo, db := newSeededRoleRepo(t)
+
+	support, err := repo.GetRole(context.Background(), rbac.RoleSupport)
+	require.NoError(t, err)
+	assert.Equal(t, "support", support.Name)
+	assert.Equal(t, []string{"orders:read", "users:block", "users:read"}, support.PermissionNames())
+
+	customer, err := repo.GetRole(context.Background(), rbac.RoleCustomer)
+	require.NoError(t, err)
+	assert.Empty(t, customer.PermissionNames())
+
+	_, err = repo.GetRole(context.Background(), "superuser")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	roles, err := repo.ListRoles(context.Background())
+	require.NoError(t, err)
+	names := make([]string, len(roles))
+	for i, role := range roles {
+		names[i] = role.Name
+	}
+	assert.Equal(t, []string{"admin", "customer", "support", "warehouse"}, names)
+	assert.ElementsMatch(t, rbac.Permissions, roles[0].PermissionNames())
+
+	// seeding again neither fails nor duplicates grants
+	require.NoError(t, SeedRoles(context.Background(), db))
+	admin, err := repo.GetRole(context.Background(), rbac.RoleAdmin)
+	require.NoError(t, err)
+	assert.Len(t, admin.Permissions, len(rbac.Permissions))
+}
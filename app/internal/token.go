@@ -0,0 +1,248 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid or expired")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used")
+	ErrMailTokenInvalid    = errors.New("mail token is invalid, expired or already used")
+)
+
+// Purposes of mail tokens, a token only works for the purpose it was sent for
+const (
+	MailTokenVerify        = "verify_mail"
+	MailTokenResetPassword = "reset_password"
+)
+
+type TokenRepo interface {
+	CreateRefreshToken(ctx context.Context, token *Refreshtoken) error
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*Refreshtoken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, tokenHash string, userID int64) error
+	RevokeUserRefreshTokens(ctx context.Context, userID int64) error
+	RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+	CreateMailToken(ctx context.Context, token *Mailtoken) error
+	FindMailToken(ctx context.Context, purpose, tokenHash string) (*Mailtoken, error)
+	UseMailToken(ctx context.Context, purpose, tokenHash string) (*Mailtoken, error)
+	RevokeMailTokens(ctx context.Context, userID int64, purpose string) error
+}
+
+type TokenRepoImpl struct {
+	db *gorm.DB
+}
+
+func NewTokenRepo(db *gorm.DB) TokenRepo {
+	return &TokenRepoImpl{
+		db: db,
+	}
+}
+
+// Refreshtoken stores only the sha256 of the token handed to the client.
+// Every token issued from one login shares a FamilyID, so reuse of an old
+// token can revoke the whole chain.
+type Refreshtoken struct {
+	ID        int64      `gorm:"primaryKey"`
+	UserID    int64      `gorm:"column:user_id;not null;index"`
+	FamilyID  string     `gorm:"column:family_id;not null;index"`
+	TokenHash string     `gorm:"column:token_hash;not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	RevokedAt *time.Time `gorm:"column:revoked_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Refreshtoken) TableName() string {
+	return "refreshtokens"
+}
+
+// Revokedtoken is a deny list of access tokens by jti, rows are only
+// needed until the token would have expired anyway
+type Revokedtoken struct {
+	JTI       string    `gorm:"column:jti;primaryKey"`
+	UserID    int64     `gorm:"column:user_id;not null;index"`
+	ExpiresAt time.Time `gorm:"column:expires_at;not null;index"`
+	CreatedAt time.Time `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Revokedtoken) TableName() string {
+	return "revokedtokens"
+}
+
+// Mailtoken is a single-use token sent to the user's mail address, like
+// refresh tokens only the sha256 is stored
+type Mailtoken struct {
+	ID        int64      `gorm:"primaryKey"`
+	UserID    int64      `gorm:"column:user_id;not null;index"`
+	Purpose   string     `gorm:"column:purpose;not null"`
+	TokenHash string     `gorm:"column:token_hash;not null;uniqueIndex"`
+	ExpiresAt time.Time  `gorm:"column:expires_at;not null"`
+	UsedAt    *time.Time `gorm:"column:used_at"`
+	CreatedAt time.Time  `gorm:"column:created_at;autoCreateTime"`
+}
+
+func (Mailtoken) TableName() string {
+	return "mailtokens"
+}
+
+// open reports whether the token can still be redeemed at now
+func (t *Mailtoken) open(now time.Time) bool {
+	return t.UsedAt == nil && t.ExpiresAt.After(now)
+}
+
+func (r *TokenRepoImpl) CreateRefreshToken(ctx context.Context, token *Refreshtoken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// RotateRefreshToken marks the old token as used and issues the next one in
+// the same family. Presenting a used or revoked token revokes the family.
+func (r *TokenRepoImpl) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*Refreshtoken, error) {
+	var next *Refreshtoken
+	reused := false
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current Refreshtoken
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", oldHash).
+			First(&current).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRefreshTokenInvalid
+			}
+			return err
+		}
+
+		now := time.Now()
+		if current.UsedAt != nil || current.RevokedAt != nil {
+			reused = true
+			return tx.Model(&Refreshtoken{}).
+				Where("family_id = ? AND revoked_at IS NULL", current.FamilyID).
+				Update("revoked_at", now).Error
+		}
+		if !current.ExpiresAt.After(now) {
+			return ErrRefreshTokenInvalid
+		}
+
+		if err := tx.Model(&current).Update("used_at", now).Error; err != nil {
+			return err
+		}
+
+		next = &Refreshtoken{
+			UserID:    current.UserID,
+			FamilyID:  current.FamilyID,
+			TokenHash: newHash,
+			ExpiresAt: expiresAt,
+		}
+		return tx.Create(next).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	// the family revocation has to commit, so the error is returned after the transaction
+	if reused {
+		return nil, ErrRefreshTokenReused
+	}
+	return next, nil
+}
+
+// RevokeRefreshTokenFamily revokes the family of the given token, as long as it belongs to the user
+func (r *TokenRepoImpl) RevokeRefreshTokenFamily(ctx context.Context, tokenHash string, userID int64) error {
+	familyIDs := r.db.WithContext(ctx).Model(&Refreshtoken{}).
+		Select("family_id").
+		Where("token_hash = ? AND user_id = ?", tokenHash, userID)
+
+	return r.db.WithContext(ctx).Model(&Refreshtoken{}).
+		Where("family_id IN (?) AND revoked_at IS NULL", familyIDs).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeUserRefreshTokens signs the user out of every session once their
+// access tokens expire
+func (r *TokenRepoImpl) RevokeUserRefreshTokens(ctx context.Context, userID int64) error {
+	return r.db.WithContext(ctx).Model(&Refreshtoken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func (r *TokenRepoImpl) RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	token := Revokedtoken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&token).Error
+}
+
+func (r *TokenRepoImpl) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&Revokedtoken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *TokenRepoImpl) CreateMailToken(ctx context.Context, token *Mailtoken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindMailToken looks up an open token for purpose without using it up
+func (r *TokenRepoImpl) FindMailToken(ctx context.Context, purpose, tokenHash string) (*Mailtoken, error) {
+	var token Mailtoken
+	err := r.db.WithContext(ctx).Where("token_hash = ? AND purpose = ?", tokenHash, purpose).First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMailTokenInvalid
+		}
+		return nil, err
+	}
+	if !token.open(time.Now()) {
+		return nil, ErrMailTokenInvalid
+	}
+	return &token, nil
+}
+
+// UseMailToken redeems a token for purpose. Every other open token of the
+// user for the same purpose is used up with it, so older mails stop working.
+func (r *TokenRepoImpl) UseMailToken(ctx context.Context, purpose, tokenHash string) (*Mailtoken, error) {
+	var token Mailtoken
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ? AND purpose = ?", tokenHash, purpose).
+			First(&token).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMailTokenInvalid
+			}
+			return err
+		}
+
+		now := time.Now()
+		if !token.open(now) {
+			return ErrMailTokenInvalid
+		}
+		token.UsedAt = &now
+
+		return tx.Model(&Mailtoken{}).
+			Where("user_id = ? AND purpose = ? AND used_at IS NULL", token.UserID, purpose).
+			Update("used_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeMailTokens uses up the open tokens of the user for purpose, e.g.
+// before a new mail is sent
+func (r *TokenRepoImpl) RevokeMailTokens(ctx context.Context, userID int64, purpose string) error {
+	return r.db.WithContext(ctx).Model(&Mailtoken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,307 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newTokenRepoMock(t *testing.T) (TokenRepo, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	if err != nil {
+		t.Fatalf("failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open gorm db: %v", err)
+	}
+
+	return NewTokenRepo(gdb), mock
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	lockQuery := `^SELECT \* FROM "refreshtokens" WHERE token_hash = \$1 ORDER BY "refreshtokens"."id" LIMIT \$2 FOR UPDATE$`
+	tokenRow := func(usedAt, revokedAt interface{}, expires time.Time) *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "user_id", "family_id", "token_hash", "expires_at", "used_at", "revoked_at"}).
+			AddRow(5, 7, "fam-1", "old-hash", expires, usedAt, revokedAt)
+	}
+
+	tests := []struct {
+		name    string
+		wantErr error
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name:    "success-case",
+			wantErr: nil,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).
+					WithArgs("old-hash", 1).
+					WillReturnRows(tokenRow(nil, nil, expiresAt))
+				mock.ExpectExec(`^UPDATE "refreshtokens" SET "used_at"=\$1 WHERE "id" = \$2$`).
+					WithArgs(sqlmock.AnyArg(), int64(5)).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectQuery(`^INSERT INTO "refreshtokens" \("user_id","family_id","token_hash","expires_at","used_at","revoked_at","created_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\) RETURNING "id"$`).
+					WithArgs(int64(7), "fam-1", "new-hash", expiresAt, nil, nil, sqlmock.AnyArg()).
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(6))
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "unknown-token",
+			wantErr: ErrRefreshTokenInvalid,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).
+					WillReturnError(gorm.ErrRecordNotFound)
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:    "expired-token",
+			wantErr: ErrRefreshTokenInvalid,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).
+					WillReturnRows(tokenRow(nil, nil, time.Now().Add(-time.Minute)))
+				mock.ExpectRollback()
+			},
+		},
+		{
+			name:    "reused-token-revokes-family",
+			wantErr: ErrRefreshTokenReused,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).
+					WillReturnRows(tokenRow(time.Now().Add(-time.Minute), nil, expiresAt))
+				mock.ExpectExec(`^UPDATE "refreshtokens" SET "revoked_at"=\$1 WHERE family_id = \$2 AND revoked_at IS NULL$`).
+					WithArgs(sqlmock.AnyArg(), "fam-1").
+					WillReturnResult(sqlmock.NewResult(0, 2))
+				// the revocation must be committed even though the call fails
+				mock.ExpectCommit()
+			},
+		},
+		{
+			name:    "database-error",
+			wantErr: errors.New("select failed"),
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectBegin()
+				mock.ExpectQuery(lockQuery).
+					WillReturnError(fmt.Errorf("select failed"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+
+	repo, mock := newTokenRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.RotateRefreshToken(context.Background(), "old-hash", "new-hash", expiresAt)
+			switch {
+			case test.wantErr == nil:
+				if err != nil {
+					t.Fatalf("RotateRefreshToken() unexpected error = %v", err)
+				}
+				if got.UserID != 7 || got.FamilyID != "fam-1" || got.TokenHash != "new-hash" {
+					t.Errorf("RotateRefreshToken() = %+v", got)
+				}
+			case errors.Is(test.wantErr, ErrRefreshTokenInvalid) || errors.Is(test.wantErr, ErrRefreshTokenReused):
+				if !errors.Is(err, test.wantErr) {
+					t.Errorf("RotateRefreshToken() error = %v, want %v", err, test.wantErr)
+				}
+			default:
+				if err == nil {
+					t.Errorf("RotateRefreshToken() expected an error")
+				}
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestRevokeRefreshTokenFamily(t *testing.T) {
+	repo, mock := newTokenRepoMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "refreshtokens" SET "revoked_at"=\$1 WHERE family_id IN \(SELECT "family_id" FROM "refreshtokens" WHERE token_hash = \$2 AND user_id = \$3\) AND revoked_at IS NULL$`).
+		WithArgs(sqlmock.AnyArg(), "hash", int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.RevokeRefreshTokenFamily(context.Background(), "hash", 7); err != nil {
+		t.Errorf("RevokeRefreshTokenFamily() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRevokeUserRefreshTokens(t *testing.T) {
+	repo, mock := newTokenRepoMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^UPDATE "refreshtokens" SET "revoked_at"=\$1 WHERE user_id = \$2 AND revoked_at IS NULL$`).
+		WithArgs(sqlmock.AnyArg(), int64(7)).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectCommit()
+
+	if err := repo.RevokeUserRefreshTokens(context.Background(), 7); err != nil {
+		t.Errorf("RevokeUserRefreshTokens() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	expiresAt := time.Now().Add(time.Minute)
+	repo, mock := newTokenRepoMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`^INSERT INTO "revokedtokens" \("jti","user_id","expires_at","created_at"\) VALUES \(\$1,\$2,\$3,\$4\) ON CONFLICT DO NOTHING$`).
+		WithArgs("jti-1", int64(7), expiresAt, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.RevokeAccessToken(context.Background(), "jti-1", 7, expiresAt); err != nil {
+		t.Errorf("RevokeAccessToken() error = %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestIsTokenRevoked(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    bool
+		wantErr bool
+		query   func(mock sqlmock.Sqlmock)
+	}{
+		{
+			name: "revoked",
+			want: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT count\(\*\) FROM "revokedtokens" WHERE jti = \$1$`).
+					WithArgs("jti-1").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+			},
+		},
+		{
+			name: "not-revoked",
+			want: false,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT count\(\*\) FROM "revokedtokens"`).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			},
+		},
+		{
+			name:    "database-error",
+			wantErr: true,
+			query: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery(`^SELECT count\(\*\) FROM "revokedtokens"`).
+					WillReturnError(fmt.Errorf("select failed"))
+			},
+		},
+	}
+
+	repo, mock := newTokenRepoMock(t)
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.query(mock)
+
+			got, err := repo.IsTokenRevoked(context.Background(), "jti-1")
+			if (err != nil) != test.wantErr {
+				t.Errorf("IsTokenRevoked() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if got != test.want {
+				t.Errorf("IsTokenRevoked() = %v, want %v", got, test.want)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+}
+
+func TestMailTokens(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	sqlDb, err := db.DB()
+	require.NoError(t, err)
+	sqlDb.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDb.Close() })
+	require.NoError(t, db.AutoMigrate(&Mailtoken{}))
+
+	repo := NewTokenRepo(db)
+	ctx := context.Background()
+	create := func(userID int64, hash string, ttl time.Duration) {
+		require.NoError(t, repo.CreateMailToken(ctx, &Mailtoken{
+			UserID:    userID,
+			Purpose:   MailTokenVerify,
+			TokenHash: hash,
+			ExpiresAt: time.Now().Add(ttl),
+		}))
+	}
+	create(7, "old", time.Hour)
+	create(7, "new", time.Hour)
+	create(8, "other-user", time.Hour)
+	create(9, "expired", -time.Minute)
+
+	_, err = repo.UseMailToken(ctx, "reset_password", "new")
+	assert.ErrorIs(t, err, ErrMailTokenInvalid, "wrong purpose")
+	_, err = repo.UseMailToken(ctx, MailTokenVerify, "unknown")
+	assert.ErrorIs(t, err, ErrMailTokenInvalid)
+	_, err = repo.UseMailToken(ctx, MailTokenVerify, "expired")
+	assert.ErrorIs(t, err, ErrMailTokenInvalid)
+
+	// finding a token leaves it usable
+	_, err = repo.FindMailToken(ctx, MailTokenVerify, "unknown")
+	assert.ErrorIs(t, err, ErrMailTokenInvalid)
+	_, err = repo.FindMailToken(ctx, MailTokenVerify, "expired")
+	assert.ErrorIs(t, err, ErrMailTokenInvalid)
+	found, err := repo.FindMailToken(ctx, MailTokenVerify, "new")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), found.UserID)
+	assert.Nil(t, found.UsedAt)
+
+	token, err := repo.UseMailToken(ctx, MailTokenVerify, "new")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), token.UserID)
+	require.NotNil(t, token.UsedAt)
+
+	// single use, and the older mail of the same user stops working too
+	_, err = repo.UseMailToken(ctx, MailTokenVerify, "new")
+	assert.ErrorIs(t, err, ErrMailTokenInvalid)
+	_, err = repo.UseMailToken(ctx, MailTokenVerify, "old")
+	assert.ErrorIs(t, err, ErrMailTokenInvalid)
+	_, err = repo.FindMailToken(ctx, MailTokenVerify, "old")
+	assert.ErrorIs(t, err, ErrMailTokenInvalid)
+
+	require.NoError(t, repo.RevokeMailTokens(ctx, 8, MailTokenVerify))
+	_, err = repo.UseMailToken(ctx, MailTokenVerify, "other-user")
+	assert.ErrorIs(t, err, ErrMailTokenInvalid)
+}
@@ -0,0 +1,332 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"sonartest_cart/app/dto"
+	"sonartest_cart/pkg/auth"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// userRepoDrivers returns a constructor for every storage driver. Each call
+// gives an empty repo. Postgres only runs when TEST_POSTGRES_DSN is set and
+// wipes the userdetails table of that database.
+func userRepoDrivers() map[string]func(t *testing.T) UserRepo {
+	drivers := map[string]func(t *testing.T) UserRepo{
+		"memory": func(t *testing.T) UserRepo {
+			return NewMemoryUserRepo()
+		},
+		"sqlite": func(t *testing.T) UserRepo {
+			db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true})
+			require.NoError(t, err)
+			sqlDb, err := db.DB()
+			require.NoError(t, err)
+			// every connection to :memory: is its own database
+			sqlDb.SetMaxOpenConns(1)
+			t.Cleanup(func() { sqlDb.Close() })
+
+			require.NoError(t, db.AutoMigrate(&Userdetail{}))
+			return NewUserRepo(db)
+		},
+	}
+
+	if dsn := os.Getenv("TEST_POSTGRES_DSN"); dsn != "" {
+		drivers["postgres"] = func(t *testing.T) UserRepo {
+			db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
+			require.NoError(t, err)
+			sqlDb, err := db.DB()
+			require.NoError(t, err)
+			t.Cleanup(func() { sqlDb.Close() })
+
+			require.NoError(t, db.AutoMigrate(&Userdetail{}))
+			require.NoError(t, db.Exec("TRUNCATE userdetails RESTART IDENTITY CASCADE").Error)
+			return NewUserRepo(db)
+		}
+	}
+	return drivers
+}
+
+func newUser(username string) *dto.UserDetailSaveRequest {
+	return &dto.UserDetailSaveRequest{
+		UserName: username,
+		Mail:     username + "@example.com",
+		Address:  "1 Main Street",
+		Pincode:  "560001",
+		Phone:    "+919876543210",
+		Password: "hash",
+	}
+}
+
+func TestUserRepoConformance(t *testing.T) {
+	for name, newRepo := range userRepoDrivers() {
+		t.Run(name, func(t *testing.T) {
+			t.Run("save_and_get", func(t *testing.T) {
+				repo := newRepo(t)
+
+				id, err := repo.SaveUserDetails(context.Background(), newUser("johndoe"))
+				require.NoError(t, err)
+				assert.NotZero(t, id)
+
+				byID, err := repo.GetUserByID(context.Background(), id)
+				require.NoError(t, err)
+				assert.Equal(t, "johndoe", byID.Username)
+				assert.Equal(t, "johndoe@example.com", byID.Mail)
+				assert.Equal(t, "560001", byID.Pincode)
+				assert.True(t, byID.Status, "new users are active")
+				assert.Equal(t, "customer", byID.Role, "new users are customers")
+
+				byName, err := repo.GetUserByUsername(context.Background(), "johndoe")
+				require.NoError(t, err)
+				assert.Equal(t, id, byName.ID)
+
+				active, err := repo.IsUserActive(context.Background(), id)
+				require.NoError(t, err)
+				assert.True(t, active)
+			})
+
+			t.Run("unique_username", func(t *testing.T) {
+				repo := newRepo(t)
+
+				_, err := repo.SaveUserDetails(context.Background(), newUser("johndoe"))
+				require.NoError(t, err)
+				_, err = repo.SaveUserDetails(context.Background(), newUser("johndoe"))
+				assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+
+				otherID, err := repo.SaveUserDetails(context.Background(), newUser("janedoe"))
+				require.NoError(t, err)
+				err = repo.UpdateUserDetails(context.Background(), &dto.UpdateUserDetailRequest{UserID: otherID, UserName: "johndoe", Mail: "x@example.com"})
+				assert.ErrorIs(t, err, gorm.ErrDuplicatedKey)
+
+				unchanged, err := repo.GetUserByID(context.Background(), otherID)
+				require.NoError(t, err)
+				assert.Equal(t, "janedoe", unchanged.Username)
+			})
+
+			t.Run("not_found", func(t *testing.T) {
+				repo := newRepo(t)
+
+				_, err := repo.GetUserByID(context.Background(), 42)
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+				_, err = repo.GetUserByUsername(context.Background(), "nobody")
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+				_, err = repo.IsUserActive(context.Background(), 42)
+				assert.ErrorIs(t, err, auth.ErrUserNotFound)
+				assert.ErrorIs(t, repo.UpdatePassword(context.Background(), 42, "hash"), gorm.ErrRecordNotFound)
+				assert.ErrorIs(t, repo.UpdateUserStatus(context.Background(), 42, false), gorm.ErrRecordNotFound)
+				assert.ErrorIs(t, repo.UpdateUserDetails(context.Background(), &dto.UpdateUserDetailRequest{UserID: 42, UserName: "x"}), gorm.ErrRecordNotFound)
+				assert.ErrorIs(t, repo.UpdateUserRole(context.Background(), 42, "admin"), gorm.ErrRecordNotFound)
+				assert.ErrorIs(t, repo.MarkMailVerified(context.Background(), 42), gorm.ErrRecordNotFound)
+				_, err = repo.IsMailVerified(context.Background(), 42)
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+				_, _, err = repo.UserStatus(context.Background(), 42)
+				assert.ErrorIs(t, err, auth.ErrUserNotFound)
+				assert.ErrorIs(t, repo.ChangePassword(context.Background(), 42, "hash"), gorm.ErrRecordNotFound)
+			})
+
+			t.Run("status", func(t *testing.T) {
+				repo := newRepo(t)
+				id, err := repo.SaveUserDetails(context.Background(), newUser("johndoe"))
+				require.NoError(t, err)
+
+				require.NoError(t, repo.UpdateUserStatus(context.Background(), id, false))
+				active, err := repo.IsUserActive(context.Background(), id)
+				require.NoError(t, err)
+				assert.False(t, active)
+
+				// blocking twice is not an error
+				require.NoError(t, repo.UpdateUserStatus(context.Background(), id, false))
+
+				require.NoError(t, repo.UpdateUserStatus(context.Background(), id, true))
+				active, err = repo.IsUserActive(context.Background(), id)
+				require.NoError(t, err)
+				assert.True(t, active)
+			})
+
+			t.Run("role", func(t *testing.T) {
+				repo := newRepo(t)
+				admin := newUser("root")
+				admin.Role = "admin"
+				adminID, err := repo.SaveUserDetails(context.Background(), admin)
+				require.NoError(t, err)
+				id, err := repo.SaveUserDetails(context.Background(), newUser("johndoe"))
+				require.NoError(t, err)
+
+				got, err := repo.GetUserByID(context.Background(), adminID)
+				require.NoError(t, err)
+				assert.Equal(t, "admin", got.Role)
+
+				require.NoError(t, repo.UpdateUserRole(context.Background(), id, "support"))
+				got, err = repo.GetUserByID(context.Background(), id)
+				require.NoError(t, err)
+				assert.Equal(t, "support", got.Role)
+			})
+
+			t.Run("mail_verification", func(t *testing.T) {
+				repo := newRepo(t)
+				id, err := repo.SaveUserDetails(context.Background(), newUser("johndoe"))
+				require.NoError(t, err)
+				operator := newUser("root")
+				operator.MailVerified = true
+				operatorID, err := repo.SaveUserDetails(context.Background(), operator)
+				require.NoError(t, err)
+
+				verified, err := repo.IsMailVerified(context.Background(), id)
+				require.NoError(t, err)
+				assert.False(t, verified)
+				verified, err = repo.IsMailVerified(context.Background(), operatorID)
+				require.NoError(t, err)
+				assert.True(t, verified)
+
+				require.NoError(t, repo.MarkMailVerified(context.Background(), id))
+				got, err := repo.GetUserByID(context.Background(), id)
+				require.NoError(t, err)
+				require.NotNil(t, got.MailVerifiedAt)
+				first := *got.MailVerifiedAt
+
+				// verifying again keeps the first confirmation
+				require.NoError(t, repo.MarkMailVerified(context.Background(), id))
+				got, err = repo.GetUserByID(context.Background(), id)
+				require.NoError(t, err)
+				assert.True(t, first.Equal(*got.MailVerifiedAt))
+
+				// an edit keeping the mail keeps the verification, a new mail has to be verified again
+				update := &dto.UpdateUserDetailRequest{UserID: id, UserName: "johndoe", Mail: "johndoe@example.com", Address: "2 Side Street"}
+				require.NoError(t, repo.UpdateUserDetails(context.Background(), update))
+				verified, err = repo.IsMailVerified(context.Background(), id)
+				require.NoError(t, err)
+				assert.True(t, verified)
+
+				update.Mail = "attacker@example.com"
+				require.NoError(t, repo.UpdateUserDetails(context.Background(), update))
+				verified, err = repo.IsMailVerified(context.Background(), id)
+				require.NoError(t, err)
+				assert.False(t, verified)
+			})
+
+			t.Run("updates", func(t *testing.T) {
+				repo := newRepo(t)
+				id, err := repo.SaveUserDetails(context.Background(), newUser("johndoe"))
+				require.NoError(t, err)
+
+				require.NoError(t, repo.UpdatePassword(context.Background(), id, "new-hash"))
+				require.NoError(t, repo.UpdateUserDetails(context.Background(), &dto.UpdateUserDetailRequest{
+					UserID:   id,
+					UserName: "john",
+					Mail:     "john@example.com",
+					Address:  "2 Side Street",
+					Pincode:  "110001",
+					Phone:    "+919123456789",
+				}))
+
+				got, err := repo.GetUserByID(context.Background(), id)
+				require.NoError(t, err)
+				assert.Equal(t, "new-hash", got.Password)
+				assert.Equal(t, "john", got.Username)
+				assert.Equal(t, "john@example.com", got.Mail)
+				assert.Equal(t, "2 Side Street", got.Address)
+				assert.Equal(t, "110001", got.Pincode)
+				assert.Equal(t, "+919123456789", got.Phonenumber)
+				assert.True(t, got.Status)
+
+				_, err = repo.GetUserByUsername(context.Background(), "johndoe")
+				assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+			})
+
+			t.Run("change_password", func(t *testing.T) {
+				repo := newRepo(t)
+				id, err := repo.SaveUserDetails(context.Background(), newUser("johndoe"))
+				require.NoError(t, err)
+
+				active, changedAt, err := repo.UserStatus(context.Background(), id)
+				require.NoError(t, err)
+				assert.True(t, active)
+				assert.True(t, changedAt.IsZero())
+
+				// a rehash keeps the sessions
+				require.NoError(t, repo.UpdatePassword(context.Background(), id, "rehashed"))
+				_, changedAt, err = repo.UserStatus(context.Background(), id)
+				require.NoError(t, err)
+				assert.True(t, changedAt.IsZero())
+
+				before := time.Now().Add(-time.Second)
+				require.NoError(t, repo.ChangePassword(context.Background(), id, "new-hash"))
+				_, changedAt, err = repo.UserStatus(context.Background(), id)
+				require.NoError(t, err)
+				assert.True(t, changedAt.After(before))
+
+				got, err := repo.GetUserByID(context.Background(), id)
+				require.NoError(t, err)
+				assert.Equal(t, "new-hash", got.Password)
+			})
+
+			t.Run("list_ordered_by_id", func(t *testing.T) {
+				repo := newRepo(t)
+				users, err := repo.ListUsers(context.Background())
+				require.NoError(t, err)
+				assert.Empty(t, users)
+
+				for _, name := range []string{"carol", "alice", "bob"} {
+					_, err := repo.SaveUserDetails(context.Background(), newUser(name))
+					require.NoError(t, err)
+				}
+
+				users, err = repo.ListUsers(context.Background())
+				require.NoError(t, err)
+				require.Len(t, users, 3)
+				assert.Equal(t, "carol", users[0].Username)
+				assert.Equal(t, "bob", users[2].Username)
+				assert.Less(t, users[0].ID, users[1].ID)
+				assert.Less(t, users[1].ID, users[2].ID)
+			})
+
+			t.Run("concurrent_signups", func(t *testing.T) {
+				repo := newRepo(t)
+
+				const workers = 20
+				var wg sync.WaitGroup
+				errs := make(chan error, workers)
+				for i := 0; i < workers; i++ {
+					wg.Add(1)
+					go func(i int) {
+						defer wg.Done()
+						// every worker also creates a user of its own
+						if _, err := repo.SaveUserDetails(context.Background(), newUser(fmt.Sprintf("user%d", i))); err != nil {
+							errs <- err
+							return
+						}
+						_, err := repo.SaveUserDetails(context.Background(), newUser("contested"))
+						errs <- err
+					}(i)
+				}
+				wg.Wait()
+				close(errs)
+
+				succeeded := 0
+				for err := range errs {
+					switch {
+					case err == nil:
+						succeeded++
+					case !errors.Is(err, gorm.ErrDuplicatedKey):
+						t.Errorf("unexpected error: %v", err)
+					}
+				}
+				assert.Equal(t, 1, succeeded, "exactly one worker gets the contested username")
+
+				users, err := repo.ListUsers(context.Background())
+				require.NoError(t, err)
+				assert.Len(t, users, workers+1)
+			})
+		})
+	}
+}
@@ -1,31 +1,156 @@
 package app
 
 import (
+	"net/http"
+
 	"sonartest_cart/app/controller"
 	"sonartest_cart/app/helper"
 	"sonartest_cart/app/internal"
 	"sonartest_cart/app/service"
 	api "sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/config"
 	"sonartest_cart/pkg/jwt"
+	"sonartest_cart/pkg/middleware"
+	"sonartest_cart/pkg/password"
+	"sonartest_cart/pkg/ratelimit"
+	"sonartest_cart/pkg/rbac"
 
 	"github.com/go-chi/chi/v5"
-	"gorm.io/gorm"
+	"github.com/rs/zerolog/log"
 )
 
-func APIRouter(db *gorm.DB) chi.Router {
+// RateLimits protects the credential and mail endpoints, a nil limiter is not
+// applied and a nil Lockout never locks
+type RateLimits struct {
+	LoginPerIP   *ratelimit.Limiter
+	LoginPerUser *ratelimit.Limiter
+	SignupPerIP  *ratelimit.Limiter
+	MailPerIP    *ratelimit.Limiter
+	TokenPerIP   *ratelimit.Limiter
+	Lockout      ratelimit.Lockout
+}
+
+// rateLimit applies limiter keyed by key, or nothing when limiter is nil
+func rateLimit(limiter *ratelimit.Limiter, key middleware.KeyFunc) func(http.Handler) http.Handler {
+	if limiter == nil {
+		return func(next http.Handler) http.Handler { return next }
+	}
+	return middleware.RateLimit(limiter, key)
+}
+
+// APIRouter wires the services and routes, a nil metricsHandler leaves /metrics unmounted
+func APIRouter(repos internal.Repos, jwtService jwt.JWTService, hasher password.PasswordHasher, policy password.Policy, mailOpts service.MailOptions, health *api.Health, metricsHandler http.Handler, limits RateLimits) chi.Router {
 	r := chi.NewRouter()
+	r.Use(middleware.RequestID, middleware.Tracing, middleware.RequestLogger(log.Logger), middleware.Metrics)
 
 	// User part
-	urRepo := internal.NewUserRepo(db)
+	urRepo := repos.User
 	hlRepo := helper.NewContextHelper()
-	jwtService := jwt.NewJWTService
-	urService := service.NewUserService(urRepo, hlRepo, jwtService())
+	tkRepo := repos.Token
+	rlRepo := repos.Role
+	lockout := limits.Lockout
+	if lockout == nil {
+		lockout = ratelimit.NoLockout{}
+	}
+	urService := service.NewUserService(urRepo, tkRepo, rlRepo, repos.Audit, hlRepo, jwtService, hasher, lockout, policy, mailOpts)
 	urController := controller.NewUserController(urService)
+	jwtMiddleware := middleware.NewJWTMiddleware(jwtService, urRepo, tkRepo)
+	// with the limit policy unverified users can log in but not order
+	verifiedMail := func(next http.Handler) http.Handler { return next }
+	if mailOpts.UnverifiedLogin == config.UnverifiedLimit {
+		verifiedMail = middleware.RequireVerifiedMail(urRepo)
+	}
 
-	r.Route("/", func(r chi.Router) {
+	// Cart part
+	prRepo := repos.Product
+	crRepo := repos.Cart
+	crService := service.NewCartService(crRepo, prRepo, urRepo, hlRepo)
+	crController := controller.NewCartController(crService)
+
+	// Product part
+	pdService := service.NewProductService(prRepo)
+	pdController := controller.NewProductController(pdService)
+
+	// Order part
+	odRepo := repos.Order
+	odService := service.NewOrderService(odRepo, urRepo, hlRepo)
+	odController := controller.NewOrderController(odService)
+
+	// Favourite part
+	fvRepo := repos.Favourite
+	fvService := service.NewFavouriteService(fvRepo, prRepo, urRepo, hlRepo)
+	fvController := controller.NewFavouriteController(fvService)
+
+	// Public routes
+	r.Group(func(r chi.Router) {
 		r.Get("/hello", api.ExampleHamdler)
-		r.Post("/signup", urController.UserDetails)
-		r.Post("/login", urController.LoginUser)
+		r.Get("/healthz", health.Liveness)
+		r.Get("/readyz", health.Readiness)
+		if metricsHandler != nil {
+			r.Handle("/metrics", metricsHandler)
+		}
+		r.With(rateLimit(limits.SignupPerIP, middleware.KeyByIP)).
+			Post("/signup", urController.UserDetails)
+		r.With(rateLimit(limits.LoginPerIP, middleware.KeyByIP), rateLimit(limits.LoginPerUser, middleware.KeyByUsername)).
+			Post("/login", urController.LoginUser)
+		r.With(rateLimit(limits.TokenPerIP, middleware.KeyByIP)).
+			Post("/token/refresh", urController.RefreshToken)
+		r.With(rateLimit(limits.TokenPerIP, middleware.KeyByIP)).
+			Post("/verify-email", urController.VerifyMail)
+		r.With(rateLimit(limits.MailPerIP, middleware.KeyByIP)).
+			Post("/verify-email/resend", urController.ResendVerificationMail)
+		r.With(rateLimit(limits.MailPerIP, middleware.KeyByIP)).
+			Post("/password/forgot", urController.ForgotPassword)
+		r.With(rateLimit(limits.TokenPerIP, middleware.KeyByIP)).
+			Post("/password/reset", urController.ResetPassword)
+
+		r.Get("/categories", pdController.ListCategories)
+		r.Get("/categories/{id}", pdController.GetCategoryByID)
+		r.Get("/categories/name/{categoryname}", pdController.GetCategoryByName)
+	})
+
+	// Routes for any logged in user
+	r.Group(func(r chi.Router) {
+		r.Use(jwtMiddleware.JWTAuthMiddleware)
+
+		r.Get("/me", urController.GetMyProfile)
+		r.Put("/me/password", urController.ChangePassword)
+		r.Post("/logout", urController.Logout)
+
+		r.Route("/cart", func(r chi.Router) {
+			r.Post("/", crController.AddItemToCart)
+			r.Get("/", crController.ViewCart)
+			r.Delete("/", crController.ClearCart)
+			r.Put("/{brandid}", crController.UpdateCartItem)
+			r.Delete("/{brandid}", crController.RemoveCartItem)
+		})
+
+		r.With(verifiedMail).Post("/orders", odController.PlaceOrder)
+		r.Get("/orders", odController.GetOrderHistory)
+
+		r.Post("/favourites", fvController.ToggleFavourite)
+		r.Get("/favourites", fvController.GetFavourites)
+
+		// Catalog writes
+		r.Group(func(r chi.Router) {
+			r.Use(jwtMiddleware.RequirePermission(rbac.CatalogWrite))
+
+			r.Post("/categories", pdController.CreateCategory)
+			r.Put("/categories/{id}", pdController.UpdateCategory)
+			r.Put("/brands/{id}", pdController.UpdateBrand)
+		})
+
+		// User management, each route needs its own permission
+		allow := jwtMiddleware.RequirePermission
+		r.Route("/admin/users", func(r chi.Router) {
+			r.With(allow(rbac.UsersRead)).Get("/", urController.ListUsers)
+			r.With(allow(rbac.UsersWrite)).Put("/{userid}", urController.UpdateUserDetails)
+			r.With(allow(rbac.UsersBlock)).Put("/{userid}/block", urController.BlockUser)
+			r.With(allow(rbac.UsersBlock)).Put("/{userid}/unblock", urController.UnblockUser)
+			r.With(allow(rbac.RolesAssign)).Put("/{userid}/role", urController.AssignRole)
+			r.With(allow(rbac.OrdersRead)).Get("/{id}/orders", odController.GetCustomerOrderHistory)
+		})
+		r.With(allow(rbac.RolesAssign)).Get("/admin/roles", urController.ListRoles)
 	})
 
 	return r
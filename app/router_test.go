@@ -0,0 +1,331 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sonartest_cart/app/internal"
+	"sonartest_cart/app/service"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/config"
+	"sonartest_cart/pkg/jwt"
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/password"
+	"sonartest_cart/pkg/ratelimit"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newRouterWithMockDB(t *testing.T, jwtService jwt.JWTService) (http.Handler, sqlmock.Sqlmock) {
+	return newRouterWithMail(t, jwtService, service.MailOptions{})
+}
+
+func newRouterWithMail(t *testing.T, jwtService jwt.JWTService, mailOpts service.MailOptions) (http.Handler, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{
+		Conn: db,
+	}), &gorm.Config{})
+	require.NoError(t, err)
+
+	return APIRouter(internal.NewRepos(gdb), jwtService, password.NewBcryptHasher(bcrypt.MinCost), password.Policy{}, mailOpts, api.NewHealth(), metrics.Handler(""), RateLimits{}), mock
+}
+
+func expectUserRow(mock sqlmock.Sqlmock, userID int64, role string) {
+	mock.ExpectQuery(`^SELECT \* FROM "userdetails" WHERE id = \$1 ORDER BY "userdetails"."id" LIMIT \$2$`).
+		WithArgs(userID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password", "mail", "status", "role"}).
+			AddRow(userID, "johndoe", "secret-hash", "john@example.com", true, role))
+}
+
+func expectRevocationCheck(mock sqlmock.Sqlmock, revoked bool) {
+	count := 0
+	if revoked {
+		count = 1
+	}
+	mock.ExpectQuery(`^SELECT count\(\*\) FROM "revokedtokens" WHERE jti = \$1$`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func TestAPIRouterGroups(t *testing.T) {
+	jwtService, err := jwt.NewJWTService(jwt.Config{
+		ActiveKeyID: "test",
+		Keys:        []jwt.KeyConfig{{ID: "test", Algorithm: jwt.AlgorithmHS256, Secret: "router-test-secret"}},
+	})
+	require.NoError(t, err)
+	userToken, err := jwtService.GenerateToken(2, "johndoe", "customer", nil)
+	require.NoError(t, err)
+	supportToken, err := jwtService.GenerateToken(3, "janedoe", "support", []string{"users:read", "users:block", "orders:read"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		mock   func(mock sqlmock.Sqlmock)
+		status int
+		want   string
+	}{
+		{
+			name:   "public_route",
+			method: "GET",
+			path:   "/hello",
+			mock:   func(mock sqlmock.Sqlmock) {},
+			status: http.StatusOK,
+		},
+		{
+			name:   "liveness_without_token",
+			method: "GET",
+			path:   "/healthz",
+			mock:   func(mock sqlmock.Sqlmock) {},
+			status: http.StatusOK,
+			want:   `{"status":"ok","result":{"status":"alive"}}`,
+		},
+		{
+			name:   "readiness_without_token",
+			method: "GET",
+			path:   "/readyz",
+			mock:   func(mock sqlmock.Sqlmock) {},
+			status: http.StatusOK,
+			want:   `{"status":"ok","result":{"status":"ready"}}`,
+		},
+		{
+			name:   "metrics_without_token",
+			method: "GET",
+			path:   "/metrics",
+			mock:   func(mock sqlmock.Sqlmock) {},
+			status: http.StatusOK,
+		},
+		{
+			name:   "user_route_without_token",
+			method: "GET",
+			path:   "/me",
+			mock:   func(mock sqlmock.Sqlmock) {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "user_route_with_token",
+			method: "GET",
+			path:   "/me",
+			token:  userToken,
+			mock: func(mock sqlmock.Sqlmock) {
+				// revocation and status checks in the middleware, then the profile itself
+				expectRevocationCheck(mock, false)
+				expectUserRow(mock, 2, "customer")
+				expectUserRow(mock, 2, "customer")
+			},
+			status: http.StatusOK,
+			want:   `{"status":"ok","result":{"userid":2,"username":"johndoe","mail":"john@example.com","mail_verified":false,"address":"","country":"","pincode":"","phonenumber":"","status":true,"role":"customer"}}`,
+		},
+		{
+			name:   "user_route_with_revoked_token",
+			method: "GET",
+			path:   "/me",
+			token:  userToken,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectRevocationCheck(mock, true)
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "admin_route_without_token",
+			method: "GET",
+			path:   "/admin/users",
+			mock:   func(mock sqlmock.Sqlmock) {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "admin_route_as_user",
+			method: "GET",
+			path:   "/admin/users",
+			token:  userToken,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectRevocationCheck(mock, false)
+				expectUserRow(mock, 2, "customer")
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "admin_route_with_permission",
+			method: "GET",
+			path:   "/admin/users",
+			token:  supportToken,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectRevocationCheck(mock, false)
+				expectUserRow(mock, 3, "support")
+				mock.ExpectQuery(`^SELECT \* FROM "userdetails" ORDER BY id$`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "status", "role"}).
+						AddRow(2, "johndoe", true, "customer"))
+			},
+			status: http.StatusOK,
+			want:   `{"status":"ok","result":[{"userid":2,"username":"johndoe","mail":"","mail_verified":false,"address":"","country":"","pincode":"","phonenumber":"","status":true,"role":"customer"}]}`,
+		},
+		{
+			name:   "admin_route_without_permission",
+			method: "PUT",
+			path:   "/admin/users/2/role",
+			token:  supportToken,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectRevocationCheck(mock, false)
+				expectUserRow(mock, 3, "support")
+			},
+			status: http.StatusForbidden,
+		},
+		{
+			name:   "catalog_write_without_permission",
+			method: "POST",
+			path:   "/categories",
+			token:  supportToken,
+			mock: func(mock sqlmock.Sqlmock) {
+				expectRevocationCheck(mock, false)
+				expectUserRow(mock, 3, "support")
+			},
+			status: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mock := newRouterWithMockDB(t, jwtService)
+			tt.mock(mock)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			res := httptest.NewRecorder()
+
+			router.ServeHTTP(res, req)
+
+			assert.Equal(t, tt.status, res.Code)
+			if tt.want != "" {
+				assert.Equal(t, tt.want, res.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPIRouterUnverifiedMail(t *testing.T) {
+	jwtService, err := jwt.NewJWTService(jwt.Config{
+		ActiveKeyID: "test",
+		Keys:        []jwt.KeyConfig{{ID: "test", Algorithm: jwt.AlgorithmHS256, Secret: "router-test-secret"}},
+	})
+	require.NoError(t, err)
+	userToken, err := jwtService.GenerateToken(2, "johndoe", "customer", nil)
+	require.NoError(t, err)
+
+	// with the limit policy the user is logged in but may not order yet
+	router, mock := newRouterWithMail(t, jwtService, service.MailOptions{UnverifiedLogin: config.UnverifiedLimit})
+	expectRevocationCheck(mock, false)
+	expectUserRow(mock, 2, "customer")
+	expectUserRow(mock, 2, "customer")
+
+	req := httptest.NewRequest("POST", "/orders", nil)
+	req.Header.Set("Authorization", "Bearer "+userToken)
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusForbidden, res.Code)
+	assert.Contains(t, res.Body.String(), "Mail address not verified")
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// the verification endpoints are public
+	req = httptest.NewRequest("POST", "/verify-email", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.Contains(t, res.Body.String(), `"field":"token"`)
+}
+
+func TestAPIRouterPasswordRoutes(t *testing.T) {
+	jwtService, err := jwt.NewJWTService(jwt.Config{
+		ActiveKeyID: "test",
+		Keys:        []jwt.KeyConfig{{ID: "test", Algorithm: jwt.AlgorithmHS256, Secret: "router-test-secret"}},
+	})
+	require.NoError(t, err)
+	userToken, err := jwtService.GenerateToken(2, "johndoe", "customer", nil)
+	require.NoError(t, err)
+	router, mock := newRouterWithMockDB(t, jwtService)
+
+	// forgot and reset work without a token
+	for _, path := range []string{"/password/forgot", "/password/reset"} {
+		req := httptest.NewRequest("POST", path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusBadRequest, res.Code, path)
+	}
+
+	req := httptest.NewRequest("PUT", "/me/password", strings.NewReader(`{}`))
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+
+	// a token issued before the password was changed is no longer accepted
+	expectRevocationCheck(mock, false)
+	mock.ExpectQuery(`^SELECT \* FROM "userdetails" WHERE id = \$1 ORDER BY "userdetails"."id" LIMIT \$2$`).
+		WithArgs(int64(2), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "status", "role", "password_changed_at"}).
+			AddRow(2, "johndoe", true, "customer", time.Now().Add(time.Minute)))
+
+	req = httptest.NewRequest("GET", "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+userToken)
+	res = httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+
+	assert.Equal(t, http.StatusUnauthorized, res.Code)
+	assert.Contains(t, res.Body.String(), "Password changed, please log in again")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIRouterMetricsDisabled(t *testing.T) {
+	jwtService, err := jwt.NewJWTService(jwt.Config{
+		ActiveKeyID: "test",
+		Keys:        []jwt.KeyConfig{{ID: "test", Algorithm: jwt.AlgorithmHS256, Secret: "router-test-secret"}},
+	})
+	require.NoError(t, err)
+	router := APIRouter(internal.Repos{}, jwtService, password.NewBcryptHasher(bcrypt.MinCost), password.Policy{}, service.MailOptions{}, api.NewHealth(), nil, RateLimits{})
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusNotFound, res.Code)
+}
+
+func TestAPIRouterTokenRateLimit(t *testing.T) {
+	jwtService, err := jwt.NewJWTService(jwt.Config{
+		ActiveKeyID: "test",
+		Keys:        []jwt.KeyConfig{{ID: "test", Algorithm: jwt.AlgorithmHS256, Secret: "router-test-secret"}},
+	})
+	require.NoError(t, err)
+	limits := RateLimits{
+		TokenPerIP: ratelimit.NewLimiter("token_ip", ratelimit.Limit{Requests: 1, Per: time.Hour, Burst: 1}, ratelimit.NewMemoryStore()),
+	}
+	router := APIRouter(internal.Repos{}, jwtService, password.NewBcryptHasher(bcrypt.MinCost), password.Policy{}, service.MailOptions{}, api.NewHealth(), nil, limits)
+
+	// every route redeeming a token draws from the same budget
+	codes := []int{}
+	for _, path := range []string{"/token/refresh", "/verify-email", "/password/reset"} {
+		req := httptest.NewRequest("POST", path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		codes = append(codes, res.Code)
+	}
+
+	assert.Equal(t, []int{http.StatusBadRequest, http.StatusTooManyRequests, http.StatusTooManyRequests}, codes)
+}
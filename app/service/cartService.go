@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sonartest_cart/app/dto"
+	helper "sonartest_cart/app/helper"
+	"sonartest_cart/app/internal"
+	"sonartest_cart/pkg/e"
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/tracing"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type CartService interface {
+	AddItemToCart(r *http.Request) (*dto.CartItemResponse, error)
+	ViewCart(r *http.Request) (*dto.ViewCartResponse, error)
+	UpdateCartItem(r *http.Request) (*dto.CartItemResponse, error)
+	RemoveCartItem(r *http.Request) (*dto.ViewCartResponse, error)
+	ClearCart(r *http.Request) (*dto.ViewCartResponse, error)
+}
+
+type cartServiceImpl struct {
+	cartRepo      internal.CartRepo
+	productRepo   internal.ProductRepo
+	userRepo      internal.UserRepo
+	contextHelper helper.ContextHelper
+}
+
+func NewCartService(cartRepo internal.CartRepo, productRepo internal.ProductRepo, userRepo internal.UserRepo, ctxHelper helper.ContextHelper) CartService {
+	return &cartServiceImpl{
+		cartRepo:      cartRepo,
+		productRepo:   productRepo,
+		userRepo:      userRepo,
+		contextHelper: ctxHelper,
+	}
+}
+
+func (s *cartServiceImpl) getUserIDAndCheckStatus(ctx context.Context) (int64, error) {
+	return checkUserStatus(ctx, s.contextHelper, s.userRepo)
+}
+
+func (s *cartServiceImpl) AddItemToCart(r *http.Request) (_ *dto.CartItemResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "CartService.AddItemToCart")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.AddItemToCart{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	userID, err := s.getUserIDAndCheckStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	brand, err := s.getBrand(ctx, args.BrandId)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.CategoryID != 0 && args.CategoryID != brand.CategoryID {
+		err := fmt.Errorf("brand %d does not belong to category %d", brand.ID, args.CategoryID)
+		return nil, e.NewError(e.ErrValidateRequest, "brand does not belong to category", err)
+	}
+
+	if args.Quantity > brand.StockCount {
+		err := fmt.Errorf("requested %d of brand %d, only %d in stock", args.Quantity, brand.ID, brand.StockCount)
+		return nil, e.NewError(e.ErrInsufficientStock, "insufficient stock", err)
+	}
+
+	// the line may already hold some, together they must not exceed the stock
+	quantity, err := s.cartRepo.AddItem(ctx, userID, brand.CategoryID, brand.ID, args.Quantity, brand.StockCount)
+	if err != nil {
+		if errors.Is(err, internal.ErrCartStockExceeded) {
+			err := fmt.Errorf("adding %d of brand %d would exceed the %d in stock", args.Quantity, brand.ID, brand.StockCount)
+			return nil, e.NewError(e.ErrInsufficientStock, "insufficient stock", err)
+		}
+		return nil, e.NewError(e.ErrAddToCart, "error while adding item to cart", err)
+	}
+	log.Ctx(ctx).Info().Msgf("Added %d of brand %d to cart of user %d", args.Quantity, brand.ID, userID)
+
+	return &dto.CartItemResponse{
+		UserID:     userID,
+		ProductID:  brand.ID,
+		Quantity:   quantity,
+		BrandName:  brand.BrandName,
+		Price:      brand.Price,
+		TotalPrice: lineTotal(brand.Price, quantity),
+	}, nil
+}
+
+func (s *cartServiceImpl) ViewCart(r *http.Request) (_ *dto.ViewCartResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "CartService.ViewCart")
+	defer func() { tracing.End(span, err) }()
+
+	userID, err := s.getUserIDAndCheckStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.buildCart(ctx, userID)
+}
+
+func (s *cartServiceImpl) UpdateCartItem(r *http.Request) (_ *dto.CartItemResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "CartService.UpdateCartItem")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.UpdateCartItemRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	userID, err := s.getUserIDAndCheckStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	brand, err := s.getBrand(ctx, args.BrandID)
+	if err != nil {
+		return nil, err
+	}
+
+	if args.Quantity > brand.StockCount {
+		err := fmt.Errorf("requested %d of brand %d, only %d in stock", args.Quantity, brand.ID, brand.StockCount)
+		return nil, e.NewError(e.ErrInsufficientStock, "insufficient stock", err)
+	}
+
+	err = s.cartRepo.UpdateQuantity(ctx, userID, brand.ID, args.Quantity)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrCartNotFound, "item is not in the cart", err)
+		}
+		return nil, e.NewError(e.ErrUpdateCart, "error while updating cart", err)
+	}
+	log.Ctx(ctx).Info().Msgf("Updated quantity of brand %d to %d in cart of user %d", brand.ID, args.Quantity, userID)
+
+	return &dto.CartItemResponse{
+		UserID:     userID,
+		ProductID:  brand.ID,
+		Quantity:   args.Quantity,
+		BrandName:  brand.BrandName,
+		Price:      brand.Price,
+		TotalPrice: lineTotal(brand.Price, args.Quantity),
+	}, nil
+}
+
+func (s *cartServiceImpl) RemoveCartItem(r *http.Request) (_ *dto.ViewCartResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "CartService.RemoveCartItem")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.RemoveCartItemRequest{}
+
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	userID, err := s.getUserIDAndCheckStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.cartRepo.RemoveItem(ctx, userID, args.BrandID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrCartNotFound, "item is not in the cart", err)
+		}
+		return nil, e.NewError(e.ErrUpdateCart, "error while removing item from cart", err)
+	}
+	log.Ctx(ctx).Info().Msgf("Removed brand %d from cart of user %d", args.BrandID, userID)
+
+	return s.buildCart(ctx, userID)
+}
+
+func (s *cartServiceImpl) ClearCart(r *http.Request) (_ *dto.ViewCartResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "CartService.ClearCart")
+	defer func() { tracing.End(span, err) }()
+
+	userID, err := s.getUserIDAndCheckStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	removed, err := s.cartRepo.ClearCart(ctx, userID)
+	if err != nil {
+		return nil, e.NewError(e.ErrClearCart, "error while clearing cart", err)
+	}
+	log.Ctx(ctx).Info().Msgf("Cleared cart of user %d", userID)
+	if removed > 0 {
+		metrics.CartsCleared.Inc()
+	}
+
+	return &dto.ViewCartResponse{
+		UserID: userID,
+		Items:  []dto.ViewCart{},
+	}, nil
+}
+
+func (s *cartServiceImpl) getBrand(ctx context.Context, brandID int64) (*internal.Brand, error) {
+	brand, err := s.productRepo.GetBrandByID(ctx, brandID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrBrandNotFound, "brand not found", err)
+		}
+		return nil, e.NewError(e.ErrGetBrand, "error while getting brand details", err)
+	}
+	return brand, nil
+}
+
+// buildCart loads the cart lines and computes the per-line and cart totals
+func (s *cartServiceImpl) buildCart(ctx context.Context, userID int64) (*dto.ViewCartResponse, error) {
+	lines, err := s.cartRepo.GetCartItems(ctx, userID)
+	if err != nil {
+		return nil, e.NewError(e.ErrViewCart, "error while getting cart items", err)
+	}
+
+	resp := &dto.ViewCartResponse{
+		UserID: userID,
+		Items:  make([]dto.ViewCart, 0, len(lines)),
+	}
+	for _, line := range lines {
+		total := lineTotal(line.Price, line.Quantity)
+		resp.Items = append(resp.Items, dto.ViewCart{
+			ProductID:   line.BrandID,
+			Quantity:    line.Quantity,
+			Price:       line.Price,
+			BrandName:   line.BrandName,
+			TotalAmount: total,
+		})
+		resp.TotalQuantity += line.Quantity
+		resp.TotalAmount += total
+	}
+	resp.TotalAmount = roundPrice(resp.TotalAmount)
+
+	return resp, nil
+}
+
+func lineTotal(price float64, quantity int64) float64 {
+	return roundPrice(price * float64(quantity))
+}
+
+// roundPrice rounds to whole cents so float noise never reaches the client
+func roundPrice(v float64) float64 {
+	return math.Round(v*100) / 100
+}
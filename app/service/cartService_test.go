@@ -0,0 +1,436 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sonartest_cart/app/dto"
+	helpermocks "sonartest_cart/app/helper/mocks"
+	"sonartest_cart/app/internal"
+	internalmocks "sonartest_cart/app/internal/mocks"
+	"sonartest_cart/pkg/e"
+	"sonartest_cart/pkg/metrics"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type cartMocks struct {
+	cartRepo    *internalmocks.CartRepo
+	productRepo *internalmocks.ProductRepo
+	userRepo    *internalmocks.UserRepo
+	helper      *helpermocks.ContextHelper
+}
+
+func newCartServiceWithMocks(t *testing.T) (CartService, cartMocks) {
+	m := cartMocks{
+		cartRepo:    internalmocks.NewCartRepo(t),
+		productRepo: internalmocks.NewProductRepo(t),
+		userRepo:    internalmocks.NewUserRepo(t),
+		helper:      helpermocks.NewContextHelper(t),
+	}
+	return NewCartService(m.cartRepo, m.productRepo, m.userRepo, m.helper), m
+}
+
+// withURLParam attaches a chi route param to the request the way the router would
+func withURLParam(r *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+func activeUser(m cartMocks, userID int64) {
+	m.helper.On("GetUserID", mock.Anything).Return(userID, nil).Once()
+	m.userRepo.On("IsUserActive", mock.Anything, userID).Return(true, nil).Once()
+}
+
+var testBrand = &internal.Brand{
+	ID:         3,
+	CategoryID: 2,
+	BrandName:  "ACME",
+	Price:      10.25,
+	StockCount: 5,
+}
+
+func TestAddItemToCart(t *testing.T) {
+	tests := []struct {
+		name    string
+		rbody   []byte
+		mock    func(m cartMocks)
+		want    *dto.CartItemResponse
+		errCode int
+	}{
+		{
+			name:    "fail_decode_request",
+			rbody:   []byte(`{"brandid": 3`),
+			mock:    func(m cartMocks) {},
+			errCode: e.ErrDecodeRequestBody,
+		},
+		{
+			name:    "fail_validate_request",
+			rbody:   []byte(`{"brandid": 3, "quantity": 0}`),
+			mock:    func(m cartMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_user_blocked",
+			rbody: []byte(`{"brandid": 3, "quantity": 2}`),
+			mock: func(m cartMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				m.userRepo.On("IsUserActive", mock.Anything, int64(1)).Return(false, nil).Once()
+			},
+			errCode: e.ErrUserBlocked,
+		},
+		{
+			name:  "fail_brand_not_found",
+			rbody: []byte(`{"brandid": 3, "quantity": 2}`),
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrBrandNotFound,
+		},
+		{
+			name:  "fail_category_mismatch",
+			rbody: []byte(`{"brandid": 3, "category_id": 9, "quantity": 2}`),
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+			},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_insufficient_stock",
+			rbody: []byte(`{"brandid": 3, "quantity": 6}`),
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+			},
+			errCode: e.ErrInsufficientStock,
+		},
+		{
+			name:  "fail_line_would_exceed_stock",
+			rbody: []byte(`{"brandid": 3, "quantity": 2}`),
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.cartRepo.On("AddItem", mock.Anything, int64(1), int64(2), int64(3), int64(2), int64(5)).Return(int64(0), internal.ErrCartStockExceeded).Once()
+			},
+			errCode: e.ErrInsufficientStock,
+		},
+		{
+			name:  "fail_add_item",
+			rbody: []byte(`{"brandid": 3, "quantity": 2}`),
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.cartRepo.On("AddItem", mock.Anything, int64(1), int64(2), int64(3), int64(2), int64(5)).Return(int64(0), errors.New("db error")).Once()
+			},
+			errCode: e.ErrAddToCart,
+		},
+		{
+			name:  "success_case",
+			rbody: []byte(`{"brandid": 3, "category_id": 2, "quantity": 2}`),
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.cartRepo.On("AddItem", mock.Anything, int64(1), int64(2), int64(3), int64(2), int64(5)).Return(int64(3), nil).Once()
+			},
+			want: &dto.CartItemResponse{
+				UserID:     1,
+				ProductID:  3,
+				Quantity:   3,
+				BrandName:  "ACME",
+				Price:      10.25,
+				TotalPrice: 30.75,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cartService, m := newCartServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("POST", "/cart", bytes.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := cartService.AddItemToCart(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestViewCart(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(m cartMocks)
+		want    *dto.ViewCartResponse
+		errCode int
+	}{
+		{
+			name: "fail_context_error",
+			mock: func(m cartMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(0), errors.New("no user")).Once()
+			},
+			errCode: e.ErrContextError,
+		},
+		{
+			name: "fail_get_items",
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.cartRepo.On("GetCartItems", mock.Anything, int64(1)).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrViewCart,
+		},
+		{
+			name: "success_empty_cart",
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.cartRepo.On("GetCartItems", mock.Anything, int64(1)).Return([]internal.CartLine{}, nil).Once()
+			},
+			want: &dto.ViewCartResponse{UserID: 1, Items: []dto.ViewCart{}},
+		},
+		{
+			name: "success_with_totals",
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.cartRepo.On("GetCartItems", mock.Anything, int64(1)).Return([]internal.CartLine{
+					{BrandID: 3, CategoryID: 2, Quantity: 3, BrandName: "ACME", Price: 0.1},
+					{BrandID: 4, CategoryID: 2, Quantity: 1, BrandName: "Globex", Price: 19.99},
+				}, nil).Once()
+			},
+			want: &dto.ViewCartResponse{
+				UserID: 1,
+				Items: []dto.ViewCart{
+					{ProductID: 3, Quantity: 3, Price: 0.1, BrandName: "ACME", TotalAmount: 0.3},
+					{ProductID: 4, Quantity: 1, Price: 19.99, BrandName: "Globex", TotalAmount: 19.99},
+				},
+				TotalQuantity: 4,
+				TotalAmount:   20.29,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cartService, m := newCartServiceWithMocks(t)
+			tt.mock(m)
+
+			got, err := cartService.ViewCart(httptest.NewRequest("GET", "/cart", nil))
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateCartItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		brandID string
+		rbody   []byte
+		mock    func(m cartMocks)
+		want    *dto.CartItemResponse
+		errCode int
+	}{
+		{
+			name:    "fail_invalid_brand_id",
+			brandID: "abc",
+			rbody:   []byte(`{"quantity": 2}`),
+			mock:    func(m cartMocks) {},
+			errCode: e.ErrDecodeRequestBody,
+		},
+		{
+			name:    "fail_validate_request",
+			brandID: "3",
+			rbody:   []byte(`{"quantity": -1}`),
+			mock:    func(m cartMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:    "fail_item_not_in_cart",
+			brandID: "3",
+			rbody:   []byte(`{"quantity": 2}`),
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.cartRepo.On("UpdateQuantity", mock.Anything, int64(1), int64(3), int64(2)).Return(gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrCartNotFound,
+		},
+		{
+			name:    "fail_update_error",
+			brandID: "3",
+			rbody:   []byte(`{"quantity": 2}`),
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.cartRepo.On("UpdateQuantity", mock.Anything, int64(1), int64(3), int64(2)).Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrUpdateCart,
+		},
+		{
+			name:    "success_case",
+			brandID: "3",
+			rbody:   []byte(`{"quantity": 4}`),
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.cartRepo.On("UpdateQuantity", mock.Anything, int64(1), int64(3), int64(4)).Return(nil).Once()
+			},
+			want: &dto.CartItemResponse{
+				UserID:     1,
+				ProductID:  3,
+				Quantity:   4,
+				BrandName:  "ACME",
+				Price:      10.25,
+				TotalPrice: 41,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cartService, m := newCartServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("PUT", "/cart/"+tt.brandID, bytes.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+			req = withURLParam(req, "brandid", tt.brandID)
+
+			got, err := cartService.UpdateCartItem(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRemoveCartItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(m cartMocks)
+		errCode int
+	}{
+		{
+			name: "fail_item_not_in_cart",
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.cartRepo.On("RemoveItem", mock.Anything, int64(1), int64(3)).Return(gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrCartNotFound,
+		},
+		{
+			name: "success_case",
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.cartRepo.On("RemoveItem", mock.Anything, int64(1), int64(3)).Return(nil).Once()
+				m.cartRepo.On("GetCartItems", mock.Anything, int64(1)).Return([]internal.CartLine{}, nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cartService, m := newCartServiceWithMocks(t)
+			tt.mock(m)
+
+			req := withURLParam(httptest.NewRequest("DELETE", "/cart/3", nil), "brandid", "3")
+			got, err := cartService.RemoveCartItem(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Empty(t, got.Items)
+			}
+		})
+	}
+}
+
+func TestClearCart(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(m cartMocks)
+		cleared bool
+		errCode int
+	}{
+		{
+			name: "fail_clear_error",
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.cartRepo.On("ClearCart", mock.Anything, int64(1)).Return(int64(0), errors.New("db error")).Once()
+			},
+			errCode: e.ErrClearCart,
+		},
+		{
+			name: "success_case",
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.cartRepo.On("ClearCart", mock.Anything, int64(1)).Return(int64(2), nil).Once()
+			},
+			cleared: true,
+		},
+		{
+			// clearing an empty cart is not counted
+			name: "success_already_empty",
+			mock: func(m cartMocks) {
+				activeUser(m, 1)
+				m.cartRepo.On("ClearCart", mock.Anything, int64(1)).Return(int64(0), nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cartService, m := newCartServiceWithMocks(t)
+			tt.mock(m)
+
+			cleared := testutil.ToFloat64(metrics.CartsCleared)
+			got, err := cartService.ClearCart(httptest.NewRequest("DELETE", "/cart", nil))
+			if tt.cleared {
+				cleared++
+			}
+			assert.Equal(t, cleared, testutil.ToFloat64(metrics.CartsCleared))
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &dto.ViewCartResponse{UserID: 1, Items: []dto.ViewCart{}}, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sonartest_cart/app/dto"
+	helper "sonartest_cart/app/helper"
+	"sonartest_cart/app/internal"
+	"sonartest_cart/pkg/e"
+	"sonartest_cart/pkg/tracing"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type FavouriteService interface {
+	ToggleFavourite(r *http.Request) (*dto.FavoriteToggleResponse, error)
+	GetFavourites(r *http.Request) ([]dto.FavoriteBrandResponse, error)
+}
+
+type favouriteServiceImpl struct {
+	favouriteRepo internal.FavouriteRepo
+	productRepo   internal.ProductRepo
+	userRepo      internal.UserRepo
+	contextHelper helper.ContextHelper
+}
+
+func NewFavouriteService(favouriteRepo internal.FavouriteRepo, productRepo internal.ProductRepo, userRepo internal.UserRepo, ctxHelper helper.ContextHelper) FavouriteService {
+	return &favouriteServiceImpl{
+		favouriteRepo: favouriteRepo,
+		productRepo:   productRepo,
+		userRepo:      userRepo,
+		contextHelper: ctxHelper,
+	}
+}
+
+func (s *favouriteServiceImpl) getUserIDAndCheckStatus(ctx context.Context) (int64, error) {
+	return checkUserStatus(ctx, s.contextHelper, s.userRepo)
+}
+
+func (s *favouriteServiceImpl) ToggleFavourite(r *http.Request) (_ *dto.FavoriteToggleResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "FavouriteService.ToggleFavourite")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.UserFavoriteBrandRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	userID, err := s.getUserIDAndCheckStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.productRepo.GetBrandByID(ctx, args.BrandID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrBrandNotFound, "brand not found", err)
+		}
+		return nil, e.NewError(e.ErrGetBrand, "error while getting brand details", err)
+	}
+
+	if args.Favorite {
+		err = s.favouriteRepo.AddFavourite(ctx, userID, args.BrandID)
+		if err != nil {
+			return nil, e.NewError(e.ErrAddToFavorites, "error while adding to favourites", err)
+		}
+		log.Ctx(ctx).Info().Msgf("User %d favourited brand %d", userID, args.BrandID)
+	} else {
+		err = s.favouriteRepo.RemoveFavourite(ctx, userID, args.BrandID)
+		if err != nil {
+			return nil, e.NewError(e.ErrUpdateFavorites, "error while removing from favourites", err)
+		}
+		log.Ctx(ctx).Info().Msgf("User %d unfavourited brand %d", userID, args.BrandID)
+	}
+
+	return &dto.FavoriteToggleResponse{
+		BrandID:  args.BrandID,
+		Favorite: args.Favorite,
+	}, nil
+}
+
+func (s *favouriteServiceImpl) GetFavourites(r *http.Request) (_ []dto.FavoriteBrandResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "FavouriteService.GetFavourites")
+	defer func() { tracing.End(span, err) }()
+
+	userID, err := s.getUserIDAndCheckStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	brands, err := s.favouriteRepo.GetFavourites(ctx, userID)
+	if err != nil {
+		return nil, e.NewError(e.ErrGetFavorites, "error while getting favourites", err)
+	}
+
+	resp := make([]dto.FavoriteBrandResponse, 0, len(brands))
+	for _, b := range brands {
+		resp = append(resp, dto.FavoriteBrandResponse{
+			BrandID:   b.ID,
+			BrandName: b.BrandName,
+			Price:     b.Price,
+			Stock:     b.StockCount,
+			ImageLink: b.ImageLink,
+		})
+	}
+	return resp, nil
+}
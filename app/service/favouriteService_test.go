@@ -0,0 +1,200 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"sonartest_cart/app/dto"
+	helpermocks "sonartest_cart/app/helper/mocks"
+	"sonartest_cart/app/internal"
+	internalmocks "sonartest_cart/app/internal/mocks"
+	"sonartest_cart/pkg/e"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type favouriteMocks struct {
+	favouriteRepo *internalmocks.FavouriteRepo
+	productRepo   *internalmocks.ProductRepo
+	userRepo      *internalmocks.UserRepo
+	helper        *helpermocks.ContextHelper
+}
+
+func newFavouriteServiceWithMocks(t *testing.T) (FavouriteService, favouriteMocks) {
+	m := favouriteMocks{
+		favouriteRepo: internalmocks.NewFavouriteRepo(t),
+		productRepo:   internalmocks.NewProductRepo(t),
+		userRepo:      internalmocks.NewUserRepo(t),
+		helper:        helpermocks.NewContextHelper(t),
+	}
+	return NewFavouriteService(m.favouriteRepo, m.productRepo, m.userRepo, m.helper), m
+}
+
+func activeFavouriteUser(m favouriteMocks, userID int64) {
+	m.helper.On("GetUserID", mock.Anything).Return(userID, nil).Once()
+	m.userRepo.On("IsUserActive", mock.Anything, userID).Return(true, nil).Once()
+}
+
+func TestToggleFavourite(t *testing.T) {
+	tests := []struct {
+		name    string
+		rbody   []byte
+		mock    func(m favouriteMocks)
+		want    *dto.FavoriteToggleResponse
+		errCode int
+	}{
+		{
+			name:    "fail_decode_request",
+			rbody:   []byte(`{"brandid": 3`),
+			mock:    func(m favouriteMocks) {},
+			errCode: e.ErrDecodeRequestBody,
+		},
+		{
+			name:    "fail_validate_request",
+			rbody:   []byte(`{"favourite": true}`),
+			mock:    func(m favouriteMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_user_blocked",
+			rbody: []byte(`{"brandid": 3, "favourite": true}`),
+			mock: func(m favouriteMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				m.userRepo.On("IsUserActive", mock.Anything, int64(1)).Return(false, nil).Once()
+			},
+			errCode: e.ErrUserBlocked,
+		},
+		{
+			name:  "fail_brand_not_found",
+			rbody: []byte(`{"brandid": 3, "favourite": true}`),
+			mock: func(m favouriteMocks) {
+				activeFavouriteUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrBrandNotFound,
+		},
+		{
+			name:  "fail_add_favourite",
+			rbody: []byte(`{"brandid": 3, "favourite": true}`),
+			mock: func(m favouriteMocks) {
+				activeFavouriteUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.favouriteRepo.On("AddFavourite", mock.Anything, int64(1), int64(3)).Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrAddToFavorites,
+		},
+		{
+			name:  "fail_remove_favourite",
+			rbody: []byte(`{"brandid": 3, "favourite": false}`),
+			mock: func(m favouriteMocks) {
+				activeFavouriteUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.favouriteRepo.On("RemoveFavourite", mock.Anything, int64(1), int64(3)).Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrUpdateFavorites,
+		},
+		{
+			name:  "success_add",
+			rbody: []byte(`{"brandid": 3, "favourite": true}`),
+			mock: func(m favouriteMocks) {
+				activeFavouriteUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.favouriteRepo.On("AddFavourite", mock.Anything, int64(1), int64(3)).Return(nil).Once()
+			},
+			want: &dto.FavoriteToggleResponse{BrandID: 3, Favorite: true},
+		},
+		{
+			name:  "success_remove",
+			rbody: []byte(`{"brandid": 3, "favourite": false}`),
+			mock: func(m favouriteMocks) {
+				activeFavouriteUser(m, 1)
+				m.productRepo.On("GetBrandByID", mock.Anything, int64(3)).Return(testBrand, nil).Once()
+				m.favouriteRepo.On("RemoveFavourite", mock.Anything, int64(1), int64(3)).Return(nil).Once()
+			},
+			want: &dto.FavoriteToggleResponse{BrandID: 3, Favorite: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			favouriteService, m := newFavouriteServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("POST", "/favourites", bytes.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := favouriteService.ToggleFavourite(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetFavourites(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(m favouriteMocks)
+		want    []dto.FavoriteBrandResponse
+		errCode int
+	}{
+		{
+			name: "fail_get_favourites",
+			mock: func(m favouriteMocks) {
+				activeFavouriteUser(m, 1)
+				m.favouriteRepo.On("GetFavourites", mock.Anything, int64(1)).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrGetFavorites,
+		},
+		{
+			name: "success_empty",
+			mock: func(m favouriteMocks) {
+				activeFavouriteUser(m, 1)
+				m.favouriteRepo.On("GetFavourites", mock.Anything, int64(1)).Return(nil, nil).Once()
+			},
+			want: []dto.FavoriteBrandResponse{},
+		},
+		{
+			name: "success_case",
+			mock: func(m favouriteMocks) {
+				activeFavouriteUser(m, 1)
+				m.favouriteRepo.On("GetFavourites", mock.Anything, int64(1)).Return([]internal.Brand{
+					{ID: 3, BrandName: "ACME", Price: 10.25, StockCount: 5, ImageLink: "http://img/acme.png"},
+				}, nil).Once()
+			},
+			want: []dto.FavoriteBrandResponse{
+				{BrandID: 3, BrandName: "ACME", Price: 10.25, Stock: 5, ImageLink: "http://img/acme.png"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			favouriteService, m := newFavouriteServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("GET", "/favourites", nil)
+
+			got, err := favouriteService.GetFavourites(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"sonartest_cart/app/internal"
+	"sonartest_cart/pkg/jwt"
+	"sonartest_cart/pkg/mail"
+
+	"github.com/rs/zerolog/log"
+)
+
+// MailOptions configures the mails the user service sends and what users
+// with an unverified mail address may do
+type MailOptions struct {
+	Mailer mail.Mailer
+	// VerifyURL is the page the verification link points to, the token is
+	// added as the token query parameter
+	VerifyURL      string
+	VerifyTokenTTL time.Duration
+	// ResetURL is the page the password reset link points to, the same way
+	ResetURL      string
+	ResetTokenTTL time.Duration
+	// UnverifiedLogin is one of the config.Unverified* values
+	UnverifiedLogin string
+	// Queue sends the mails off the request path, nil gets a queue with the
+	// default size that is never drained
+	Queue *MailQueue
+}
+
+// Defaults of the mail queue
+const (
+	DefaultMailWorkers   = 4
+	DefaultMailQueueSize = 256
+)
+
+// ErrMailQueueFull is logged for a mail dropped because the queue is full
+var ErrMailQueueFull = errors.New("mail queue is full")
+
+// MailQueue sends mails in the background with a fixed number of workers. A
+// full queue drops the mail rather than holding up the request, Close sends
+// what is still queued before the process exits.
+type MailQueue struct {
+	mu     sync.RWMutex
+	closed bool
+	jobs   chan mailJob
+	wg     sync.WaitGroup
+}
+
+type mailJob struct {
+	ctx context.Context
+	run func(ctx context.Context) error
+}
+
+func NewMailQueue(workers, size int) *MailQueue {
+	q := &MailQueue{jobs: make(chan mailJob, size)}
+	q.wg.Add(workers)
+	for range workers {
+		go q.work()
+	}
+	return q
+}
+
+func (q *MailQueue) work() {
+	defer q.wg.Done()
+	for job := range q.jobs {
+		if err := job.run(job.ctx); err != nil {
+			log.Ctx(job.ctx).Error().Err(err).Msg("failed to send mail")
+		}
+	}
+}
+
+// Enqueue queues job, it runs with ctx detached from the request
+func (q *MailQueue) Enqueue(ctx context.Context, job func(ctx context.Context) error) {
+	ctx = context.WithoutCancel(ctx)
+
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		log.Ctx(ctx).Error().Msg("mail queue is closed, mail dropped")
+		return
+	}
+	select {
+	case q.jobs <- mailJob{ctx: ctx, run: job}:
+	default:
+		log.Ctx(ctx).Error().Err(ErrMailQueueFull).Msg("mail dropped")
+	}
+}
+
+// Close stops taking mails and waits until the queued ones are sent or ctx is
+// done, in which case the rest is lost and ctx.Err() returned
+func (q *MailQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.jobs)
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d mails not sent: %w", len(q.jobs), ctx.Err())
+	}
+}
+
+// mailInBackground queues job off the request path, so a request that sends a
+// mail answers as fast as one that doesn't. The job keeps the logger and trace
+// of ctx but not its cancellation, a failure is only logged.
+func (s *userServiceImpl) mailInBackground(ctx context.Context, job func(ctx context.Context) error) {
+	s.mail.Queue.Enqueue(ctx, job)
+}
+
+// sendVerificationMail mails the user a link with a new verification token
+func (s *userServiceImpl) sendVerificationMail(ctx context.Context, userID int64, username, to string) error {
+	link, err := s.mailLink(ctx, userID, internal.MailTokenVerify, s.mail.VerifyURL, s.mail.VerifyTokenTTL)
+	if err != nil {
+		return err
+	}
+	return s.mail.Mailer.Send(ctx, mail.Message{
+		To:      to,
+		Subject: "Verify your mail address",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"please confirm your mail address by opening the link below, it is valid for %s.\n\n"+
+			"%s\n\n"+
+			"If you did not sign up, you can ignore this mail.\n",
+			username, validFor(s.mail.VerifyTokenTTL), link),
+	})
+}
+
+// sendResetMail mails the user a link with a new password reset token
+func (s *userServiceImpl) sendResetMail(ctx context.Context, userID int64, username, to string) error {
+	link, err := s.mailLink(ctx, userID, internal.MailTokenResetPassword, s.mail.ResetURL, s.mail.ResetTokenTTL)
+	if err != nil {
+		return err
+	}
+	return s.mail.Mailer.Send(ctx, mail.Message{
+		To:      to,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"somebody asked to reset the password of your account. Open the link below to choose a new one, it is valid for %s.\n\n"+
+			"%s\n\n"+
+			"If it wasn't you, you can ignore this mail, your password stays the same.\n",
+			username, validFor(s.mail.ResetTokenTTL), link),
+	})
+}
+
+// mailLink stores a new token for purpose and returns page with the token added
+func (s *userServiceImpl) mailLink(ctx context.Context, userID int64, purpose, page string, ttl time.Duration) (string, error) {
+	// mail tokens are opaque random tokens just like refresh tokens
+	token, err := jwt.NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.tokenRepo.CreateMailToken(ctx, &internal.Mailtoken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: jwt.HashRefreshToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return "", err
+	}
+	return linkWithToken(page, token)
+}
+
+// linkWithToken adds token as the token query parameter of page
+func linkWithToken(page, token string) (string, error) {
+	u, err := url.Parse(page)
+	if err != nil {
+		return "", fmt.Errorf("invalid link %q: %w", page, err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// validFor spells out a token lifetime for a mail, e.g. "24 hours"
+func validFor(ttl time.Duration) string {
+	switch {
+	case ttl == time.Hour:
+		return "1 hour"
+	case ttl > time.Hour && ttl%time.Hour == 0:
+		return fmt.Sprintf("%d hours", int64(ttl/time.Hour))
+	case ttl == time.Minute:
+		return "1 minute"
+	default:
+		return fmt.Sprintf("%d minutes", int64(ttl.Round(time.Minute)/time.Minute))
+	}
+}
@@ -0,0 +1,179 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	http "net/http"
+	dto "sonartest_cart/app/dto"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// CartService is an autogenerated mock type for the CartService type
+type CartService struct {
+	mock.Mock
+}
+
+// AddItemToCart provides a mock function with given fields: r
+func (_m *CartService) AddItemToCart(r *http.Request) (*dto.CartItemResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AddItemToCart")
+	}
+
+	var r0 *dto.CartItemResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.CartItemResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.CartItemResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.CartItemResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClearCart provides a mock function with given fields: r
+func (_m *CartService) ClearCart(r *http.Request) (*dto.ViewCartResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClearCart")
+	}
+
+	var r0 *dto.ViewCartResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.ViewCartResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.ViewCartResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.ViewCartResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RemoveCartItem provides a mock function with given fields: r
+func (_m *CartService) RemoveCartItem(r *http.Request) (*dto.ViewCartResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RemoveCartItem")
+	}
+
+	var r0 *dto.ViewCartResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.ViewCartResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.ViewCartResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.ViewCartResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCartItem provides a mock function with given fields: r
+func (_m *CartService) UpdateCartItem(r *http.Request) (*dto.CartItemResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCartItem")
+	}
+
+	var r0 *dto.CartItemResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.CartItemResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.CartItemResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.CartItemResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ViewCart provides a mock function with given fields: r
+func (_m *CartService) ViewCart(r *http.Request) (*dto.ViewCartResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ViewCart")
+	}
+
+	var r0 *dto.ViewCartResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.ViewCartResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.ViewCartResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.ViewCartResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewCartService creates a new instance of CartService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewCartService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *CartService {
+	mock := &CartService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
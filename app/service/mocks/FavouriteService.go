@@ -0,0 +1,89 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	http "net/http"
+	dto "sonartest_cart/app/dto"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// FavouriteService is an autogenerated mock type for the FavouriteService type
+type FavouriteService struct {
+	mock.Mock
+}
+
+// GetFavourites provides a mock function with given fields: r
+func (_m *FavouriteService) GetFavourites(r *http.Request) ([]dto.FavoriteBrandResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetFavourites")
+	}
+
+	var r0 []dto.FavoriteBrandResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) ([]dto.FavoriteBrandResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) []dto.FavoriteBrandResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.FavoriteBrandResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ToggleFavourite provides a mock function with given fields: r
+func (_m *FavouriteService) ToggleFavourite(r *http.Request) (*dto.FavoriteToggleResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ToggleFavourite")
+	}
+
+	var r0 *dto.FavoriteToggleResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.FavoriteToggleResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.FavoriteToggleResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.FavoriteToggleResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewFavouriteService creates a new instance of FavouriteService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewFavouriteService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *FavouriteService {
+	mock := &FavouriteService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,119 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	http "net/http"
+	dto "sonartest_cart/app/dto"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// OrderService is an autogenerated mock type for the OrderService type
+type OrderService struct {
+	mock.Mock
+}
+
+// GetCustomerOrderHistory provides a mock function with given fields: r
+func (_m *OrderService) GetCustomerOrderHistory(r *http.Request) (*dto.OrderHistoryResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCustomerOrderHistory")
+	}
+
+	var r0 *dto.OrderHistoryResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.OrderHistoryResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.OrderHistoryResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.OrderHistoryResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetOrderHistory provides a mock function with given fields: r
+func (_m *OrderService) GetOrderHistory(r *http.Request) (*dto.OrderHistoryResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetOrderHistory")
+	}
+
+	var r0 *dto.OrderHistoryResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.OrderHistoryResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.OrderHistoryResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.OrderHistoryResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// PlaceOrder provides a mock function with given fields: r
+func (_m *OrderService) PlaceOrder(r *http.Request) (*dto.ItemOrderedResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PlaceOrder")
+	}
+
+	var r0 *dto.ItemOrderedResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.ItemOrderedResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.ItemOrderedResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.ItemOrderedResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewOrderService creates a new instance of OrderService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewOrderService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *OrderService {
+	mock := &OrderService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,209 @@
+// Code generated by mockery v2.43.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	http "net/http"
+	dto "sonartest_cart/app/dto"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ProductService is an autogenerated mock type for the ProductService type
+type ProductService struct {
+	mock.Mock
+}
+
+// CreateCategory provides a mock function with given fields: r
+func (_m *ProductService) CreateCategory(r *http.Request) (*dto.CreateProductResponds, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateCategory")
+	}
+
+	var r0 *dto.CreateProductResponds
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.CreateProductResponds, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.CreateProductResponds); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.CreateProductResponds)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCategoryByID provides a mock function with given fields: r
+func (_m *ProductService) GetCategoryByID(r *http.Request) (*dto.CategoryDetailResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCategoryByID")
+	}
+
+	var r0 *dto.CategoryDetailResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.CategoryDetailResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.CategoryDetailResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.CategoryDetailResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetCategoryByName provides a mock function with given fields: r
+func (_m *ProductService) GetCategoryByName(r *http.Request) (*dto.CategoryDetailResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCategoryByName")
+	}
+
+	var r0 *dto.CategoryDetailResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.CategoryDetailResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.CategoryDetailResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.CategoryDetailResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListCategories provides a mock function with given fields: r
+func (_m *ProductService) ListCategories(r *http.Request) ([]dto.CatagoryListResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListCategories")
+	}
+
+	var r0 []dto.CatagoryListResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) ([]dto.CatagoryListResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) []dto.CatagoryListResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.CatagoryListResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateBrand provides a mock function with given fields: r
+func (_m *ProductService) UpdateBrand(r *http.Request) (*dto.BrandDetailResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateBrand")
+	}
+
+	var r0 *dto.BrandDetailResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.BrandDetailResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.BrandDetailResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.BrandDetailResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateCategory provides a mock function with given fields: r
+func (_m *ProductService) UpdateCategory(r *http.Request) (*dto.CatagoryListResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateCategory")
+	}
+
+	var r0 *dto.CatagoryListResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.CatagoryListResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.CatagoryListResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.CatagoryListResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewProductService creates a new instance of ProductService. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewProductService(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ProductService {
+	mock := &ProductService{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -3,10 +3,8 @@
 package mocks
 
 import (
-	context "context"
-	dto "sonartest_cart/app/dto"
-
 	http "net/http"
+	dto "sonartest_cart/app/dto"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -16,6 +14,216 @@ type UserService struct {
 	mock.Mock
 }
 
+// AssignRole provides a mock function with given fields: r
+func (_m *UserService) AssignRole(r *http.Request) (*dto.UserRoleResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for AssignRole")
+	}
+
+	var r0 *dto.UserRoleResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.UserRoleResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.UserRoleResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.UserRoleResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// BlockUser provides a mock function with given fields: r
+func (_m *UserService) BlockUser(r *http.Request) (*dto.UserStatusResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for BlockUser")
+	}
+
+	var r0 *dto.UserStatusResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.UserStatusResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.UserStatusResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.UserStatusResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ChangePassword provides a mock function with given fields: r
+func (_m *UserService) ChangePassword(r *http.Request) (*dto.PasswordChangedResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ChangePassword")
+	}
+
+	var r0 *dto.PasswordChangedResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.PasswordChangedResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.PasswordChangedResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.PasswordChangedResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ForgotPassword provides a mock function with given fields: r
+func (_m *UserService) ForgotPassword(r *http.Request) (*dto.ForgotPasswordResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ForgotPassword")
+	}
+
+	var r0 *dto.ForgotPasswordResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.ForgotPasswordResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.ForgotPasswordResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.ForgotPasswordResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetMyProfile provides a mock function with given fields: r
+func (_m *UserService) GetMyProfile(r *http.Request) (*dto.AllUserDetails, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetMyProfile")
+	}
+
+	var r0 *dto.AllUserDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.AllUserDetails, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.AllUserDetails); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.AllUserDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRoles provides a mock function with given fields: r
+func (_m *UserService) ListRoles(r *http.Request) ([]dto.RoleDetails, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListRoles")
+	}
+
+	var r0 []dto.RoleDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) ([]dto.RoleDetails, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) []dto.RoleDetails); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.RoleDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListUsers provides a mock function with given fields: r
+func (_m *UserService) ListUsers(r *http.Request) ([]dto.AllUserDetails, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListUsers")
+	}
+
+	var r0 []dto.AllUserDetails
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) ([]dto.AllUserDetails, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) []dto.AllUserDetails); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]dto.AllUserDetails)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // LoginUser provides a mock function with given fields: r
 func (_m *UserService) LoginUser(r *http.Request) (*dto.LoginResponse, error) {
 	ret := _m.Called(r)
@@ -46,6 +254,126 @@ func (_m *UserService) LoginUser(r *http.Request) (*dto.LoginResponse, error) {
 	return r0, r1
 }
 
+// Logout provides a mock function with given fields: r
+func (_m *UserService) Logout(r *http.Request) (*dto.LogoutResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Logout")
+	}
+
+	var r0 *dto.LogoutResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.LogoutResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.LogoutResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.LogoutResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RefreshToken provides a mock function with given fields: r
+func (_m *UserService) RefreshToken(r *http.Request) (*dto.LoginResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefreshToken")
+	}
+
+	var r0 *dto.LoginResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.LoginResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.LoginResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.LoginResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResendVerificationMail provides a mock function with given fields: r
+func (_m *UserService) ResendVerificationMail(r *http.Request) (*dto.ResendVerificationResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResendVerificationMail")
+	}
+
+	var r0 *dto.ResendVerificationResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.ResendVerificationResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.ResendVerificationResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.ResendVerificationResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetPassword provides a mock function with given fields: r
+func (_m *UserService) ResetPassword(r *http.Request) (*dto.PasswordChangedResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResetPassword")
+	}
+
+	var r0 *dto.PasswordChangedResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.PasswordChangedResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.PasswordChangedResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.PasswordChangedResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // SaveUserDetails provides a mock function with given fields: r
 func (_m *UserService) SaveUserDetails(r *http.Request) (*dto.SaveUserResponse, error) {
 	ret := _m.Called(r)
@@ -76,27 +404,89 @@ func (_m *UserService) SaveUserDetails(r *http.Request) (*dto.SaveUserResponse,
 	return r0, r1
 }
 
-// getUserIDAndCheckStatus provides a mock function with given fields: ctx
-func (_m *UserService) getUserIDAndCheckStatus(ctx context.Context) (int64, error) {
-	ret := _m.Called(ctx)
+// UnblockUser provides a mock function with given fields: r
+func (_m *UserService) UnblockUser(r *http.Request) (*dto.UserStatusResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UnblockUser")
+	}
+
+	var r0 *dto.UserStatusResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.UserStatusResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.UserStatusResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.UserStatusResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateUserDetails provides a mock function with given fields: r
+func (_m *UserService) UpdateUserDetails(r *http.Request) (*dto.AllUserDetails, error) {
+	ret := _m.Called(r)
 
 	if len(ret) == 0 {
-		panic("no return value specified for getUserIDAndCheckStatus")
+		panic("no return value specified for UpdateUserDetails")
 	}
 
-	var r0 int64
+	var r0 *dto.AllUserDetails
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
-		return rf(ctx)
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.AllUserDetails, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.AllUserDetails); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.AllUserDetails)
+		}
 	}
-	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
-		r0 = rf(ctx)
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
 	} else {
-		r0 = ret.Get(0).(int64)
+		r1 = ret.Error(1)
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
-		r1 = rf(ctx)
+	return r0, r1
+}
+
+// VerifyMail provides a mock function with given fields: r
+func (_m *UserService) VerifyMail(r *http.Request) (*dto.VerifyMailResponse, error) {
+	ret := _m.Called(r)
+
+	if len(ret) == 0 {
+		panic("no return value specified for VerifyMail")
+	}
+
+	var r0 *dto.VerifyMailResponse
+	var r1 error
+	if rf, ok := ret.Get(0).(func(*http.Request) (*dto.VerifyMailResponse, error)); ok {
+		return rf(r)
+	}
+	if rf, ok := ret.Get(0).(func(*http.Request) *dto.VerifyMailResponse); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*dto.VerifyMailResponse)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(*http.Request) error); ok {
+		r1 = rf(r)
 	} else {
 		r1 = ret.Error(1)
 	}
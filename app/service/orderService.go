@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sonartest_cart/app/dto"
+	helper "sonartest_cart/app/helper"
+	"sonartest_cart/app/internal"
+	"sonartest_cart/pkg/e"
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/tracing"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type OrderService interface {
+	PlaceOrder(r *http.Request) (*dto.ItemOrderedResponse, error)
+	GetOrderHistory(r *http.Request) (*dto.OrderHistoryResponse, error)
+	GetCustomerOrderHistory(r *http.Request) (*dto.OrderHistoryResponse, error)
+}
+
+type orderServiceImpl struct {
+	orderRepo     internal.OrderRepo
+	userRepo      internal.UserRepo
+	contextHelper helper.ContextHelper
+}
+
+func NewOrderService(orderRepo internal.OrderRepo, userRepo internal.UserRepo, ctxHelper helper.ContextHelper) OrderService {
+	return &orderServiceImpl{
+		orderRepo:     orderRepo,
+		userRepo:      userRepo,
+		contextHelper: ctxHelper,
+	}
+}
+
+func (s *orderServiceImpl) getUserIDAndCheckStatus(ctx context.Context) (int64, error) {
+	return checkUserStatus(ctx, s.contextHelper, s.userRepo)
+}
+
+func (s *orderServiceImpl) PlaceOrder(r *http.Request) (_ *dto.ItemOrderedResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "OrderService.PlaceOrder")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.PlaceOrderFromCart{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	userID, err := s.getUserIDAndCheckStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// fetch the user before checkout so a failure here can't lose a placed order
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, e.NewError(e.ErrGetUserDetails, "error while getting user details", err)
+	}
+
+	order, err := s.orderRepo.PlaceOrder(ctx, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, internal.ErrEmptyCart):
+			return nil, e.NewError(e.ErrPlaceOrder, "cart is empty", err)
+		case errors.Is(err, internal.ErrInsufficientStock):
+			return nil, e.NewError(e.ErrInsufficientStock, "insufficient stock", err)
+		case errors.Is(err, internal.ErrStockUpdate):
+			return nil, e.NewError(e.ErrUpdateStock, "error while updating stock", err)
+		default:
+			return nil, e.NewError(e.ErrTransactionError, "error while placing order", err)
+		}
+	}
+	log.Ctx(ctx).Info().Msgf("Placed order %d for user %d, total %.2f", order.ID, userID, order.TotalPrice)
+	metrics.OrdersPlaced.Inc()
+
+	resp := &dto.ItemOrderedResponse{
+		OrderID:    order.ID,
+		TotalPrice: order.TotalPrice,
+		UserDetails: dto.UserDetailsResponse{
+			Username:    user.Username,
+			Address:     user.Address,
+			Country:     user.Country,
+			Pincode:     user.Pincode,
+			PhoneNumber: user.Phonenumber,
+			Email:       user.Mail,
+		},
+		Items: make([]dto.OrderItemResponse, 0, len(order.Items)),
+	}
+	for _, item := range order.Items {
+		resp.Items = append(resp.Items, dto.OrderItemResponse{
+			ProductID:  item.BrandID,
+			Quantity:   item.Quantity,
+			CategoryID: item.CategoryID,
+			BrandName:  item.BrandName,
+			Price:      item.Price,
+		})
+	}
+	return resp, nil
+}
+
+// GetOrderHistory lists the orders of the logged in user
+func (s *orderServiceImpl) GetOrderHistory(r *http.Request) (_ *dto.OrderHistoryResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "OrderService.GetOrderHistory")
+	defer func() { tracing.End(span, err) }()
+
+	userID, err := s.getUserIDAndCheckStatus(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.orderHistory(ctx, r, userID)
+}
+
+// GetCustomerOrderHistory lets an admin list the orders of any user
+func (s *orderServiceImpl) GetCustomerOrderHistory(r *http.Request) (_ *dto.OrderHistoryResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "OrderService.GetCustomerOrderHistory")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.SearchByCustomerIdRequest{}
+
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrInvalidRequest, err)
+	}
+
+	_, err = s.userRepo.GetUserByID(ctx, args.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		return nil, e.NewError(e.ErrGetUserDetails, "error while getting user details", err)
+	}
+
+	return s.orderHistory(ctx, r, args.UserId)
+}
+
+func (s *orderServiceImpl) orderHistory(ctx context.Context, r *http.Request, userID int64) (*dto.OrderHistoryResponse, error) {
+	args := &dto.OrderHistoryRequest{}
+
+	// parsing the query params
+	err := args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrInvalidRequest, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	filter := internal.OrderHistoryFilter{
+		UserID: userID,
+		From:   args.From,
+		To:     args.To,
+		// one extra row tells us whether there is a next page
+		Limit: args.Limit + 1,
+	}
+	if args.Cursor != nil {
+		filter.CursorCreatedAt = &args.Cursor.CreatedAt
+		filter.CursorID = args.Cursor.OrderID
+	}
+
+	orders, err := s.orderRepo.GetOrderHistory(ctx, filter)
+	if err != nil {
+		return nil, e.NewError(e.ErrGetOrderHistory, "error while getting order history", err)
+	}
+
+	resp := &dto.OrderHistoryResponse{
+		UserID: userID,
+		Orders: make([]dto.OrderHistoryItem, 0, len(orders)),
+	}
+	if len(orders) > args.Limit {
+		orders = orders[:args.Limit]
+		last := orders[len(orders)-1]
+		resp.NextCursor = dto.EncodeOrderCursor(last.CreatedAt, last.ID)
+	}
+
+	for _, order := range orders {
+		item := dto.OrderHistoryItem{
+			OrderID:    order.ID,
+			Status:     order.Status,
+			TotalPrice: order.TotalPrice,
+			CreatedAt:  order.CreatedAt,
+			Items:      make([]dto.OrderItemResponse, 0, len(order.Items)),
+		}
+		for _, line := range order.Items {
+			item.TotalQuantity += line.Quantity
+			item.Items = append(item.Items, dto.OrderItemResponse{
+				ProductID:  line.BrandID,
+				Quantity:   line.Quantity,
+				CategoryID: line.CategoryID,
+				BrandName:  line.BrandName,
+				Price:      line.Price,
+			})
+		}
+		resp.Orders = append(resp.Orders, item)
+	}
+	log.Ctx(ctx).Info().Msgf("Fetched %d orders for user %d", len(resp.Orders), userID)
+
+	return resp, nil
+}
@@ -0,0 +1,358 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sonartest_cart/app/dto"
+	helpermocks "sonartest_cart/app/helper/mocks"
+	"sonartest_cart/app/internal"
+	internalmocks "sonartest_cart/app/internal/mocks"
+	"sonartest_cart/pkg/e"
+	"sonartest_cart/pkg/metrics"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestPlaceOrder(t *testing.T) {
+	user := &internal.Userdetail{
+		ID:          1,
+		Username:    "testuser",
+		Address:     "123 Test St",
+		Pincode:     "123456",
+		Phonenumber: "+919876543210",
+		Mail:        "test@example.com",
+		Status:      true,
+	}
+
+	tests := []struct {
+		name    string
+		rbody   []byte
+		mock    func(orderRepoMock *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo)
+		want    *dto.ItemOrderedResponse
+		errCode int
+	}{
+		{
+			name:    "fail_decode_request",
+			rbody:   []byte(`{"cartid":`),
+			mock:    func(_ *internalmocks.OrderRepo, _ *internalmocks.UserRepo) {},
+			errCode: e.ErrDecodeRequestBody,
+		},
+		{
+			name:  "fail_get_user",
+			rbody: nil,
+			mock: func(_ *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo) {
+				userRepoMock.On("IsUserActive", mock.Anything, int64(1)).Return(true, nil).Once()
+				userRepoMock.On("GetUserByID", mock.Anything, int64(1)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrGetUserDetails,
+		},
+		{
+			name:  "fail_empty_cart",
+			rbody: nil,
+			mock: func(orderRepoMock *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo) {
+				userRepoMock.On("IsUserActive", mock.Anything, int64(1)).Return(true, nil).Once()
+				userRepoMock.On("GetUserByID", mock.Anything, int64(1)).Return(user, nil).Once()
+				orderRepoMock.On("PlaceOrder", mock.Anything, int64(1)).Return(nil, internal.ErrEmptyCart).Once()
+			},
+			errCode: e.ErrPlaceOrder,
+		},
+		{
+			name:  "fail_insufficient_stock",
+			rbody: nil,
+			mock: func(orderRepoMock *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo) {
+				userRepoMock.On("IsUserActive", mock.Anything, int64(1)).Return(true, nil).Once()
+				userRepoMock.On("GetUserByID", mock.Anything, int64(1)).Return(user, nil).Once()
+				orderRepoMock.On("PlaceOrder", mock.Anything, int64(1)).
+					Return(nil, fmt.Errorf("%w: brand 3 (requested 2, available 1)", internal.ErrInsufficientStock)).Once()
+			},
+			errCode: e.ErrInsufficientStock,
+		},
+		{
+			name:  "fail_stock_update",
+			rbody: nil,
+			mock: func(orderRepoMock *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo) {
+				userRepoMock.On("IsUserActive", mock.Anything, int64(1)).Return(true, nil).Once()
+				userRepoMock.On("GetUserByID", mock.Anything, int64(1)).Return(user, nil).Once()
+				orderRepoMock.On("PlaceOrder", mock.Anything, int64(1)).
+					Return(nil, fmt.Errorf("%w: brand 3: timeout", internal.ErrStockUpdate)).Once()
+			},
+			errCode: e.ErrUpdateStock,
+		},
+		{
+			name:  "fail_transaction",
+			rbody: nil,
+			mock: func(orderRepoMock *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo) {
+				userRepoMock.On("IsUserActive", mock.Anything, int64(1)).Return(true, nil).Once()
+				userRepoMock.On("GetUserByID", mock.Anything, int64(1)).Return(user, nil).Once()
+				orderRepoMock.On("PlaceOrder", mock.Anything, int64(1)).Return(nil, errors.New("commit failed")).Once()
+			},
+			errCode: e.ErrTransactionError,
+		},
+		{
+			name:  "success_case",
+			rbody: []byte(`{}`),
+			mock: func(orderRepoMock *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo) {
+				userRepoMock.On("IsUserActive", mock.Anything, int64(1)).Return(true, nil).Once()
+				userRepoMock.On("GetUserByID", mock.Anything, int64(1)).Return(user, nil).Once()
+				orderRepoMock.On("PlaceOrder", mock.Anything, int64(1)).Return(&internal.Order{
+					ID:         11,
+					UserID:     1,
+					TotalPrice: 20.5,
+					Status:     internal.OrderStatusPlaced,
+					Items: []internal.Orderitem{
+						{OrderID: 11, BrandID: 3, CategoryID: 2, BrandName: "ACME", Price: 10.25, Quantity: 2},
+					},
+				}, nil).Once()
+			},
+			want: &dto.ItemOrderedResponse{
+				OrderID:    11,
+				TotalPrice: 20.5,
+				UserDetails: dto.UserDetailsResponse{
+					Username:    "testuser",
+					Address:     "123 Test St",
+					Pincode:     "123456",
+					PhoneNumber: "+919876543210",
+					Email:       "test@example.com",
+				},
+				Items: []dto.OrderItemResponse{
+					{ProductID: 3, Quantity: 2, CategoryID: 2, BrandName: "ACME", Price: 10.25},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderRepoMock := internalmocks.NewOrderRepo(t)
+			userRepoMock := internalmocks.NewUserRepo(t)
+			helperMock := new(helpermocks.ContextHelper)
+			helperMock.On("GetUserID", mock.Anything).Return(int64(1), nil).Maybe()
+
+			orderService := NewOrderService(orderRepoMock, userRepoMock, helperMock)
+			tt.mock(orderRepoMock, userRepoMock)
+
+			req := httptest.NewRequest("POST", "/orders", bytes.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			placed := testutil.ToFloat64(metrics.OrdersPlaced)
+			got, err := orderService.PlaceOrder(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+				assert.Equal(t, placed, testutil.ToFloat64(metrics.OrdersPlaced))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				assert.Equal(t, placed+1, testutil.ToFloat64(metrics.OrdersPlaced))
+			}
+		})
+	}
+}
+
+func TestGetOrderHistory(t *testing.T) {
+	placedAt := time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC)
+	orders := func(n int) []internal.Order {
+		out := make([]internal.Order, 0, n)
+		for i := 0; i < n; i++ {
+			out = append(out, internal.Order{
+				ID:         int64(10 - i),
+				UserID:     1,
+				TotalPrice: 20.5,
+				Status:     internal.OrderStatusPlaced,
+				CreatedAt:  placedAt.Add(-time.Duration(i) * time.Hour),
+				Items: []internal.Orderitem{
+					{BrandID: 3, CategoryID: 2, BrandName: "ACME", Price: 10.25, Quantity: 2},
+				},
+			})
+		}
+		return out
+	}
+	cursor := dto.EncodeOrderCursor(placedAt, 10)
+
+	tests := []struct {
+		name    string
+		query   string
+		mock    func(orderRepoMock *internalmocks.OrderRepo)
+		check   func(t *testing.T, got *dto.OrderHistoryResponse)
+		errCode int
+	}{
+		{
+			name:    "fail_invalid_from",
+			query:   "?from=yesterday",
+			mock:    func(_ *internalmocks.OrderRepo) {},
+			errCode: e.ErrInvalidRequest,
+		},
+		{
+			name:    "fail_invalid_cursor",
+			query:   "?cursor=not-a-cursor",
+			mock:    func(_ *internalmocks.OrderRepo) {},
+			errCode: e.ErrInvalidRequest,
+		},
+		{
+			name:    "fail_limit_too_large",
+			query:   "?limit=500",
+			mock:    func(_ *internalmocks.OrderRepo) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:    "fail_from_after_to",
+			query:   "?from=2024-02-01&to=2024-01-01",
+			mock:    func(_ *internalmocks.OrderRepo) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_get_history",
+			query: "",
+			mock: func(orderRepoMock *internalmocks.OrderRepo) {
+				orderRepoMock.On("GetOrderHistory", mock.Anything, mock.Anything).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrGetOrderHistory,
+		},
+		{
+			name:  "success_last_page",
+			query: "?from=2024-01-01&to=2024-01-31",
+			mock: func(orderRepoMock *internalmocks.OrderRepo) {
+				orderRepoMock.On("GetOrderHistory", mock.Anything, mock.MatchedBy(func(f internal.OrderHistoryFilter) bool {
+					// "to" as a plain date includes that whole day
+					return f.UserID == 1 && f.Limit == dto.DefaultOrderHistoryLimit+1 &&
+						f.From.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) &&
+						f.To.Equal(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)) &&
+						f.CursorCreatedAt == nil
+				})).Return(orders(1), nil).Once()
+			},
+			check: func(t *testing.T, got *dto.OrderHistoryResponse) {
+				require.Len(t, got.Orders, 1)
+				assert.Equal(t, "", got.NextCursor)
+				assert.Equal(t, dto.OrderHistoryItem{
+					OrderID:       10,
+					Status:        "placed",
+					TotalPrice:    20.5,
+					TotalQuantity: 2,
+					CreatedAt:     placedAt,
+					Items: []dto.OrderItemResponse{
+						{ProductID: 3, Quantity: 2, CategoryID: 2, BrandName: "ACME", Price: 10.25},
+					},
+				}, got.Orders[0])
+			},
+		},
+		{
+			name:  "success_has_next_page",
+			query: "?limit=2",
+			mock: func(orderRepoMock *internalmocks.OrderRepo) {
+				orderRepoMock.On("GetOrderHistory", mock.Anything, mock.MatchedBy(func(f internal.OrderHistoryFilter) bool {
+					return f.Limit == 3
+				})).Return(orders(3), nil).Once()
+			},
+			check: func(t *testing.T, got *dto.OrderHistoryResponse) {
+				require.Len(t, got.Orders, 2)
+				assert.Equal(t, dto.EncodeOrderCursor(placedAt.Add(-time.Hour), 9), got.NextCursor)
+			},
+		},
+		{
+			name:  "success_with_cursor",
+			query: "?cursor=" + cursor,
+			mock: func(orderRepoMock *internalmocks.OrderRepo) {
+				orderRepoMock.On("GetOrderHistory", mock.Anything, mock.MatchedBy(func(f internal.OrderHistoryFilter) bool {
+					return f.CursorCreatedAt != nil && f.CursorCreatedAt.Equal(placedAt) && f.CursorID == 10
+				})).Return(nil, nil).Once()
+			},
+			check: func(t *testing.T, got *dto.OrderHistoryResponse) {
+				assert.Empty(t, got.Orders)
+				assert.NotNil(t, got.Orders)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderRepoMock := internalmocks.NewOrderRepo(t)
+			userRepoMock := internalmocks.NewUserRepo(t)
+			helperMock := helpermocks.NewContextHelper(t)
+			helperMock.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+			userRepoMock.On("IsUserActive", mock.Anything, int64(1)).Return(true, nil).Once()
+			tt.mock(orderRepoMock)
+
+			orderService := NewOrderService(orderRepoMock, userRepoMock, helperMock)
+
+			got, err := orderService.GetOrderHistory(httptest.NewRequest("GET", "/orders"+tt.query, nil))
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, int64(1), got.UserID)
+				tt.check(t, got)
+			}
+		})
+	}
+}
+
+func TestGetCustomerOrderHistory(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  string
+		mock    func(orderRepoMock *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo)
+		errCode int
+	}{
+		{
+			name:    "fail_invalid_id",
+			userID:  "abc",
+			mock:    func(_ *internalmocks.OrderRepo, _ *internalmocks.UserRepo) {},
+			errCode: e.ErrInvalidRequest,
+		},
+		{
+			name:   "fail_user_not_found",
+			userID: "5",
+			mock: func(_ *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo) {
+				userRepoMock.On("GetUserByID", mock.Anything, int64(5)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrUserNotFound,
+		},
+		{
+			name:   "success_case",
+			userID: "5",
+			mock: func(orderRepoMock *internalmocks.OrderRepo, userRepoMock *internalmocks.UserRepo) {
+				userRepoMock.On("GetUserByID", mock.Anything, int64(5)).Return(&internal.Userdetail{ID: 5}, nil).Once()
+				orderRepoMock.On("GetOrderHistory", mock.Anything, mock.MatchedBy(func(f internal.OrderHistoryFilter) bool {
+					return f.UserID == 5
+				})).Return([]internal.Order{{ID: 3, UserID: 5, Status: internal.OrderStatusPlaced}}, nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orderRepoMock := internalmocks.NewOrderRepo(t)
+			userRepoMock := internalmocks.NewUserRepo(t)
+			tt.mock(orderRepoMock, userRepoMock)
+
+			orderService := NewOrderService(orderRepoMock, userRepoMock, helpermocks.NewContextHelper(t))
+
+			req := withURLParam(httptest.NewRequest("GET", "/admin/users/"+tt.userID+"/orders", nil), "id", tt.userID)
+			got, err := orderService.GetCustomerOrderHistory(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, int64(5), got.UserID)
+				require.Len(t, got.Orders, 1)
+				assert.Equal(t, int64(3), got.Orders[0].OrderID)
+			}
+		})
+	}
+}
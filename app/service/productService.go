@@ -0,0 +1,227 @@
+package service
+
+import (
+	"errors"
+	"net/http"
+	"sonartest_cart/app/dto"
+	"sonartest_cart/app/internal"
+	"sonartest_cart/pkg/e"
+	"sonartest_cart/pkg/tracing"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+)
+
+type ProductService interface {
+	CreateCategory(r *http.Request) (*dto.CreateProductResponds, error)
+	ListCategories(r *http.Request) ([]dto.CatagoryListResponse, error)
+	GetCategoryByID(r *http.Request) (*dto.CategoryDetailResponse, error)
+	GetCategoryByName(r *http.Request) (*dto.CategoryDetailResponse, error)
+	UpdateCategory(r *http.Request) (*dto.CatagoryListResponse, error)
+	UpdateBrand(r *http.Request) (*dto.BrandDetailResponse, error)
+}
+
+type productServiceImpl struct {
+	productRepo internal.ProductRepo
+}
+
+func NewProductService(productRepo internal.ProductRepo) ProductService {
+	return &productServiceImpl{
+		productRepo: productRepo,
+	}
+}
+
+func (s *productServiceImpl) CreateCategory(r *http.Request) (_ *dto.CreateProductResponds, err error) {
+	ctx, span := tracer.Start(r.Context(), "ProductService.CreateCategory")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.CreateCategoryDetailRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+	log.Ctx(ctx).Info().Msg("Successfully completed parsing and validation of request body")
+
+	category, err := s.productRepo.CreateCategory(ctx, args)
+	if err != nil {
+		return nil, e.NewError(e.ErrCreateProduct, "error while creating category", err)
+	}
+	log.Ctx(ctx).Info().Msgf("Successfully created category %s with id %d", category.CategoryName, category.ID)
+
+	resp := &dto.CreateProductResponds{
+		ProductID:   category.ID,
+		Category:    category.CategoryName,
+		Description: category.Description,
+		Brands:      make([]dto.BrandResponse, 0, len(category.Brands)),
+	}
+	for _, b := range category.Brands {
+		resp.Brands = append(resp.Brands, dto.BrandResponse{
+			BrandName:  b.BrandName,
+			Price:      b.Price,
+			StockCount: b.StockCount,
+			ImageLink:  b.ImageLink,
+		})
+	}
+	return resp, nil
+}
+
+func (s *productServiceImpl) ListCategories(r *http.Request) (_ []dto.CatagoryListResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "ProductService.ListCategories")
+	defer func() { tracing.End(span, err) }()
+
+	categories, err := s.productRepo.ListCategories(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrListProducts, "error while listing categories", err)
+	}
+
+	resp := make([]dto.CatagoryListResponse, 0, len(categories))
+	for _, c := range categories {
+		resp = append(resp, dto.CatagoryListResponse{
+			CatagoryID:   c.ID,
+			CatagoryName: c.CategoryName,
+			Description:  c.Description,
+		})
+	}
+	return resp, nil
+}
+
+func (s *productServiceImpl) GetCategoryByID(r *http.Request) (_ *dto.CategoryDetailResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "ProductService.GetCategoryByID")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.SearchByCatagoryIdRequest{}
+
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrInvalidRequest, err)
+	}
+
+	category, err := s.productRepo.GetCategoryByID(ctx, args.CatagoryId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrCategoryNotFound, "category not found", err)
+		}
+		return nil, e.NewError(e.ErrGetCategory, "error while getting category", err)
+	}
+
+	return categoryDetail(category), nil
+}
+
+func (s *productServiceImpl) GetCategoryByName(r *http.Request) (_ *dto.CategoryDetailResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "ProductService.GetCategoryByName")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.SearchProductByNameRequest{}
+
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrInvalidRequest, err)
+	}
+
+	category, err := s.productRepo.GetCategoryByName(ctx, args.CategoryName)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrCategoryNotFound, "category not found", err)
+		}
+		return nil, e.NewError(e.ErrGetCategory, "error while getting category", err)
+	}
+
+	return categoryDetail(category), nil
+}
+
+func (s *productServiceImpl) UpdateCategory(r *http.Request) (_ *dto.CatagoryListResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "ProductService.UpdateCategory")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.UpdateCategory{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	category, err := s.productRepo.UpdateCategory(ctx, args)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrCategoryNotFound, "category not found", err)
+		}
+		return nil, e.NewError(e.ErrUpdateCategory, "error while updating category", err)
+	}
+	log.Ctx(ctx).Info().Msgf("Successfully updated category %d", category.ID)
+
+	return &dto.CatagoryListResponse{
+		CatagoryID:   category.ID,
+		CatagoryName: category.CategoryName,
+		Description:  category.Description,
+	}, nil
+}
+
+func (s *productServiceImpl) UpdateBrand(r *http.Request) (_ *dto.BrandDetailResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "ProductService.UpdateBrand")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.UpdateBrand{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	brand, err := s.productRepo.UpdateBrand(ctx, args)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrBrandNotFound, "brand not found", err)
+		}
+		return nil, e.NewError(e.ErrUpdateBrand, "error while updating brand", err)
+	}
+	log.Ctx(ctx).Info().Msgf("Successfully updated brand %d", brand.ID)
+
+	return brandDetail(brand, ""), nil
+}
+
+func categoryDetail(category *internal.Category) *dto.CategoryDetailResponse {
+	resp := &dto.CategoryDetailResponse{
+		CategoryID:   category.ID,
+		CategoryName: category.CategoryName,
+		Description:  category.Description,
+		Brands:       make([]dto.BrandDetailResponse, 0, len(category.Brands)),
+	}
+	for i := range category.Brands {
+		resp.Brands = append(resp.Brands, *brandDetail(&category.Brands[i], category.CategoryName))
+	}
+	return resp
+}
+
+func brandDetail(brand *internal.Brand, categoryName string) *dto.BrandDetailResponse {
+	return &dto.BrandDetailResponse{
+		BrandName:    brand.BrandName,
+		BrandId:      brand.ID,
+		Price:        brand.Price,
+		StockCount:   brand.StockCount,
+		CategoryID:   brand.CategoryID,
+		CategoryName: categoryName,
+		ImageLink:    brand.ImageLink,
+	}
+}
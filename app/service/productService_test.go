@@ -0,0 +1,365 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"sonartest_cart/app/dto"
+	"sonartest_cart/app/internal"
+	internalmocks "sonartest_cart/app/internal/mocks"
+	"sonartest_cart/pkg/e"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestCreateCategory(t *testing.T) {
+	validBody := []byte(`{"categoryname": "phones", "description": "smart phones", "brands": [{"brandname": "ACME", "price": 10.5, "stockcount": 7, "imagelink": "http://img/acme"}]}`)
+
+	tests := []struct {
+		name    string
+		rbody   []byte
+		mock    func(productRepoMock *internalmocks.ProductRepo)
+		want    *dto.CreateProductResponds
+		errCode int
+	}{
+		{
+			name:    "fail_decode_request",
+			rbody:   []byte(`{"categoryname":`),
+			mock:    func(_ *internalmocks.ProductRepo) {},
+			errCode: e.ErrDecodeRequestBody,
+		},
+		{
+			name:    "fail_validate_no_brands",
+			rbody:   []byte(`{"categoryname": "phones", "brands": []}`),
+			mock:    func(_ *internalmocks.ProductRepo) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:    "fail_validate_nested_brand",
+			rbody:   []byte(`{"categoryname": "phones", "brands": [{"brandname": "ACME"}]}`),
+			mock:    func(_ *internalmocks.ProductRepo) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_create_error",
+			rbody: validBody,
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("CreateCategory", mock.Anything, mock.Anything).Return(nil, errors.New("duplicate key")).Once()
+			},
+			errCode: e.ErrCreateProduct,
+		},
+		{
+			name:  "success_case",
+			rbody: validBody,
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("CreateCategory", mock.Anything, mock.MatchedBy(func(args *dto.CreateCategoryDetailRequest) bool {
+					// names are upper-cased while parsing
+					return args.CategoryName == "PHONES" && len(args.Brands) == 1
+				})).Return(&internal.Category{
+					ID:           1,
+					CategoryName: "PHONES",
+					Description:  "smart phones",
+					Brands: []internal.Brand{
+						{ID: 10, CategoryID: 1, BrandName: "ACME", Price: 10.5, StockCount: 7, ImageLink: "http://img/acme"},
+					},
+				}, nil).Once()
+			},
+			want: &dto.CreateProductResponds{
+				ProductID:   1,
+				Category:    "PHONES",
+				Description: "smart phones",
+				Brands: []dto.BrandResponse{
+					{BrandName: "ACME", Price: 10.5, StockCount: 7, ImageLink: "http://img/acme"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			productRepoMock := internalmocks.NewProductRepo(t)
+			productService := NewProductService(productRepoMock)
+			tt.mock(productRepoMock)
+
+			req := httptest.NewRequest("POST", "/categories", bytes.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := productService.CreateCategory(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListCategories(t *testing.T) {
+	productRepoMock := internalmocks.NewProductRepo(t)
+	productService := NewProductService(productRepoMock)
+
+	productRepoMock.On("ListCategories", mock.Anything).Return([]internal.Category{
+		{ID: 1, CategoryName: "PHONES", Description: "smart phones"},
+	}, nil).Once()
+
+	got, err := productService.ListCategories(httptest.NewRequest("GET", "/categories", nil))
+	require.NoError(t, err)
+	assert.Equal(t, []dto.CatagoryListResponse{{CatagoryID: 1, CatagoryName: "PHONES", Description: "smart phones"}}, got)
+
+	productRepoMock.On("ListCategories", mock.Anything).Return(nil, errors.New("db error")).Once()
+
+	_, err = productService.ListCategories(httptest.NewRequest("GET", "/categories", nil))
+	require.Error(t, err)
+	assert.Equal(t, e.ErrListProducts, err.(*e.WrapError).ErrorCode)
+}
+
+func TestGetCategoryByID(t *testing.T) {
+	category := &internal.Category{
+		ID:           1,
+		CategoryName: "PHONES",
+		Brands: []internal.Brand{
+			{ID: 10, CategoryID: 1, BrandName: "ACME", Price: 10.5, StockCount: 7},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		mock    func(productRepoMock *internalmocks.ProductRepo)
+		want    *dto.CategoryDetailResponse
+		errCode int
+	}{
+		{
+			name:    "fail_invalid_id",
+			id:      "abc",
+			mock:    func(_ *internalmocks.ProductRepo) {},
+			errCode: e.ErrInvalidRequest,
+		},
+		{
+			name: "fail_not_found",
+			id:   "1",
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("GetCategoryByID", mock.Anything, int64(1)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrCategoryNotFound,
+		},
+		{
+			name: "fail_db_error",
+			id:   "1",
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("GetCategoryByID", mock.Anything, int64(1)).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrGetCategory,
+		},
+		{
+			name: "success_case",
+			id:   "1",
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("GetCategoryByID", mock.Anything, int64(1)).Return(category, nil).Once()
+			},
+			want: &dto.CategoryDetailResponse{
+				CategoryID:   1,
+				CategoryName: "PHONES",
+				Brands: []dto.BrandDetailResponse{
+					{BrandName: "ACME", BrandId: 10, Price: 10.5, StockCount: 7, CategoryID: 1, CategoryName: "PHONES"},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			productRepoMock := internalmocks.NewProductRepo(t)
+			productService := NewProductService(productRepoMock)
+			tt.mock(productRepoMock)
+
+			req := withURLParam(httptest.NewRequest("GET", "/categories/"+tt.id, nil), "id", tt.id)
+			got, err := productService.GetCategoryByID(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetCategoryByName(t *testing.T) {
+	productRepoMock := internalmocks.NewProductRepo(t)
+	productService := NewProductService(productRepoMock)
+
+	// the lookup is always done with the upper-cased name
+	productRepoMock.On("GetCategoryByName", mock.Anything, "PHONES").Return(&internal.Category{ID: 1, CategoryName: "PHONES"}, nil).Once()
+
+	req := withURLParam(httptest.NewRequest("GET", "/categories/name/phones", nil), "categoryname", "phones")
+	got, err := productService.GetCategoryByName(req)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.CategoryID)
+	assert.Empty(t, got.Brands)
+
+	productRepoMock.On("GetCategoryByName", mock.Anything, "TOYS").Return(nil, gorm.ErrRecordNotFound).Once()
+
+	req = withURLParam(httptest.NewRequest("GET", "/categories/name/toys", nil), "categoryname", "toys")
+	_, err = productService.GetCategoryByName(req)
+	require.Error(t, err)
+	assert.Equal(t, e.ErrCategoryNotFound, err.(*e.WrapError).ErrorCode)
+}
+
+func TestUpdateCategory(t *testing.T) {
+	tests := []struct {
+		name    string
+		rbody   []byte
+		mock    func(productRepoMock *internalmocks.ProductRepo)
+		want    *dto.CatagoryListResponse
+		errCode int
+	}{
+		{
+			name:    "fail_validate_request",
+			rbody:   []byte(`{"categoryname": ""}`),
+			mock:    func(_ *internalmocks.ProductRepo) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_not_found",
+			rbody: []byte(`{"categoryname": "tablets"}`),
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("UpdateCategory", mock.Anything, &dto.UpdateCategory{CategoryID: 1, CategoryName: "TABLETS"}).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrCategoryNotFound,
+		},
+		{
+			name:  "fail_update_error",
+			rbody: []byte(`{"categoryname": "tablets"}`),
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("UpdateCategory", mock.Anything, &dto.UpdateCategory{CategoryID: 1, CategoryName: "TABLETS"}).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrUpdateCategory,
+		},
+		{
+			name:  "success_path_id_wins",
+			rbody: []byte(`{"category_id": 99, "categoryname": "tablets"}`),
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("UpdateCategory", mock.Anything, &dto.UpdateCategory{CategoryID: 1, CategoryName: "TABLETS"}).
+					Return(&internal.Category{ID: 1, CategoryName: "TABLETS"}, nil).Once()
+			},
+			want: &dto.CatagoryListResponse{CatagoryID: 1, CatagoryName: "TABLETS"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			productRepoMock := internalmocks.NewProductRepo(t)
+			productService := NewProductService(productRepoMock)
+			tt.mock(productRepoMock)
+
+			req := httptest.NewRequest("PUT", "/categories/1", bytes.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+			req = withURLParam(req, "id", "1")
+
+			got, err := productService.UpdateCategory(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateBrand(t *testing.T) {
+	tests := []struct {
+		name    string
+		rbody   []byte
+		mock    func(productRepoMock *internalmocks.ProductRepo)
+		want    *dto.BrandDetailResponse
+		errCode int
+	}{
+		{
+			name:    "fail_nothing_to_update",
+			rbody:   []byte(`{}`),
+			mock:    func(_ *internalmocks.ProductRepo) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:    "fail_negative_price",
+			rbody:   []byte(`{"price": -1}`),
+			mock:    func(_ *internalmocks.ProductRepo) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "success_free_brand",
+			rbody: []byte(`{"price": 0}`),
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("UpdateBrand", mock.Anything, mock.MatchedBy(func(args *dto.UpdateBrand) bool {
+					return args.Price != nil && *args.Price == 0
+				})).Return(&internal.Brand{ID: 10, CategoryID: 1, BrandName: "ACME", Price: 0}, nil).Once()
+			},
+			want: &dto.BrandDetailResponse{BrandName: "ACME", BrandId: 10, Price: 0, CategoryID: 1},
+		},
+		{
+			name:  "fail_not_found",
+			rbody: []byte(`{"price": 12.5}`),
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("UpdateBrand", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrBrandNotFound,
+		},
+		{
+			name:  "fail_update_error",
+			rbody: []byte(`{"price": 12.5}`),
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("UpdateBrand", mock.Anything, mock.Anything).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrUpdateBrand,
+		},
+		{
+			name:  "success_case",
+			rbody: []byte(`{"price": 12.5, "stock_count": 0}`),
+			mock: func(productRepoMock *internalmocks.ProductRepo) {
+				productRepoMock.On("UpdateBrand", mock.Anything, mock.MatchedBy(func(args *dto.UpdateBrand) bool {
+					return args.BrandId == 10 && args.Price != nil && *args.Price == 12.5 && args.StockCount != nil && *args.StockCount == 0
+				})).Return(&internal.Brand{ID: 10, CategoryID: 1, BrandName: "ACME", Price: 12.5}, nil).Once()
+			},
+			want: &dto.BrandDetailResponse{BrandName: "ACME", BrandId: 10, Price: 12.5, CategoryID: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			productRepoMock := internalmocks.NewProductRepo(t)
+			productService := NewProductService(productRepoMock)
+			tt.mock(productRepoMock)
+
+			req := httptest.NewRequest("PUT", "/brands/10", bytes.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+			req = withURLParam(req, "id", "10")
+
+			got, err := productService.UpdateBrand(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
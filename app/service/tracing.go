@@ -0,0 +1,7 @@
+package service
+
+import "go.opentelemetry.io/otel"
+
+// tracer starts one span per service method, repository queries show up as
+// children through the gorm tracing plugin
+var tracer = otel.Tracer("sonartest_cart/app/service")
@@ -8,8 +8,18 @@ import (
 	"sonartest_cart/app/dto"
 	helper "sonartest_cart/app/helper"
 	"sonartest_cart/app/internal"
+	"sonartest_cart/pkg/config"
 	"sonartest_cart/pkg/e"
 	"sonartest_cart/pkg/jwt"
+	"sonartest_cart/pkg/mail"
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/middleware"
+	"sonartest_cart/pkg/password"
+	"sonartest_cart/pkg/ratelimit"
+	"sonartest_cart/pkg/rbac"
+	"sonartest_cart/pkg/tracing"
+	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"gorm.io/gorm"
@@ -18,50 +28,108 @@ import (
 type UserService interface {
 	SaveUserDetails(r *http.Request) (*dto.SaveUserResponse, error)
 	LoginUser(r *http.Request) (*dto.LoginResponse, error)
+	BlockUser(r *http.Request) (*dto.UserStatusResponse, error)
+	UnblockUser(r *http.Request) (*dto.UserStatusResponse, error)
+	ListUsers(r *http.Request) ([]dto.AllUserDetails, error)
+	UpdateUserDetails(r *http.Request) (*dto.AllUserDetails, error)
+	GetMyProfile(r *http.Request) (*dto.AllUserDetails, error)
+	RefreshToken(r *http.Request) (*dto.LoginResponse, error)
+	Logout(r *http.Request) (*dto.LogoutResponse, error)
+	AssignRole(r *http.Request) (*dto.UserRoleResponse, error)
+	ListRoles(r *http.Request) ([]dto.RoleDetails, error)
+	VerifyMail(r *http.Request) (*dto.VerifyMailResponse, error)
+	ResendVerificationMail(r *http.Request) (*dto.ResendVerificationResponse, error)
+	ForgotPassword(r *http.Request) (*dto.ForgotPasswordResponse, error)
+	ResetPassword(r *http.Request) (*dto.PasswordChangedResponse, error)
+	ChangePassword(r *http.Request) (*dto.PasswordChangedResponse, error)
 }
 
+// errLoginFailed is all a client learns about a failed login, the real
+// reason is only logged so usernames can't be enumerated
+var errLoginFailed = errors.New("invalid username or password")
+
+// resendAnswer is all a client learns from asking for a verification mail,
+// so it can't find out which usernames exist
+const resendAnswer = "if the account exists and its mail address is not verified yet, a new verification mail is on its way"
+
+// forgotAnswer is all a client learns from asking for a password reset
+const forgotAnswer = "if the account exists, a password reset mail is on its way"
+
 type userServiceImpl struct {
 	userRepo      internal.UserRepo
+	tokenRepo     internal.TokenRepo
+	roleRepo      internal.RoleRepo
+	auditRepo     internal.AuditRepo
 	contextHelper helper.ContextHelper
 	jwtService    jwt.JWTService
+	hasher        password.PasswordHasher
+	lockout       ratelimit.Lockout
+	policy        password.Policy
+	mail          MailOptions
+
+	// dummyHash is verified for unknown usernames so they take as long as wrong passwords
+	dummyOnce sync.Once
+	dummyHash string
 }
 
-func NewUserService(userRepo internal.UserRepo, ctxHelper helper.ContextHelper, jwtService jwt.JWTService) UserService {
+func NewUserService(userRepo internal.UserRepo, tokenRepo internal.TokenRepo, roleRepo internal.RoleRepo, auditRepo internal.AuditRepo, ctxHelper helper.ContextHelper, jwtService jwt.JWTService, hasher password.PasswordHasher, lockout ratelimit.Lockout, policy password.Policy, mailOpts MailOptions) UserService {
+	if mailOpts.Mailer == nil {
+		mailOpts.Mailer = mail.NopMailer{}
+	}
+	if mailOpts.Queue == nil {
+		mailOpts.Queue = NewMailQueue(DefaultMailWorkers, DefaultMailQueueSize)
+	}
 	return &userServiceImpl{
 		userRepo:      userRepo,
+		tokenRepo:     tokenRepo,
+		roleRepo:      roleRepo,
+		auditRepo:     auditRepo,
 		contextHelper: ctxHelper,
 		jwtService:    jwtService,
+		hasher:        hasher,
+		lockout:       lockout,
+		policy:        policy,
+		mail:          mailOpts,
 	}
 }
 
 func (s *userServiceImpl) getUserIDAndCheckStatus(ctx context.Context) (int64, error) {
-	userID, err := s.contextHelper.GetUserID(ctx)
+	return checkUserStatus(ctx, s.contextHelper, s.userRepo)
+}
+
+// checkUserStatus reads the logged in user from ctx and makes sure the user is
+// still active. Every service acting on behalf of a user goes through it.
+func checkUserStatus(ctx context.Context, contextHelper helper.ContextHelper, userRepo internal.UserRepo) (int64, error) {
+	userID, err := contextHelper.GetUserID(ctx)
 	if err != nil {
 		return 0, e.NewError(e.ErrContextError, "error while getting userId from ctx", err)
 	}
-	log.Info().Msgf("userId of the user logged in %d", userID)
+	log.Ctx(ctx).Info().Msgf("userId of the user logged in %d", userID)
 
-	isActive, err := s.userRepo.IsUserActive(userID)
+	isActive, err := userRepo.IsUserActive(ctx, userID)
 	if err != nil {
 		return 0, e.NewError(e.ErrGetUserDetails, "error while checking user details", err)
 	}
 
 	if !isActive {
-		log.Info().Msg("User is not active.")
+		log.Ctx(ctx).Info().Msg("User is not active.")
 		return 0, e.NewError(e.ErrUserBlocked, "user is blocked or inactive", nil)
 	}
-	log.Info().Msg("User is active")
+	log.Ctx(ctx).Info().Msg("User is active")
 
 	return userID, nil
 }
 
-func (s *userServiceImpl) SaveUserDetails(r *http.Request) (*dto.SaveUserResponse, error) {
+func (s *userServiceImpl) SaveUserDetails(r *http.Request) (_ *dto.SaveUserResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.SaveUserDetails")
+	defer func() { tracing.End(span, err) }()
+
 	args := &dto.UserDetailSaveRequest{}
 
 	// parsing the req.body
-	err := args.Parse(r)
+	err = args.Parse(r)
 	if err != nil {
-		return nil, e.NewError(e.ErrDecodeRequestBody, "error while parsing", err)
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
 	}
 
 	//validation
@@ -69,26 +137,48 @@ func (s *userServiceImpl) SaveUserDetails(r *http.Request) (*dto.SaveUserRespons
 	if err != nil {
 		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
 	}
-	log.Info().Msg("Successfully completed parsing and validation of request body")
+	err = s.policy.Check("password", args.Password, args.UserName)
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+	log.Ctx(ctx).Info().Msg("Successfully completed parsing and validation of request body")
 
-	userID, err := s.userRepo.SaveUserDetails(args)
+	// never store the plaintext password
+	hash, err := s.hasher.Hash(args.Password)
+	if err != nil {
+		return nil, e.NewError(e.ErrCreateUser, "error while hashing password", err)
+	}
+	args.Password = hash
+	// admins hand out every other role
+	args.Role = rbac.RoleCustomer
+
+	userID, err := s.userRepo.SaveUserDetails(ctx, args)
 	if err != nil {
 		return nil, e.NewError(e.ErrCreateUser, "error while creating user", err)
 	}
-	log.Info().Msgf("Successfully created user with id %d", userID)
+	log.Ctx(ctx).Info().Msgf("Successfully created user with id %d", userID)
+	metrics.Signups.Inc()
+
+	// the account exists either way, a lost mail can be sent again
+	if err := s.sendVerificationMail(ctx, userID, args.UserName, args.Mail); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("failed to send verification mail to user %d", userID)
+	}
 
 	return &dto.SaveUserResponse{
 		UserId: userID,
 	}, nil
 }
 
-func (s *userServiceImpl) LoginUser(r *http.Request) (*dto.LoginResponse, error) {
+func (s *userServiceImpl) LoginUser(r *http.Request) (_ *dto.LoginResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.LoginUser")
+	defer func() { tracing.End(span, err) }()
+
 	args := &dto.LoginRequest{}
 
 	// parsing the req.body
-	err := args.Parse(r)
+	err = args.Parse(r)
 	if err != nil {
-		return nil, e.NewError(e.ErrDecodeRequestBody, "error while parsing", err)
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
 	}
 
 	//validation
@@ -96,49 +186,882 @@ func (s *userServiceImpl) LoginUser(r *http.Request) (*dto.LoginResponse, error)
 	if err != nil {
 		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
 	}
-	log.Info().Msg("Successfully completed parsing and validation of request body")
+	log.Ctx(ctx).Info().Msg("Successfully completed parsing and validation of request body")
+
+	lockedFor, err := s.lockout.LockedFor(ctx, args.Username)
+	if err != nil {
+		// don't lock everybody out because the lockout store is down
+		log.Ctx(ctx).Error().Err(err).Msg("failed to check account lockout")
+	}
+	if lockedFor > 0 {
+		metrics.Login(metrics.LoginLocked)
+		err := fmt.Errorf("account locked, try again in %s", lockedFor.Round(time.Second))
+		return nil, e.NewError(e.ErrAccountLocked, "too many failed login attempts", err)
+	}
 
 	// Fetching user from database
-	user, err := s.userRepo.GetUserByUsername(args.Username)
+	user, err := s.userRepo.GetUserByUsername(ctx, args.Username)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+			s.verifyDummyPassword(args.Password)
+			return nil, s.loginFailed(ctx, args.Username, "unknown username")
 		}
+		metrics.Login(metrics.LoginError)
 		return nil, e.NewError(e.ErrLoginUser, "error during login", err)
 	}
 
 	// Check if user is nil
 	if user == nil {
-		return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		s.verifyDummyPassword(args.Password)
+		return nil, s.loginFailed(ctx, args.Username, "unknown username")
 	}
 
-	if user.IsAdmin {
-		log.Info().Msg("the user is an admin")
-	} else {
-		log.Info().Msg("the user is a regular user")
+	// Validate password (constant time, works for hashed and legacy plaintext rows)
+	match, err := s.hasher.Verify(user.Password, args.Password)
+	if err != nil {
+		metrics.Login(metrics.LoginError)
+		return nil, e.NewError(e.ErrLoginUser, "error during login", err)
 	}
-
-	// Validate password
-	if user.Password != args.Password {
-		err := fmt.Errorf("invalid password for user %s", user.Username)
-		return nil, e.NewError(e.ErrInvalidCredentials, "invalid password", err)
+	if !match {
+		return nil, s.loginFailed(ctx, args.Username, "wrong password")
+	}
+	if err := s.lockout.Reset(ctx, args.Username); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to reset failed login attempts")
 	}
 
 	// Check if user is active
+	if !user.Status {
+		metrics.Login(metrics.LoginBlocked)
+		err := fmt.Errorf("user %s is blocked", user.Username)
+		return nil, e.NewError(e.ErrUserBlocked, "user is blocked", err)
+	}
+	if user.MailVerifiedAt == nil && s.mail.UnverifiedLogin == config.UnverifiedBlock {
+		metrics.Login(metrics.LoginUnverified)
+		err := fmt.Errorf("user %s has not verified their mail address", user.Username)
+		return nil, e.NewError(e.ErrMailNotVerified, "mail address is not verified", err)
+	}
+
+	// Upgrade legacy plaintext rows and outdated hashes now that we know the password
+	if s.hasher.NeedsRehash(user.Password) {
+		s.rehashPassword(ctx, user.ID, args.Password)
+	}
+
+	// the token carries the permissions of the user's role as they are now
+	token, err := s.accessToken(ctx, user)
+	if err != nil {
+		metrics.Login(metrics.LoginError)
+		return nil, e.NewError(e.ErrGenerateToken, "failed to generate token", err)
+	}
+	log.Ctx(ctx).Info().Msgf("Generated token for user %s (role %s)", user.Username, user.Role)
+
+	// every login starts a new refresh token family
+	refreshToken, err := s.issueRefreshToken(ctx, user.ID)
+	if err != nil {
+		metrics.Login(metrics.LoginError)
+		return nil, e.NewError(e.ErrGenerateToken, "failed to generate refresh token", err)
+	}
+	metrics.Login(metrics.LoginSuccess)
+
+	return &dto.LoginResponse{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.jwtService.AccessTokenTTL().Seconds()),
+	}, nil
+}
+
+// loginFailed counts a failed attempt towards the lockout and returns the
+// one error every credential failure gets
+func (s *userServiceImpl) loginFailed(ctx context.Context, username, reason string) error {
+	metrics.Login(metrics.LoginInvalidCredentials)
+	log.Ctx(ctx).Warn().Str("username", username).Str("reason", reason).Msg("Login failed")
+
+	lockedFor, err := s.lockout.Fail(ctx, username)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to record failed login attempt")
+	}
+	if lockedFor > 0 {
+		metrics.AccountLockouts.Inc()
+		log.Ctx(ctx).Warn().Str("username", username).Dur("locked_for", lockedFor).Msg("Account locked after too many failed logins")
+	}
+	return e.NewError(e.ErrLoginFailed, "invalid username or password", errLoginFailed)
+}
+
+// verifyDummyPassword spends the time of a real password check
+func (s *userServiceImpl) verifyDummyPassword(plain string) {
+	s.dummyOnce.Do(func() {
+		hash, err := s.hasher.Hash("dummy password for unknown users")
+		if err == nil {
+			s.dummyHash = hash
+		}
+	})
+	if s.dummyHash != "" {
+		_, _ = s.hasher.Verify(s.dummyHash, plain)
+	}
+}
+
+// accessToken signs a token with the role of the user and its permissions
+func (s *userServiceImpl) accessToken(ctx context.Context, user *internal.Userdetail) (string, error) {
+	role, err := s.roleRepo.GetRole(ctx, user.Role)
+	if err != nil {
+		return "", fmt.Errorf("role %q of user %d: %w", user.Role, user.ID, err)
+	}
+	return s.jwtService.GenerateToken(user.ID, user.Username, role.Name, role.PermissionNames())
+}
+
+func (s *userServiceImpl) issueRefreshToken(ctx context.Context, userID int64) (string, error) {
+	familyID, err := jwt.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+	refreshToken, err := jwt.NewRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.tokenRepo.CreateRefreshToken(ctx, &internal.Refreshtoken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: jwt.HashRefreshToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.jwtService.RefreshTokenTTL()),
+	})
+	if err != nil {
+		return "", err
+	}
+	return refreshToken, nil
+}
+
+// RefreshToken swaps a refresh token for a new access and refresh token.
+// Refresh tokens are single use, presenting one twice revokes its family.
+func (s *userServiceImpl) RefreshToken(r *http.Request) (_ *dto.LoginResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.RefreshToken")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.RefreshTokenRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	newRefreshToken, err := jwt.NewRefreshToken()
+	if err != nil {
+		return nil, e.NewError(e.ErrGenerateToken, "failed to generate refresh token", err)
+	}
+
+	rotated, err := s.tokenRepo.RotateRefreshToken(
+		ctx,
+		jwt.HashRefreshToken(args.RefreshToken),
+		jwt.HashRefreshToken(newRefreshToken),
+		time.Now().Add(s.jwtService.RefreshTokenTTL()),
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, internal.ErrRefreshTokenReused):
+			log.Ctx(ctx).Warn().Msg("Refresh token reuse detected, token family revoked")
+			return nil, e.NewError(e.ErrRefreshTokenReused, "refresh token was already used", err)
+		case errors.Is(err, internal.ErrRefreshTokenInvalid):
+			return nil, e.NewError(e.ErrInvalidRefreshToken, "invalid refresh token", err)
+		default:
+			return nil, e.NewError(e.ErrGenerateToken, "failed to rotate refresh token", err)
+		}
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, rotated.UserID)
+	if err != nil {
+		return nil, e.NewError(e.ErrGetUserDetails, "error while getting user details", err)
+	}
 	if !user.Status {
 		err := fmt.Errorf("user %s is blocked", user.Username)
 		return nil, e.NewError(e.ErrUserBlocked, "user is blocked", err)
 	}
 
-	// Generating JWT Token with isAdmin from database
-	// token, err := jwt.NewJWTService().GenerateToken(user.ID, user.Username, user.IsAdmin)
-	token, err := s.jwtService.GenerateToken(user.ID, user.Username, user.IsAdmin)
+	token, err := s.accessToken(ctx, user)
 	if err != nil {
 		return nil, e.NewError(e.ErrGenerateToken, "failed to generate token", err)
 	}
-	log.Info().Msgf("Generated token for user %s (Admin: %v)", user.Username, user.IsAdmin)
+	log.Ctx(ctx).Info().Msgf("Refreshed token for user %d", user.ID)
 
 	return &dto.LoginResponse{
-		Token: token,
+		Token:        token,
+		RefreshToken: newRefreshToken,
+		ExpiresIn:    int64(s.jwtService.AccessTokenTTL().Seconds()),
 	}, nil
 }
+
+// Logout revokes the access token used for the request and, when given, the refresh token family
+func (s *userServiceImpl) Logout(r *http.Request) (_ *dto.LogoutResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.Logout")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.LogoutRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	userID, err := s.contextHelper.GetUserID(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrContextError, "error while getting userId from ctx", err)
+	}
+	jti, err := s.contextHelper.GetTokenID(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrContextError, "error while getting token id from ctx", err)
+	}
+	expiresAt, err := s.contextHelper.GetTokenExpiry(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrContextError, "error while getting token expiry from ctx", err)
+	}
+
+	err = s.tokenRepo.RevokeAccessToken(ctx, jti, userID, expiresAt)
+	if err != nil {
+		return nil, e.NewError(e.ErrLogout, "error while revoking access token", err)
+	}
+
+	if args.RefreshToken != "" {
+		err = s.tokenRepo.RevokeRefreshTokenFamily(ctx, jwt.HashRefreshToken(args.RefreshToken), userID)
+		if err != nil {
+			return nil, e.NewError(e.ErrLogout, "error while revoking refresh token", err)
+		}
+	}
+	log.Ctx(ctx).Info().Msgf("User %d logged out", userID)
+
+	return &dto.LogoutResponse{
+		LoggedOut: true,
+	}, nil
+}
+
+// rehashPassword stores a fresh hash for the user. Failures are only logged,
+// the login itself already succeeded.
+func (s *userServiceImpl) rehashPassword(ctx context.Context, userID int64, plain string) {
+	hash, err := s.hasher.Hash(plain)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("failed to rehash password for user %d", userID)
+		return
+	}
+	if err := s.userRepo.UpdatePassword(ctx, userID, hash); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("failed to store rehashed password for user %d", userID)
+		return
+	}
+	log.Ctx(ctx).Info().Msgf("Rehashed password for user %d", userID)
+}
+
+func (s *userServiceImpl) BlockUser(r *http.Request) (_ *dto.UserStatusResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.BlockUser")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.BlockUserRequest{}
+
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrInvalidRequest, err)
+	}
+
+	// an admin locking themselves out would leave nobody to undo it
+	adminID, err := s.contextHelper.GetUserID(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrContextError, "error while getting userId from ctx", err)
+	}
+	if adminID == args.UserID {
+		return nil, e.NewError(e.ErrBlockUser, "admin cannot block themselves", fmt.Errorf("user %d tried to block themselves", adminID))
+	}
+
+	_, err = s.checkOutranks(ctx, args.UserID, e.ErrBlockUser)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.userRepo.UpdateUserStatus(ctx, args.UserID, false)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		return nil, e.NewError(e.ErrBlockUser, "error while blocking user", err)
+	}
+	log.Ctx(ctx).Info().Msgf("User %d blocked by admin %d", args.UserID, adminID)
+
+	return &dto.UserStatusResponse{
+		UserID: args.UserID,
+		Status: false,
+	}, nil
+}
+
+func (s *userServiceImpl) UnblockUser(r *http.Request) (_ *dto.UserStatusResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.UnblockUser")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.BlockUserRequest{}
+
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrInvalidRequest, err)
+	}
+
+	_, err = s.checkOutranks(ctx, args.UserID, e.ErrUnblockUser)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.userRepo.UpdateUserStatus(ctx, args.UserID, true)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		return nil, e.NewError(e.ErrUnblockUser, "error while unblocking user", err)
+	}
+	log.Ctx(ctx).Info().Msgf("User %d unblocked", args.UserID)
+
+	return &dto.UserStatusResponse{
+		UserID: args.UserID,
+		Status: true,
+	}, nil
+}
+
+// checkOutranks makes sure the caller holds every permission of the target
+// user's role, otherwise support could block, unblock or edit an admin. It
+// returns the target user, errCode is used when the lookup itself fails.
+func (s *userServiceImpl) checkOutranks(ctx context.Context, targetID int64, errCode int) (*internal.Userdetail, error) {
+	granted, err := s.contextHelper.GetPermissions(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrContextError, "error while getting permissions from ctx", err)
+	}
+
+	target, err := s.userRepo.GetUserByID(ctx, targetID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		return nil, e.NewError(errCode, "error while getting user", err)
+	}
+
+	role, err := s.roleRepo.GetRole(ctx, target.Role)
+	if err != nil {
+		// a role that no longer exists grants nothing
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return target, nil
+		}
+		return nil, e.NewError(errCode, "error while getting role", err)
+	}
+	for _, permission := range role.PermissionNames() {
+		if !rbac.Has(granted, permission) {
+			err := fmt.Errorf("role %s of user %d grants %s", role.Name, targetID, permission)
+			return nil, e.NewError(e.ErrForbidden, "user has permissions you lack", err)
+		}
+	}
+	return target, nil
+}
+
+func (s *userServiceImpl) ListUsers(r *http.Request) (_ []dto.AllUserDetails, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.ListUsers")
+	defer func() { tracing.End(span, err) }()
+
+	users, err := s.userRepo.ListUsers(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrGetUserDetails, "error while listing users", err)
+	}
+
+	resp := make([]dto.AllUserDetails, 0, len(users))
+	for i := range users {
+		resp = append(resp, userDetails(&users[i]))
+	}
+	return resp, nil
+}
+
+func (s *userServiceImpl) UpdateUserDetails(r *http.Request) (_ *dto.AllUserDetails, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.UpdateUserDetails")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.UpdateUserDetailRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	// renaming or re-mailing a user is as good as taking over their account
+	before, err := s.checkOutranks(ctx, args.UserID, e.ErrUpdateUserProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.userRepo.UpdateUserDetails(ctx, args)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return nil, e.NewError(e.ErrUsernameTaken, "username is already taken", err)
+		}
+		return nil, e.NewError(e.ErrUpdateUserProfile, "error while updating user details", err)
+	}
+	log.Ctx(ctx).Info().Msgf("Updated details of user %d", args.UserID)
+
+	user, err := s.userRepo.GetUserByID(ctx, args.UserID)
+	if err != nil {
+		return nil, e.NewError(e.ErrGetUserDetails, "error while getting user details", err)
+	}
+
+	// the owner has to confirm the new address, the old verification is gone
+	if user.Mail != before.Mail {
+		s.mailInBackground(ctx, func(ctx context.Context) error {
+			err := s.tokenRepo.RevokeMailTokens(ctx, user.ID, internal.MailTokenVerify)
+			if err != nil {
+				return fmt.Errorf("revoking verification tokens of user %d: %w", user.ID, err)
+			}
+			if err := s.sendVerificationMail(ctx, user.ID, user.Username, user.Mail); err != nil {
+				return fmt.Errorf("sending verification mail to user %d: %w", user.ID, err)
+			}
+			log.Ctx(ctx).Info().Msgf("Sent a verification mail for the new address of user %d", user.ID)
+			return nil
+		})
+	}
+
+	resp := userDetails(user)
+	return &resp, nil
+}
+
+// GetMyProfile returns the profile of the logged in user
+func (s *userServiceImpl) GetMyProfile(r *http.Request) (_ *dto.AllUserDetails, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.GetMyProfile")
+	defer func() { tracing.End(span, err) }()
+
+	userID, err := s.contextHelper.GetUserID(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrContextError, "error while getting userId from ctx", err)
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		return nil, e.NewError(e.ErrGetUserDetails, "error while getting user details", err)
+	}
+
+	resp := userDetails(user)
+	return &resp, nil
+}
+
+// userDetails maps a user row to its public view, the password never leaves the service
+func userDetails(user *internal.Userdetail) dto.AllUserDetails {
+	return dto.AllUserDetails{
+		UserID:       user.ID,
+		UserName:     user.Username,
+		Mail:         user.Mail,
+		MailVerified: user.MailVerifiedAt != nil,
+		Address:      user.Address,
+		Country:      user.Country,
+		Pincode:      user.Pincode,
+		Phone:        user.Phonenumber,
+		Status:       user.Status,
+		Role:         user.Role,
+	}
+}
+
+// AssignRole gives a user another role. Their refresh tokens are revoked so
+// the new permissions apply once the current access token expires.
+func (s *userServiceImpl) AssignRole(r *http.Request) (_ *dto.UserRoleResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.AssignRole")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.AssignRoleRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrInvalidRequest, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	// an admin demoting themselves could leave nobody to grant roles
+	adminID, err := s.contextHelper.GetUserID(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrContextError, "error while getting userId from ctx", err)
+	}
+	if adminID == args.UserID {
+		return nil, e.NewError(e.ErrAssignRole, "admin cannot change their own role", fmt.Errorf("user %d tried to change their own role", adminID))
+	}
+
+	role, err := s.roleRepo.GetRole(ctx, args.Role)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrRoleNotFound, "role not found", err)
+		}
+		return nil, e.NewError(e.ErrAssignRole, "error while getting role", err)
+	}
+
+	err = s.userRepo.UpdateUserRole(ctx, args.UserID, role.Name)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		return nil, e.NewError(e.ErrAssignRole, "error while assigning role", err)
+	}
+
+	err = s.tokenRepo.RevokeUserRefreshTokens(ctx, args.UserID)
+	if err != nil {
+		return nil, e.NewError(e.ErrAssignRole, "error while revoking refresh tokens", err)
+	}
+	log.Ctx(ctx).Info().Msgf("User %d given role %s by admin %d", args.UserID, role.Name, adminID)
+
+	return &dto.UserRoleResponse{
+		UserID:      args.UserID,
+		Role:        role.Name,
+		Permissions: role.PermissionNames(),
+	}, nil
+}
+
+// ListRoles returns every role with the permissions it grants
+func (s *userServiceImpl) ListRoles(r *http.Request) (_ []dto.RoleDetails, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.ListRoles")
+	defer func() { tracing.End(span, err) }()
+
+	roles, err := s.roleRepo.ListRoles(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrListRoles, "error while listing roles", err)
+	}
+
+	resp := make([]dto.RoleDetails, len(roles))
+	for i, role := range roles {
+		resp[i] = dto.RoleDetails{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.PermissionNames(),
+		}
+	}
+	return resp, nil
+}
+
+// VerifyMail redeems the token from a verification mail
+func (s *userServiceImpl) VerifyMail(r *http.Request) (_ *dto.VerifyMailResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.VerifyMail")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.VerifyMailRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	token, err := s.tokenRepo.UseMailToken(ctx, internal.MailTokenVerify, jwt.HashRefreshToken(args.Token))
+	if err != nil {
+		if errors.Is(err, internal.ErrMailTokenInvalid) {
+			return nil, e.NewError(e.ErrInvalidMailToken, "invalid verification token", err)
+		}
+		return nil, e.NewError(e.ErrVerifyMail, "error while redeeming verification token", err)
+	}
+
+	err = s.userRepo.MarkMailVerified(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		return nil, e.NewError(e.ErrVerifyMail, "error while verifying mail address", err)
+	}
+	log.Ctx(ctx).Info().Msgf("User %d verified their mail address", token.UserID)
+
+	return &dto.VerifyMailResponse{
+		UserID:       token.UserID,
+		MailVerified: true,
+	}, nil
+}
+
+// ResendVerificationMail sends a new verification mail, the links in older
+// ones stop working. The answer never tells whether the user exists.
+func (s *userServiceImpl) ResendVerificationMail(r *http.Request) (_ *dto.ResendVerificationResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.ResendVerificationMail")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.ResendVerificationRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+	resp := &dto.ResendVerificationResponse{Message: resendAnswer}
+
+	user, err := s.userRepo.GetUserByUsername(ctx, args.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Ctx(ctx).Info().Str("username", args.Username).Msg("Verification mail requested for unknown user")
+			return resp, nil
+		}
+		return nil, e.NewError(e.ErrVerifyMail, "error while getting user details", err)
+	}
+	if user.MailVerifiedAt != nil || !user.Status {
+		log.Ctx(ctx).Info().Msgf("No verification mail for user %d, already verified or blocked", user.ID)
+		return resp, nil
+	}
+
+	// the answer must not take longer for a user who gets a mail
+	s.mailInBackground(ctx, func(ctx context.Context) error {
+		err := s.tokenRepo.RevokeMailTokens(ctx, user.ID, internal.MailTokenVerify)
+		if err != nil {
+			return fmt.Errorf("revoking verification tokens of user %d: %w", user.ID, err)
+		}
+		if err := s.sendVerificationMail(ctx, user.ID, user.Username, user.Mail); err != nil {
+			return fmt.Errorf("sending verification mail to user %d: %w", user.ID, err)
+		}
+		log.Ctx(ctx).Info().Msgf("Sent a new verification mail to user %d", user.ID)
+		return nil
+	})
+
+	return resp, nil
+}
+
+// ForgotPassword mails a link to reset the password, the links in older
+// ones stop working. The answer never tells whether the user exists.
+func (s *userServiceImpl) ForgotPassword(r *http.Request) (_ *dto.ForgotPasswordResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.ForgotPassword")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.ForgotPasswordRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+	resp := &dto.ForgotPasswordResponse{Message: forgotAnswer}
+
+	user, err := s.userRepo.GetUserByUsername(ctx, args.Username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Ctx(ctx).Info().Str("username", args.Username).Msg("Password reset requested for unknown user")
+			return resp, nil
+		}
+		return nil, e.NewError(e.ErrResetPassword, "error while getting user details", err)
+	}
+	if !user.Status {
+		log.Ctx(ctx).Info().Msgf("No password reset mail for blocked user %d", user.ID)
+		return resp, nil
+	}
+
+	// the answer must not take longer for a known user, or it tells who has an account
+	s.mailInBackground(ctx, func(ctx context.Context) error {
+		err := s.tokenRepo.RevokeMailTokens(ctx, user.ID, internal.MailTokenResetPassword)
+		if err != nil {
+			return fmt.Errorf("revoking reset tokens of user %d: %w", user.ID, err)
+		}
+		if err := s.sendResetMail(ctx, user.ID, user.Username, user.Mail); err != nil {
+			return fmt.Errorf("sending password reset mail to user %d: %w", user.ID, err)
+		}
+		log.Ctx(ctx).Info().Msgf("Sent a password reset mail to user %d", user.ID)
+		return nil
+	})
+
+	return resp, nil
+}
+
+// ResetPassword redeems the token from a reset mail and sets the new password
+func (s *userServiceImpl) ResetPassword(r *http.Request) (_ *dto.PasswordChangedResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.ResetPassword")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.ResetPasswordRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+	tokenHash := jwt.HashRefreshToken(args.Token)
+
+	// the token is only looked up here, a rejected password must leave it usable
+	token, err := s.tokenRepo.FindMailToken(ctx, internal.MailTokenResetPassword, tokenHash)
+	if err != nil {
+		if errors.Is(err, internal.ErrMailTokenInvalid) {
+			return nil, e.NewError(e.ErrInvalidMailToken, "invalid reset token", err)
+		}
+		return nil, e.NewError(e.ErrResetPassword, "error while getting reset token", err)
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		return nil, e.NewError(e.ErrResetPassword, "error while getting user details", err)
+	}
+	if !user.Status {
+		err := fmt.Errorf("user %s is blocked", user.Username)
+		return nil, e.NewError(e.ErrUserBlocked, "user is blocked", err)
+	}
+	err = s.policy.Check("password", args.Password, user.Username)
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	// redeeming still fails if a concurrent reset used the token in the meantime
+	_, err = s.tokenRepo.UseMailToken(ctx, internal.MailTokenResetPassword, tokenHash)
+	if err != nil {
+		if errors.Is(err, internal.ErrMailTokenInvalid) {
+			return nil, e.NewError(e.ErrInvalidMailToken, "invalid reset token", err)
+		}
+		return nil, e.NewError(e.ErrResetPassword, "error while redeeming reset token", err)
+	}
+
+	err = s.setPassword(ctx, r, user.ID, args.Password, internal.AuditPasswordReset)
+	if err != nil {
+		return nil, e.NewError(e.ErrResetPassword, "error while resetting password", err)
+	}
+	// a failed login streak was most likely the reason for the reset
+	if err := s.lockout.Reset(ctx, user.Username); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to reset failed login attempts")
+	}
+	log.Ctx(ctx).Info().Msgf("User %d reset their password", user.ID)
+
+	return &dto.PasswordChangedResponse{
+		UserID:          user.ID,
+		SessionsRevoked: true,
+	}, nil
+}
+
+// ChangePassword sets a new password for the logged in user. A wrong current
+// password counts towards the login lockout like a failed login.
+func (s *userServiceImpl) ChangePassword(r *http.Request) (_ *dto.PasswordChangedResponse, err error) {
+	ctx, span := tracer.Start(r.Context(), "UserService.ChangePassword")
+	defer func() { tracing.End(span, err) }()
+
+	args := &dto.ChangePasswordRequest{}
+
+	// parsing the req.body
+	err = args.Parse(r)
+	if err != nil {
+		return nil, e.NewParseError(e.ErrDecodeRequestBody, err)
+	}
+
+	//validation
+	err = args.Validate()
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	userID, err := s.contextHelper.GetUserID(ctx)
+	if err != nil {
+		return nil, e.NewError(e.ErrContextError, "error while getting userId from ctx", err)
+	}
+	user, err := s.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, e.NewError(e.ErrUserNotFound, "user not found", err)
+		}
+		return nil, e.NewError(e.ErrChangePassword, "error while getting user details", err)
+	}
+
+	lockedFor, err := s.lockout.LockedFor(ctx, user.Username)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("failed to check account lockout")
+	}
+	if lockedFor > 0 {
+		err := fmt.Errorf("account locked, try again in %s", lockedFor.Round(time.Second))
+		return nil, e.NewError(e.ErrAccountLocked, "too many failed login attempts", err)
+	}
+
+	match, err := s.hasher.Verify(user.Password, args.OldPassword)
+	if err != nil {
+		return nil, e.NewError(e.ErrChangePassword, "error while checking password", err)
+	}
+	if !match {
+		log.Ctx(ctx).Warn().Msgf("User %d gave a wrong current password", user.ID)
+		if _, err := s.lockout.Fail(ctx, user.Username); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("failed to record failed login attempt")
+		}
+		return nil, e.NewError(e.ErrWrongPassword, "current password is wrong", errors.New("current password is wrong"))
+	}
+
+	err = s.policy.Check("new_password", args.NewPassword, user.Username)
+	if err != nil {
+		return nil, e.NewError(e.ErrValidateRequest, "error while validating", err)
+	}
+
+	err = s.setPassword(ctx, r, user.ID, args.NewPassword, internal.AuditPasswordChanged)
+	if err != nil {
+		return nil, e.NewError(e.ErrChangePassword, "error while changing password", err)
+	}
+	// a reset link sent before is no longer wanted
+	if err := s.tokenRepo.RevokeMailTokens(ctx, user.ID, internal.MailTokenResetPassword); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("failed to revoke reset tokens of user %d", user.ID)
+	}
+	log.Ctx(ctx).Info().Msgf("User %d changed their password", user.ID)
+
+	return &dto.PasswordChangedResponse{
+		UserID:          user.ID,
+		SessionsRevoked: true,
+	}, nil
+}
+
+// setPassword stores a new password and ends every session of the user:
+// refresh tokens are revoked and access tokens issued before are rejected by
+// the auth middleware. The change is written to the audit log.
+func (s *userServiceImpl) setPassword(ctx context.Context, r *http.Request, userID int64, plain, action string) error {
+	hash, err := s.hasher.Hash(plain)
+	if err != nil {
+		return err
+	}
+	if err := s.userRepo.ChangePassword(ctx, userID, hash); err != nil {
+		return err
+	}
+	if err := s.tokenRepo.RevokeUserRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+
+	// the password is changed already, a missing audit entry must not hide that
+	err = s.auditRepo.Record(ctx, &internal.Auditlog{
+		UserID:  userID,
+		ActorID: userID,
+		Action:  action,
+		IP:      middleware.KeyByIP(r),
+	})
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msgf("failed to write audit entry %s for user %d", action, userID)
+	}
+	return nil
+}
@@ -11,11 +11,24 @@ import (
 	helpermocks "sonartest_cart/app/helper/mocks"
 	"sonartest_cart/app/internal"
 	internalmocks "sonartest_cart/app/internal/mocks"
+	"sonartest_cart/pkg/config"
 	"sonartest_cart/pkg/e"
+	"strings"
 
+	"sonartest_cart/pkg/jwt"
 	jwtmocks "sonartest_cart/pkg/jwt/mocks"
+	"sonartest_cart/pkg/mail"
+	mailmocks "sonartest_cart/pkg/mail/mocks"
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/password"
+	passwordmocks "sonartest_cart/pkg/password/mocks"
+	"sonartest_cart/pkg/ratelimit"
+	ratelimitmocks "sonartest_cart/pkg/ratelimit/mocks"
+	"sonartest_cart/pkg/rbac"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -23,14 +36,16 @@ import (
 )
 
 func TestSaveUserDetails(t *testing.T) {
-	validBody := []byte(`{"UserName": "testuser", "password": "password123", "mail": "test@example.com", "address": "123 Test St", "pincode": 123456, "phonenumber": 9876543210}`)
+	validBody := []byte(`{"UserName": "testuser", "password": "password123", "mail": "test@example.com", "address": "123 Test St", "pincode": "123456", "phonenumber": "+919876543210"}`)
 
 	tests := []struct {
 		name        string
 		rbody       []byte
 		parseErr    error
 		validateErr error
+		hashErr     error
 		saveErr     error
+		mailErr     error
 		userID      int64
 		want        *dto.SaveUserResponse
 		wantErr     bool
@@ -43,6 +58,15 @@ func TestSaveUserDetails(t *testing.T) {
 			want:    &dto.SaveUserResponse{UserId: 101},
 			wantErr: false,
 		},
+		{
+			// the user can ask for the mail again, the signup stands
+			name:    "success_mail_fails",
+			rbody:   validBody,
+			userID:  102,
+			mailErr: errors.New("smtp down"),
+			want:    &dto.SaveUserResponse{UserId: 102},
+			wantErr: false,
+		},
 		{
 			name:     "fail_parse_error",
 			rbody:    []byte(`invalid-json`),
@@ -57,6 +81,25 @@ func TestSaveUserDetails(t *testing.T) {
 			wantErr:     true,
 			errCode:     e.ErrValidateRequest,
 		},
+		{
+			name:    "fail_isadmin_in_body",
+			rbody:   []byte(`{"UserName": "testuser", "password": "password123", "mail": "test@example.com", "address": "123 Test St", "pincode": "123456", "phonenumber": "+919876543210", "isadmin": true}`),
+			wantErr: true,
+			errCode: e.ErrUnknownField,
+		},
+		{
+			name:    "fail_weak_password",
+			rbody:   []byte(`{"UserName": "testuser", "password": "testuser1", "mail": "test@example.com", "address": "123 Test St", "pincode": "123456", "phonenumber": "+919876543210"}`),
+			wantErr: true,
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:    "fail_hash_error",
+			rbody:   validBody,
+			hashErr: errors.New("hash error"),
+			wantErr: true,
+			errCode: e.ErrCreateUser,
+		},
 		{
 			name:    "fail_save_error",
 			rbody:   validBody,
@@ -73,21 +116,55 @@ func TestSaveUserDetails(t *testing.T) {
 			userRepoMock := new(internalmocks.UserRepo)
 			helperMock := new(helpermocks.ContextHelper)
 			jwtMock := new(jwtmocks.JWTService)
-			userService := NewUserService(userRepoMock, helperMock, jwtMock)
+			hasherMock := new(passwordmocks.PasswordHasher)
+			tokenRepoMock := new(internalmocks.TokenRepo)
+			mailerMock := new(mailmocks.Mailer)
+			mailOpts := MailOptions{Mailer: mailerMock, VerifyURL: "https://shop.example/verify-email", VerifyTokenTTL: time.Hour}
+			userService := NewUserService(userRepoMock, tokenRepoMock, new(internalmocks.RoleRepo), new(internalmocks.AuditRepo), helperMock, jwtMock, hasherMock, ratelimit.NoLockout{}, password.Policy{MinLength: 8, RequireLower: true, RequireDigit: true}, mailOpts)
+			succeeds := test.name == "success_case" || test.name == "success_mail_fails"
 
 			req, _ := http.NewRequest("POST", "/", bytes.NewBuffer(test.rbody))
 			req.Header.Set("Content-Type", "application/json")
 
+			// Only mock hashing for cases that get past validation
+			if succeeds || test.name == "fail_save_error" || test.name == "fail_hash_error" {
+				hasherMock.On("Hash", "password123").Return("hashed-password123", test.hashErr)
+			}
+
 			// Only mock SaveUserDetails for cases that go that far
-			if test.name == "success_case" || test.name == "fail_save_error" {
+			if succeeds || test.name == "fail_save_error" {
 				fmt.Printf("Mock returning: userID=%d, err=%v\n", test.userID, test.saveErr)
-				userRepoMock.On("SaveUserDetails", mock.MatchedBy(func(req *dto.UserDetailSaveRequest) bool {
-					return req.UserName == "testuser" && req.Password == "password123"
+				userRepoMock.On("SaveUserDetails", mock.Anything, mock.MatchedBy(func(req *dto.UserDetailSaveRequest) bool {
+					return req.UserName == "testuser" && req.Password == "hashed-password123" && req.Role == "customer"
 				})).Return(test.userID, test.saveErr)
 			}
 
+			// every new user gets a verification mail with a hashed single-use token
+			var sentToken string
+			if succeeds {
+				tokenRepoMock.On("CreateMailToken", mock.Anything, mock.MatchedBy(func(token *internal.Mailtoken) bool {
+					return token.UserID == test.userID && token.Purpose == internal.MailTokenVerify && token.ExpiresAt.After(time.Now())
+				})).Run(func(args mock.Arguments) {
+					sentToken = args.Get(1).(*internal.Mailtoken).TokenHash
+				}).Return(nil)
+				mailerMock.On("Send", mock.Anything, mock.MatchedBy(func(msg mail.Message) bool {
+					return msg.To == "test@example.com" && strings.Contains(msg.Body, "https://shop.example/verify-email?token=") && strings.Contains(msg.Body, "valid for 1 hour")
+				})).Run(func(args mock.Arguments) {
+					link := args.Get(1).(mail.Message).Body
+					token := link[strings.Index(link, "?token=")+len("?token="):]
+					token = token[:strings.IndexByte(token, '\n')]
+					assert.Equal(t, sentToken, jwt.HashRefreshToken(token), "only the hash is stored")
+				}).Return(test.mailErr)
+			}
+
+			signups := testutil.ToFloat64(metrics.Signups)
 			resp, err := userService.SaveUserDetails(req)
 
+			if succeeds {
+				assert.Equal(t, signups+1, testutil.ToFloat64(metrics.Signups))
+			} else {
+				assert.Equal(t, signups, testutil.ToFloat64(metrics.Signups))
+			}
 			if test.wantErr {
 				assert.Error(t, err)
 				assert.Nil(t, resp)
@@ -102,22 +179,34 @@ func TestSaveUserDetails(t *testing.T) {
 			}
 
 			userRepoMock.AssertExpectations(t)
+			hasherMock.AssertExpectations(t)
+			tokenRepoMock.AssertExpectations(t)
+			mailerMock.AssertExpectations(t)
 		})
 	}
 }
 
 func TestLoginUser(t *testing.T) {
 	tests := []struct {
-		name    string
-		rbody   []byte
-		mock    func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService)
-		want    *dto.LoginResponse
-		wantErr error
+		name  string
+		rbody []byte
+		mock  func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher)
+		want  *dto.LoginResponse
+		// storing the refresh token fails
+		refreshErr error
+		// lockedFor is how long the username is already locked
+		lockedFor time.Duration
+		// wantFail expects a failed attempt, failLocks is the lock it triggers
+		wantFail  bool
+		failLocks time.Duration
+		// unverifiedLogin is the policy for users who didn't verify their mail
+		unverifiedLogin string
+		wantErr         error
 	}{
 		{
 			name:  "fail_decode_request",
 			rbody: []byte(`{"invalid": "json"`),
-			mock:  func(_ *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService) {},
+			mock:  func(_ *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, _ *passwordmocks.PasswordHasher) {},
 			want:  nil,
 			wantErr: e.NewError(
 				e.ErrDecodeRequestBody,
@@ -125,10 +214,17 @@ func TestLoginUser(t *testing.T) {
 				errors.New("unexpected EOF"),
 			),
 		},
+		{
+			name:    "fail_unknown_field",
+			rbody:   []byte(`{"username": "testuser", "password": "pass", "isadmin": true}`),
+			mock:    func(_ *internalmocks.UserRepo, _ *jwtmocks.JWTService, _ *passwordmocks.PasswordHasher) {},
+			want:    nil,
+			wantErr: e.NewError(e.ErrUnknownField, "unknown field in request body", errors.New(`request body contains unknown field "isadmin"`)),
+		},
 		{
 			name:  "fail_validate_request",
 			rbody: []byte(`{"username": "testuser"}`), // missing password
-			mock:  func(_ *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService) {},
+			mock:  func(_ *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, _ *passwordmocks.PasswordHasher) {},
 			want:  nil,
 			wantErr: e.NewError(
 				e.ErrValidateRequest,
@@ -139,34 +235,33 @@ func TestLoginUser(t *testing.T) {
 		{
 			name:  "fail_user_not_found_db",
 			rbody: []byte(`{"username": "testuser", "password": "pass"}`),
-			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService) {
-				userRepoMock.On("GetUserByUsername", "testuser").Return(nil, gorm.ErrRecordNotFound).Once()
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(nil, gorm.ErrRecordNotFound).Once()
+				// unknown users still pay for a password check
+				hasherMock.On("Hash", mock.Anything).Return("dummy-hash", nil).Once()
+				hasherMock.On("Verify", "dummy-hash", "pass").Return(false, nil).Once()
 			},
-			want: nil,
-			wantErr: e.NewError(
-				e.ErrUserNotFound,
-				"user not found",
-				gorm.ErrRecordNotFound,
-			),
+			want:     nil,
+			wantFail: true,
+			wantErr:  e.NewError(e.ErrLoginFailed, "invalid username or password", errLoginFailed),
 		},
 		{
 			name:  "fail_user_nil",
 			rbody: []byte(`{"username": "testuser", "password": "pass"}`),
-			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService) {
-				userRepoMock.On("GetUserByUsername", "testuser").Return(nil, nil).Once()
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(nil, nil).Once()
+				hasherMock.On("Hash", mock.Anything).Return("dummy-hash", nil).Once()
+				hasherMock.On("Verify", "dummy-hash", "pass").Return(false, nil).Once()
 			},
-			want: nil,
-			wantErr: e.NewError(
-				e.ErrUserNotFound,
-				"user not found",
-				nil,
-			),
+			want:     nil,
+			wantFail: true,
+			wantErr:  e.NewError(e.ErrLoginFailed, "invalid username or password", errLoginFailed),
 		},
 		{
 			name:  "fail_db_error",
 			rbody: []byte(`{"username": "testuser", "password": "pass"}`),
-			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService) {
-				userRepoMock.On("GetUserByUsername", "testuser").Return(nil, errors.New("some db error")).Once()
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(nil, errors.New("some db error")).Once()
 			},
 			want: nil,
 			wantErr: e.NewError(
@@ -178,31 +273,97 @@ func TestLoginUser(t *testing.T) {
 		{
 			name:  "fail_wrong_password",
 			rbody: []byte(`{"username": "testuser", "password": "wrong"}`),
-			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService) {
-				userRepoMock.On("GetUserByUsername", "testuser").Return(&internal.Userdetail{
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
 					ID:       1,
 					Username: "testuser",
 					Password: "correct", // stored password
 					Status:   true,
 				}, nil).Once()
+				hasherMock.On("Verify", "correct", "wrong").Return(false, nil).Once()
+			},
+			want:     nil,
+			wantFail: true,
+			wantErr:  e.NewError(e.ErrLoginFailed, "invalid username or password", errLoginFailed),
+		},
+		{
+			name:  "fail_wrong_password_locks_account",
+			rbody: []byte(`{"username": "testuser", "password": "wrong"}`),
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
+					ID:       1,
+					Username: "testuser",
+					Password: "correct",
+					Status:   true,
+				}, nil).Once()
+				hasherMock.On("Verify", "correct", "wrong").Return(false, nil).Once()
+			},
+			want:      nil,
+			wantFail:  true,
+			failLocks: 15 * time.Minute,
+			wantErr:   e.NewError(e.ErrLoginFailed, "invalid username or password", errLoginFailed),
+		},
+		{
+			name:      "fail_account_locked",
+			rbody:     []byte(`{"username": "testuser", "password": "password"}`),
+			mock:      func(_ *internalmocks.UserRepo, _ *jwtmocks.JWTService, _ *passwordmocks.PasswordHasher) {},
+			lockedFor: 10 * time.Minute,
+			want:      nil,
+			wantErr:   e.NewError(e.ErrAccountLocked, "too many failed login attempts", errors.New("account locked, try again in 10m0s")),
+		},
+		{
+			name:  "fail_verify_error",
+			rbody: []byte(`{"username": "testuser", "password": "pass"}`),
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
+					ID:       1,
+					Username: "testuser",
+					Password: "$argon2id$broken",
+					Status:   true,
+				}, nil).Once()
+				hasherMock.On("Verify", "$argon2id$broken", "pass").Return(false, errors.New("invalid argon2id hash format")).Once()
 			},
 			want: nil,
 			wantErr: e.NewError(
-				e.ErrInvalidCredentials,
-				"invalid password",
-				fmt.Errorf("invalid password for user %s", "testuser"),
+				e.ErrLoginUser,
+				"error during login",
+				errors.New("invalid argon2id hash format"),
 			),
 		},
+		{
+			name:  "success_login_rehash_failure_ignored",
+			rbody: []byte(`{"username": "testuser", "password": "password"}`),
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
+					ID:       1,
+					Username: "testuser",
+					Password: "password",
+					Status:   true,
+					Role:     "customer",
+				}, nil).Once()
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
+				hasherMock.On("NeedsRehash", "password").Return(true).Once()
+				hasherMock.On("Hash", "password").Return("hashed-password", nil).Once()
+				userRepoMock.On("UpdatePassword", mock.Anything, int64(1), "hashed-password").Return(errors.New("db error")).Once()
+				jwtMock.On("GenerateToken", int64(1), "testuser", "customer", []string{}).
+					Return("mocked-token", nil).Once()
+			},
+			want: &dto.LoginResponse{
+				Token: "mocked-token",
+			},
+			wantErr: nil,
+		},
 		{
 			name:  "fail_user_blocked",
 			rbody: []byte(`{"username": "testuser", "password": "password"}`),
-			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService) {
-				userRepoMock.On("GetUserByUsername", "testuser").Return(&internal.Userdetail{
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
 					ID:       1,
 					Username: "testuser",
 					Password: "password",
 					Status:   false,
 				}, nil).Once()
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
 			},
 			want: nil,
 			wantErr: e.NewError(
@@ -211,36 +372,167 @@ func TestLoginUser(t *testing.T) {
 				fmt.Errorf("user %s is blocked", "testuser"),
 			),
 		},
+		{
+			name:  "fail_unverified_mail_blocked",
+			rbody: []byte(`{"username": "testuser", "password": "password"}`),
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
+					ID:       1,
+					Username: "testuser",
+					Password: "password",
+					Status:   true,
+				}, nil).Once()
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
+			},
+			unverifiedLogin: config.UnverifiedBlock,
+			want:            nil,
+			wantErr: e.NewError(
+				e.ErrMailNotVerified,
+				"mail address is not verified",
+				fmt.Errorf("user %s has not verified their mail address", "testuser"),
+			),
+		},
+		{
+			// limited users log in, the routes they may not use check the mail themselves
+			name:  "success_unverified_mail_limited",
+			rbody: []byte(`{"username": "testuser", "password": "password"}`),
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
+					ID:       1,
+					Username: "testuser",
+					Password: "password",
+					Status:   true,
+					Role:     "customer",
+				}, nil).Once()
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
+				hasherMock.On("NeedsRehash", "password").Return(false).Once()
+				jwtMock.On("GenerateToken", int64(1), "testuser", "customer", []string{}).
+					Return("mocked-token", nil).Once()
+			},
+			unverifiedLogin: config.UnverifiedLimit,
+			want: &dto.LoginResponse{
+				Token: "mocked-token",
+			},
+			wantErr: nil,
+		},
+		{
+			name:  "success_verified_mail_with_block_policy",
+			rbody: []byte(`{"username": "testuser", "password": "password"}`),
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				verifiedAt := time.Now().Add(-time.Hour)
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
+					ID:             1,
+					Username:       "testuser",
+					Password:       "password",
+					Status:         true,
+					Role:           "customer",
+					MailVerifiedAt: &verifiedAt,
+				}, nil).Once()
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
+				hasherMock.On("NeedsRehash", "password").Return(false).Once()
+				jwtMock.On("GenerateToken", int64(1), "testuser", "customer", []string{}).
+					Return("mocked-token", nil).Once()
+			},
+			unverifiedLogin: config.UnverifiedBlock,
+			want: &dto.LoginResponse{
+				Token: "mocked-token",
+			},
+			wantErr: nil,
+		},
 		{
 			name:  "fail_token_generation",
 			rbody: []byte(`{"username": "testuser", "password": "password"}`),
-			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService) {
-				userRepoMock.On("GetUserByUsername", "testuser").Return(&internal.Userdetail{
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
 					ID:       1,
 					Username: "testuser",
 					Password: "password",
 					Status:   true,
-					IsAdmin:  false,
+					Role:     "customer",
 				}, nil).Once()
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
+				hasherMock.On("NeedsRehash", "password").Return(false).Once()
 
-				jwtMock.On("GenerateToken", int64(1), "testuser", false).
+				jwtMock.On("GenerateToken", int64(1), "testuser", "customer", []string{}).
 					Return("", errors.New("token error")).Once()
 			},
 			want:    nil,
 			wantErr: e.NewError(e.ErrGenerateToken, "failed to generate token", errors.New("token error")),
 		},
+		{
+			name:  "fail_unknown_role",
+			rbody: []byte(`{"username": "testuser", "password": "password"}`),
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
+					ID:       1,
+					Username: "testuser",
+					Password: "password",
+					Status:   true,
+					Role:     "retired",
+				}, nil).Once()
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
+				hasherMock.On("NeedsRehash", "password").Return(false).Once()
+			},
+			want:    nil,
+			wantErr: e.NewError(e.ErrGenerateToken, "failed to generate token", gorm.ErrRecordNotFound),
+		},
+		{
+			name:  "success_login_with_permissions",
+			rbody: []byte(`{"username": "testuser", "password": "password"}`),
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
+					ID:       1,
+					Username: "testuser",
+					Password: "password",
+					Status:   true,
+					Role:     "support",
+				}, nil).Once()
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
+				hasherMock.On("NeedsRehash", "password").Return(false).Once()
+				jwtMock.On("GenerateToken", int64(1), "testuser", "support", []string{"orders:read", "users:read"}).
+					Return("mocked-token", nil).Once()
+			},
+			want: &dto.LoginResponse{
+				Token: "mocked-token",
+			},
+			wantErr: nil,
+		},
+		{
+			name:  "fail_store_refresh_token",
+			rbody: []byte(`{"username": "testuser", "password": "password"}`),
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
+					ID:       1,
+					Username: "testuser",
+					Password: "password",
+					Status:   true,
+					Role:     "customer",
+				}, nil).Once()
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
+				hasherMock.On("NeedsRehash", "password").Return(false).Once()
+				jwtMock.On("GenerateToken", int64(1), "testuser", "customer", []string{}).
+					Return("mocked-token", nil).Once()
+			},
+			refreshErr: errors.New("db error"),
+			wantErr:    e.NewError(e.ErrGenerateToken, "failed to generate refresh token", errors.New("db error")),
+		},
 		{
 			name:  "success_login",
 			rbody: []byte(`{"username": "testuser", "password": "password"}`),
-			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService) {
-				userRepoMock.On("GetUserByUsername", "testuser").Return(&internal.Userdetail{
+			mock: func(userRepoMock *internalmocks.UserRepo, jwtMock *jwtmocks.JWTService, hasherMock *passwordmocks.PasswordHasher) {
+				userRepoMock.On("GetUserByUsername", mock.Anything, "testuser").Return(&internal.Userdetail{
 					ID:       1,
 					Username: "testuser",
 					Password: "password",
 					Status:   true,
-					IsAdmin:  false,
+					Role:     "customer",
 				}, nil).Once()
-				jwtMock.On("GenerateToken", int64(1), "testuser", false).
+				// legacy plaintext row gets rehashed on successful login
+				hasherMock.On("Verify", "password", "password").Return(true, nil).Once()
+				hasherMock.On("NeedsRehash", "password").Return(true).Once()
+				hasherMock.On("Hash", "password").Return("hashed-password", nil).Once()
+				userRepoMock.On("UpdatePassword", mock.Anything, int64(1), "hashed-password").Return(nil).Once()
+				jwtMock.On("GenerateToken", int64(1), "testuser", "customer", []string{}).
 					Return("mocked-token", nil).Once()
 			},
 			want: &dto.LoginResponse{
@@ -255,24 +547,58 @@ func TestLoginUser(t *testing.T) {
 			userRepoMock := internalmocks.NewUserRepo(t)
 			contextHelperMock := helpermocks.NewContextHelper(t)
 			jwtMock := jwtmocks.NewJWTService(t)
+			hasherMock := passwordmocks.NewPasswordHasher(t)
+
+			tokenRepoMock := new(internalmocks.TokenRepo)
+			tokenRepoMock.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(token *internal.Refreshtoken) bool {
+				return token.UserID == 1 && token.FamilyID != "" && len(token.TokenHash) == 64
+			})).Return(tt.refreshErr).Maybe()
+			jwtMock.On("RefreshTokenTTL").Return(7 * 24 * time.Hour).Maybe()
+			jwtMock.On("AccessTokenTTL").Return(15 * time.Minute).Maybe()
 
-			userService := NewUserService(userRepoMock, contextHelperMock, jwtMock)
-			tt.mock(userRepoMock, jwtMock)
+			lockoutMock := ratelimitmocks.NewLockout(t)
+			lockoutMock.On("LockedFor", mock.Anything, "testuser").Return(tt.lockedFor, nil).Maybe()
+			lockoutMock.On("Reset", mock.Anything, "testuser").Return(nil).Maybe()
+			if tt.wantFail {
+				lockoutMock.On("Fail", mock.Anything, "testuser").Return(tt.failLocks, nil).Once()
+			}
+
+			roleRepoMock := new(internalmocks.RoleRepo)
+			roleRepoMock.On("GetRole", mock.Anything, "customer").Return(&internal.Role{Name: "customer"}, nil).Maybe()
+			roleRepoMock.On("GetRole", mock.Anything, "support").Return(&internal.Role{Name: "support", Permissions: []internal.Rolepermission{
+				{Role: "support", Permission: "orders:read"}, {Role: "support", Permission: "users:read"},
+			}}, nil).Maybe()
+			roleRepoMock.On("GetRole", mock.Anything, "retired").Return(nil, gorm.ErrRecordNotFound).Maybe()
+
+			userService := NewUserService(userRepoMock, tokenRepoMock, roleRepoMock, new(internalmocks.AuditRepo), contextHelperMock, jwtMock, hasherMock, lockoutMock, password.Policy{}, MailOptions{UnverifiedLogin: tt.unverifiedLogin})
+			tt.mock(userRepoMock, jwtMock, hasherMock)
 
 			req := httptest.NewRequest("POST", "/", bytes.NewReader(tt.rbody))
 			req.Header.Set("Content-Type", "application/json")
 
+			logins := loginCounts()
 			got, err := userService.LoginUser(req)
 
+			// every attempt that gets past validation counts once, under its outcome
+			if outcome := loginOutcome(tt.wantErr, tt.wantFail); outcome != "" {
+				logins[outcome]++
+			}
+			assert.Equal(t, logins, loginCounts())
+
 			if tt.wantErr != nil {
 				require.Error(t, err)
 				assert.Nil(t, got)
 				assert.Equal(t, tt.wantErr.(*e.WrapError).ErrorCode, err.(*e.WrapError).ErrorCode)
+				if tt.wantFail {
+					// every credential failure looks the same to the client
+					assert.Equal(t, errLoginFailed.Error(), err.(*e.WrapError).RootCause.Error())
+				}
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, got)
 				assert.Equal(t, "mocked-token", got.Token)
-				assert.Equal(t, "mocked-token", got.Token)
+				assert.NotEmpty(t, got.RefreshToken)
+				assert.Equal(t, int64(900), got.ExpiresIn)
 
 			}
 
@@ -281,6 +607,36 @@ func TestLoginUser(t *testing.T) {
 	}
 }
 
+func loginCounts() map[string]float64 {
+	counts := map[string]float64{}
+	for _, outcome := range []string{metrics.LoginSuccess, metrics.LoginInvalidCredentials, metrics.LoginBlocked, metrics.LoginLocked, metrics.LoginUnverified, metrics.LoginError} {
+		counts[outcome] = testutil.ToFloat64(metrics.Logins.WithLabelValues(outcome))
+	}
+	return counts
+}
+
+// loginOutcome is the outcome a login test case is counted under, empty when
+// the request is rejected before the attempt counts
+func loginOutcome(wantErr error, wantFail bool) string {
+	if wantErr == nil {
+		return metrics.LoginSuccess
+	}
+	if wantFail {
+		return metrics.LoginInvalidCredentials
+	}
+	switch wantErr.(*e.WrapError).ErrorCode {
+	case e.ErrAccountLocked:
+		return metrics.LoginLocked
+	case e.ErrUserBlocked:
+		return metrics.LoginBlocked
+	case e.ErrMailNotVerified:
+		return metrics.LoginUnverified
+	case e.ErrLoginUser, e.ErrGenerateToken:
+		return metrics.LoginError
+	}
+	return ""
+}
+
 func TestGetUserIDAndCheckStatus(t *testing.T) {
 	type mocks struct {
 		helper   *helpermocks.ContextHelper
@@ -301,7 +657,7 @@ func TestGetUserIDAndCheckStatus(t *testing.T) {
 			ctx:  context.Background(),
 			mockSetup: func(m mocks) {
 				m.helper.On("GetUserID", mock.Anything).Return(int64(123), nil)
-				m.userRepo.On("IsUserActive", int64(123)).Return(true, nil)
+				m.userRepo.On("IsUserActive", mock.Anything, int64(123)).Return(true, nil)
 			},
 			wantUserID: 123,
 			wantErr:    false,
@@ -321,7 +677,7 @@ func TestGetUserIDAndCheckStatus(t *testing.T) {
 			ctx:  context.Background(),
 			mockSetup: func(m mocks) {
 				m.helper.On("GetUserID", mock.Anything).Return(int64(456), nil)
-				m.userRepo.On("IsUserActive", int64(456)).Return(false, errors.New("db failure"))
+				m.userRepo.On("IsUserActive", mock.Anything, int64(456)).Return(false, errors.New("db failure"))
 			},
 			wantUserID:   0,
 			wantErr:      true,
@@ -332,7 +688,7 @@ func TestGetUserIDAndCheckStatus(t *testing.T) {
 			ctx:  context.Background(),
 			mockSetup: func(m mocks) {
 				m.helper.On("GetUserID", mock.Anything).Return(int64(789), nil)
-				m.userRepo.On("IsUserActive", int64(789)).Return(false, nil)
+				m.userRepo.On("IsUserActive", mock.Anything, int64(789)).Return(false, nil)
 			},
 			wantUserID:   0,
 			wantErr:      true,
@@ -379,3 +735,1465 @@ func TestGetUserIDAndCheckStatus(t *testing.T) {
 		})
 	}
 }
+
+type userMocks struct {
+	userRepo  *internalmocks.UserRepo
+	tokenRepo *internalmocks.TokenRepo
+	roleRepo  *internalmocks.RoleRepo
+	auditRepo *internalmocks.AuditRepo
+	helper    *helpermocks.ContextHelper
+	jwt       *jwtmocks.JWTService
+	hasher    *passwordmocks.PasswordHasher
+	mailer    *mailmocks.Mailer
+}
+
+func newUserServiceWithMocks(t *testing.T) (UserService, userMocks) {
+	m := userMocks{
+		userRepo:  internalmocks.NewUserRepo(t),
+		tokenRepo: internalmocks.NewTokenRepo(t),
+		roleRepo:  internalmocks.NewRoleRepo(t),
+		auditRepo: internalmocks.NewAuditRepo(t),
+		helper:    helpermocks.NewContextHelper(t),
+		jwt:       jwtmocks.NewJWTService(t),
+		hasher:    passwordmocks.NewPasswordHasher(t),
+		mailer:    mailmocks.NewMailer(t),
+	}
+	mailOpts := MailOptions{
+		Mailer:         m.mailer,
+		VerifyURL:      "https://shop.example/verify-email",
+		VerifyTokenTTL: 24 * time.Hour,
+		ResetURL:       "https://shop.example/password/reset",
+		ResetTokenTTL:  30 * time.Minute,
+	}
+	return NewUserService(m.userRepo, m.tokenRepo, m.roleRepo, m.auditRepo, m.helper, m.jwt, m.hasher, ratelimit.NoLockout{}, password.Policy{MinLength: 8}, mailOpts), m
+}
+
+// waitForMails drains the mail queue like a shutdown does, the mocks are
+// checked once the test returns
+func waitForMails(userService UserService) {
+	if err := userService.(*userServiceImpl).mail.Queue.Close(context.Background()); err != nil {
+		panic(err)
+	}
+}
+
+// roleWith builds a role as the role repo returns it
+func roleWith(name string, permissions ...string) *internal.Role {
+	role := &internal.Role{Name: name}
+	for _, p := range permissions {
+		role.Permissions = append(role.Permissions, internal.Rolepermission{Role: name, Permission: p})
+	}
+	return role
+}
+
+var (
+	supportPerms = []string{rbac.UsersRead, rbac.UsersBlock, rbac.OrdersRead}
+	supportRole  = roleWith(rbac.RoleSupport, supportPerms...)
+	adminRole    = roleWith(rbac.RoleAdmin, rbac.Permissions...)
+)
+
+// guardedTarget expects the lookup of the user about to be (un)blocked or edited
+func guardedTarget(m userMocks, granted []string, userID int64, role *internal.Role) {
+	m.helper.On("GetPermissions", mock.Anything).Return(granted, nil).Once()
+	m.userRepo.On("GetUserByID", mock.Anything, userID).Return(&internal.Userdetail{ID: userID, Role: role.Name}, nil).Once()
+	m.roleRepo.On("GetRole", mock.Anything, role.Name).Return(role, nil).Once()
+}
+
+func TestBlockUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  string
+		mock    func(m userMocks)
+		want    *dto.UserStatusResponse
+		errCode int
+	}{
+		{
+			name:    "fail_invalid_user_id",
+			userID:  "abc",
+			mock:    func(m userMocks) {},
+			errCode: e.ErrInvalidRequest,
+		},
+		{
+			name:   "fail_block_self",
+			userID: "1",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+			},
+			errCode: e.ErrBlockUser,
+		},
+		{
+			name:   "fail_user_not_found",
+			userID: "2",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				m.helper.On("GetPermissions", mock.Anything).Return(rbac.Permissions, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrUserNotFound,
+		},
+		{
+			name:   "fail_get_role",
+			userID: "2",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				m.helper.On("GetPermissions", mock.Anything).Return(rbac.Permissions, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(&internal.Userdetail{ID: 2, Role: rbac.RoleSupport}, nil).Once()
+				m.roleRepo.On("GetRole", mock.Anything, rbac.RoleSupport).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrBlockUser,
+		},
+		{
+			// support holds users:block but must not lock out an admin
+			name:   "fail_support_blocks_admin",
+			userID: "2",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(3), nil).Once()
+				guardedTarget(m, supportPerms, 2, adminRole)
+			},
+			errCode: e.ErrForbidden,
+		},
+		{
+			name:   "fail_update_status",
+			userID: "2",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				guardedTarget(m, rbac.Permissions, 2, roleWith(rbac.RoleCustomer))
+				m.userRepo.On("UpdateUserStatus", mock.Anything, int64(2), false).Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrBlockUser,
+		},
+		{
+			name:   "success_admin_blocks_support",
+			userID: "2",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				guardedTarget(m, rbac.Permissions, 2, supportRole)
+				m.userRepo.On("UpdateUserStatus", mock.Anything, int64(2), false).Return(nil).Once()
+			},
+			want: &dto.UserStatusResponse{UserID: 2, Status: false},
+		},
+		{
+			name:   "success_support_blocks_customer",
+			userID: "2",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(3), nil).Once()
+				guardedTarget(m, supportPerms, 2, roleWith(rbac.RoleCustomer))
+				m.userRepo.On("UpdateUserStatus", mock.Anything, int64(2), false).Return(nil).Once()
+			},
+			want: &dto.UserStatusResponse{UserID: 2, Status: false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			req := withURLParam(httptest.NewRequest("PUT", "/admin/users/"+tt.userID+"/block", nil), "userid", tt.userID)
+
+			got, err := userService.BlockUser(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUnblockUser(t *testing.T) {
+	tests := []struct {
+		name    string
+		userID  string
+		mock    func(m userMocks)
+		want    *dto.UserStatusResponse
+		errCode int
+	}{
+		{
+			name:    "fail_invalid_user_id",
+			userID:  "abc",
+			mock:    func(m userMocks) {},
+			errCode: e.ErrInvalidRequest,
+		},
+		{
+			name:   "fail_user_not_found",
+			userID: "2",
+			mock: func(m userMocks) {
+				m.helper.On("GetPermissions", mock.Anything).Return(rbac.Permissions, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrUserNotFound,
+		},
+		{
+			// an admin blocked by another admin stays blocked for support
+			name:   "fail_support_unblocks_admin",
+			userID: "2",
+			mock: func(m userMocks) {
+				guardedTarget(m, supportPerms, 2, adminRole)
+			},
+			errCode: e.ErrForbidden,
+		},
+		{
+			name:   "fail_update_status",
+			userID: "2",
+			mock: func(m userMocks) {
+				guardedTarget(m, rbac.Permissions, 2, roleWith(rbac.RoleCustomer))
+				m.userRepo.On("UpdateUserStatus", mock.Anything, int64(2), true).Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrUnblockUser,
+		},
+		{
+			name:   "success_admin_unblocks_support",
+			userID: "2",
+			mock: func(m userMocks) {
+				guardedTarget(m, rbac.Permissions, 2, supportRole)
+				m.userRepo.On("UpdateUserStatus", mock.Anything, int64(2), true).Return(nil).Once()
+			},
+			want: &dto.UserStatusResponse{UserID: 2, Status: true},
+		},
+		{
+			// a role that was deleted grants nothing
+			name:   "success_unknown_role",
+			userID: "2",
+			mock: func(m userMocks) {
+				m.helper.On("GetPermissions", mock.Anything).Return(supportPerms, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(&internal.Userdetail{ID: 2, Role: "retired"}, nil).Once()
+				m.roleRepo.On("GetRole", mock.Anything, "retired").Return(nil, gorm.ErrRecordNotFound).Once()
+				m.userRepo.On("UpdateUserStatus", mock.Anything, int64(2), true).Return(nil).Once()
+			},
+			want: &dto.UserStatusResponse{UserID: 2, Status: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			req := withURLParam(httptest.NewRequest("PUT", "/admin/users/"+tt.userID+"/unblock", nil), "userid", tt.userID)
+
+			got, err := userService.UnblockUser(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(m userMocks)
+		want    []dto.AllUserDetails
+		errCode int
+	}{
+		{
+			name: "fail_list_users",
+			mock: func(m userMocks) {
+				m.userRepo.On("ListUsers", mock.Anything).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrGetUserDetails,
+		},
+		{
+			name: "success_case",
+			mock: func(m userMocks) {
+				m.userRepo.On("ListUsers", mock.Anything).Return([]internal.Userdetail{
+					{ID: 1, Username: "admin", Password: "secret-hash", Mail: "admin@example.com", Status: true, Role: "admin"},
+					{ID: 2, Username: "johndoe", Password: "secret-hash", Address: "123 Street", Pincode: "123456", Phonenumber: "+919876543210", Mail: "john@example.com"},
+				}, nil).Once()
+			},
+			want: []dto.AllUserDetails{
+				{UserID: 1, UserName: "admin", Mail: "admin@example.com", Status: true, Role: "admin"},
+				{UserID: 2, UserName: "johndoe", Mail: "john@example.com", Address: "123 Street", Pincode: "123456", Phone: "+919876543210"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			got, err := userService.ListUsers(httptest.NewRequest("GET", "/admin/users", nil))
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateUserDetails(t *testing.T) {
+	validBody := []byte(`{"username": "janedoe", "mail": "jane@example.com", "address": "456 Avenue", "pincode": "654321", "phonenumber": "+919123456780"}`)
+	matchReq := mock.MatchedBy(func(req *dto.UpdateUserDetailRequest) bool {
+		return req.UserID == 2 && req.UserName == "janedoe"
+	})
+	updated := &internal.Userdetail{
+		ID: 2, Username: "janedoe", Password: "secret-hash", Mail: "jane@example.com",
+		Address: "456 Avenue", Pincode: "654321", Phonenumber: "+919123456780", Status: true,
+	}
+	// editTarget lets an admin edit the customer 2, who has mail before the update
+	editTarget := func(m userMocks, mail string) {
+		m.helper.On("GetPermissions", mock.Anything).Return(rbac.Permissions, nil).Once()
+		m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(&internal.Userdetail{ID: 2, Username: "jane", Mail: mail, Role: rbac.RoleCustomer, Status: true}, nil).Once()
+		m.roleRepo.On("GetRole", mock.Anything, rbac.RoleCustomer).Return(roleWith(rbac.RoleCustomer), nil).Once()
+	}
+
+	tests := []struct {
+		name    string
+		rbody   []byte
+		mock    func(m userMocks)
+		want    *dto.AllUserDetails
+		errCode int
+	}{
+		{
+			name:    "fail_decode_request",
+			rbody:   []byte(`{"username": `),
+			mock:    func(m userMocks) {},
+			errCode: e.ErrDecodeRequestBody,
+		},
+		{
+			name:    "fail_validate_request",
+			rbody:   []byte(`{"username": "janedoe"}`),
+			mock:    func(m userMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_user_not_found",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				m.helper.On("GetPermissions", mock.Anything).Return(rbac.Permissions, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrUserNotFound,
+		},
+		{
+			name:  "fail_user_write_cannot_edit_admin",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				// a role given users:write could otherwise re-mail an admin and reset their password
+				guardedTarget(m, []string{rbac.UsersRead, rbac.UsersWrite}, 2, adminRole)
+			},
+			errCode: e.ErrForbidden,
+		},
+		{
+			name:  "fail_user_deleted_meanwhile",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				editTarget(m, "jane@example.com")
+				m.userRepo.On("UpdateUserDetails", mock.Anything, matchReq).Return(gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrUserNotFound,
+		},
+		{
+			name:  "fail_username_taken",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				editTarget(m, "jane@example.com")
+				m.userRepo.On("UpdateUserDetails", mock.Anything, matchReq).Return(gorm.ErrDuplicatedKey).Once()
+			},
+			errCode: e.ErrUsernameTaken,
+		},
+		{
+			name:  "fail_update",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				editTarget(m, "jane@example.com")
+				m.userRepo.On("UpdateUserDetails", mock.Anything, matchReq).Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrUpdateUserProfile,
+		},
+		{
+			name: "success_case",
+			// the path id wins over any id in the body
+			rbody: []byte(`{"userid": 7, "username": "janedoe", "mail": "jane@example.com", "address": "456 Avenue", "pincode": "654321", "phonenumber": "+919123456780"}`),
+			mock: func(m userMocks) {
+				// the mail stays, so no verification mail
+				editTarget(m, "jane@example.com")
+				m.userRepo.On("UpdateUserDetails", mock.Anything, matchReq).Return(nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(updated, nil).Once()
+			},
+			want: &dto.AllUserDetails{
+				UserID: 2, UserName: "janedoe", Mail: "jane@example.com",
+				Address: "456 Avenue", Pincode: "654321", Phone: "+919123456780", Status: true,
+			},
+		},
+		{
+			name:  "success_new_mail_is_verified_again",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				editTarget(m, "jane@old.example")
+				m.userRepo.On("UpdateUserDetails", mock.Anything, matchReq).Return(nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(updated, nil).Once()
+				m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenVerify).Return(nil).Once()
+				m.tokenRepo.On("CreateMailToken", mock.Anything, mock.MatchedBy(func(token *internal.Mailtoken) bool {
+					return token.UserID == 2 && token.Purpose == internal.MailTokenVerify
+				})).Return(nil).Once()
+				m.mailer.On("Send", mock.Anything, mock.MatchedBy(func(msg mail.Message) bool {
+					return msg.To == "jane@example.com" && strings.Contains(msg.Body, "Hi janedoe")
+				})).Return(nil).Once()
+			},
+			want: &dto.AllUserDetails{
+				UserID: 2, UserName: "janedoe", Mail: "jane@example.com",
+				Address: "456 Avenue", Pincode: "654321", Phone: "+919123456780", Status: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			req := withURLParam(httptest.NewRequest("PUT", "/admin/users/2", bytes.NewReader(tt.rbody)), "userid", "2")
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := userService.UpdateUserDetails(req)
+			waitForMails(userService)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGetMyProfile(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    func(m userMocks)
+		want    *dto.AllUserDetails
+		errCode int
+	}{
+		{
+			name: "fail_get_user_id",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(0), errors.New("user ID not found in context")).Once()
+			},
+			errCode: e.ErrContextError,
+		},
+		{
+			name: "fail_user_not_found",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(2), nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrUserNotFound,
+		},
+		{
+			name: "fail_get_user",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(2), nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrGetUserDetails,
+		},
+		{
+			name: "success_case",
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(2), nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(&internal.Userdetail{
+					ID: 2, Username: "johndoe", Password: "secret-hash", Mail: "john@example.com", Status: true,
+				}, nil).Once()
+			},
+			want: &dto.AllUserDetails{UserID: 2, UserName: "johndoe", Mail: "john@example.com", Status: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			got, err := userService.GetMyProfile(httptest.NewRequest("GET", "/me", nil))
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	rotated := &internal.Refreshtoken{ID: 6, UserID: 2, FamilyID: "fam-1"}
+	validBody := []byte(`{"refresh_token": "old-token"}`)
+	oldHash := jwt.HashRefreshToken("old-token")
+
+	tests := []struct {
+		name    string
+		rbody   []byte
+		mock    func(m userMocks)
+		errCode int
+	}{
+		{
+			name:    "fail_decode_request",
+			rbody:   []byte(`{"refresh_token": `),
+			mock:    func(m userMocks) {},
+			errCode: e.ErrDecodeRequestBody,
+		},
+		{
+			name:    "fail_validate_request",
+			rbody:   []byte(`{}`),
+			mock:    func(m userMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_invalid_token",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				m.jwt.On("RefreshTokenTTL").Return(time.Hour).Once()
+				m.tokenRepo.On("RotateRefreshToken", mock.Anything, oldHash, mock.Anything, mock.Anything).Return(nil, internal.ErrRefreshTokenInvalid).Once()
+			},
+			errCode: e.ErrInvalidRefreshToken,
+		},
+		{
+			name:  "fail_reused_token",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				m.jwt.On("RefreshTokenTTL").Return(time.Hour).Once()
+				m.tokenRepo.On("RotateRefreshToken", mock.Anything, oldHash, mock.Anything, mock.Anything).Return(nil, internal.ErrRefreshTokenReused).Once()
+			},
+			errCode: e.ErrRefreshTokenReused,
+		},
+		{
+			name:  "fail_user_blocked",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				m.jwt.On("RefreshTokenTTL").Return(time.Hour).Once()
+				m.tokenRepo.On("RotateRefreshToken", mock.Anything, oldHash, mock.Anything, mock.Anything).Return(rotated, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(&internal.Userdetail{ID: 2, Username: "johndoe", Status: false}, nil).Once()
+			},
+			errCode: e.ErrUserBlocked,
+		},
+		{
+			name:  "success_case",
+			rbody: validBody,
+			mock: func(m userMocks) {
+				m.jwt.On("RefreshTokenTTL").Return(time.Hour).Once()
+				m.tokenRepo.On("RotateRefreshToken", mock.Anything, oldHash, mock.MatchedBy(func(newHash string) bool {
+					return newHash != oldHash && len(newHash) == 64
+				}), mock.Anything).Return(rotated, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(&internal.Userdetail{ID: 2, Username: "johndoe", Status: true, Role: "customer"}, nil).Once()
+				m.roleRepo.On("GetRole", mock.Anything, "customer").Return(&internal.Role{Name: "customer"}, nil).Once()
+				m.jwt.On("GenerateToken", int64(2), "johndoe", "customer", []string{}).Return("new-access-token", nil).Once()
+				m.jwt.On("AccessTokenTTL").Return(15 * time.Minute).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("POST", "/token/refresh", bytes.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := userService.RefreshToken(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "new-access-token", got.Token)
+				assert.NotEmpty(t, got.RefreshToken)
+				assert.NotEqual(t, "old-token", got.RefreshToken)
+				assert.Equal(t, int64(900), got.ExpiresIn)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	expiresAt := time.Now().Add(10 * time.Minute)
+	tokenCtx := func(m userMocks) {
+		m.helper.On("GetUserID", mock.Anything).Return(int64(2), nil).Once()
+		m.helper.On("GetTokenID", mock.Anything).Return("jti-1", nil).Once()
+		m.helper.On("GetTokenExpiry", mock.Anything).Return(expiresAt, nil).Once()
+	}
+
+	tests := []struct {
+		name    string
+		rbody   []byte
+		mock    func(m userMocks)
+		errCode int
+	}{
+		{
+			name:    "fail_decode_request",
+			rbody:   []byte(`{"refresh_token": `),
+			mock:    func(m userMocks) {},
+			errCode: e.ErrDecodeRequestBody,
+		},
+		{
+			name:  "fail_get_token_id",
+			rbody: nil,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(2), nil).Once()
+				m.helper.On("GetTokenID", mock.Anything).Return("", errors.New("token ID not found in context")).Once()
+			},
+			errCode: e.ErrContextError,
+		},
+		{
+			name:  "fail_revoke_access_token",
+			rbody: nil,
+			mock: func(m userMocks) {
+				tokenCtx(m)
+				m.tokenRepo.On("RevokeAccessToken", mock.Anything, "jti-1", int64(2), expiresAt).Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrLogout,
+		},
+		{
+			name:  "success_access_token_only",
+			rbody: nil,
+			mock: func(m userMocks) {
+				tokenCtx(m)
+				m.tokenRepo.On("RevokeAccessToken", mock.Anything, "jti-1", int64(2), expiresAt).Return(nil).Once()
+			},
+		},
+		{
+			name:  "success_with_refresh_token",
+			rbody: []byte(`{"refresh_token": "refresh"}`),
+			mock: func(m userMocks) {
+				tokenCtx(m)
+				m.tokenRepo.On("RevokeAccessToken", mock.Anything, "jti-1", int64(2), expiresAt).Return(nil).Once()
+				m.tokenRepo.On("RevokeRefreshTokenFamily", mock.Anything, jwt.HashRefreshToken("refresh"), int64(2)).Return(nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("POST", "/logout", bytes.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := userService.Logout(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &dto.LogoutResponse{LoggedOut: true}, got)
+			}
+		})
+	}
+}
+
+func TestAssignRole(t *testing.T) {
+	support := &internal.Role{Name: "support", Permissions: []internal.Rolepermission{
+		{Role: "support", Permission: "orders:read"}, {Role: "support", Permission: "users:read"},
+	}}
+
+	tests := []struct {
+		name    string
+		userID  string
+		rbody   string
+		mock    func(m userMocks)
+		want    *dto.UserRoleResponse
+		errCode int
+	}{
+		{
+			name:    "fail_invalid_user_id",
+			userID:  "abc",
+			rbody:   `{"role": "support"}`,
+			mock:    func(m userMocks) {},
+			errCode: e.ErrInvalidRequest,
+		},
+		{
+			name:    "fail_missing_role",
+			userID:  "2",
+			rbody:   `{}`,
+			mock:    func(m userMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:   "fail_own_role",
+			userID: "1",
+			rbody:  `{"role": "customer"}`,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+			},
+			errCode: e.ErrAssignRole,
+		},
+		{
+			name:   "fail_role_not_found",
+			userID: "2",
+			rbody:  `{"role": "superuser"}`,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				m.roleRepo.On("GetRole", mock.Anything, "superuser").Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrRoleNotFound,
+		},
+		{
+			name:   "fail_user_not_found",
+			userID: "2",
+			rbody:  `{"role": "support"}`,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				m.roleRepo.On("GetRole", mock.Anything, "support").Return(support, nil).Once()
+				m.userRepo.On("UpdateUserRole", mock.Anything, int64(2), "support").Return(gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrUserNotFound,
+		},
+		{
+			name:   "fail_revoke_sessions",
+			userID: "2",
+			rbody:  `{"role": "support"}`,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				m.roleRepo.On("GetRole", mock.Anything, "support").Return(support, nil).Once()
+				m.userRepo.On("UpdateUserRole", mock.Anything, int64(2), "support").Return(nil).Once()
+				m.tokenRepo.On("RevokeUserRefreshTokens", mock.Anything, int64(2)).Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrAssignRole,
+		},
+		{
+			name:   "success_case",
+			userID: "2",
+			rbody:  `{"role": " Support "}`,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(1), nil).Once()
+				m.roleRepo.On("GetRole", mock.Anything, "support").Return(support, nil).Once()
+				m.userRepo.On("UpdateUserRole", mock.Anything, int64(2), "support").Return(nil).Once()
+				m.tokenRepo.On("RevokeUserRefreshTokens", mock.Anything, int64(2)).Return(nil).Once()
+			},
+			want: &dto.UserRoleResponse{UserID: 2, Role: "support", Permissions: []string{"orders:read", "users:read"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("PUT", "/admin/users/"+tt.userID+"/role", strings.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+			req = withURLParam(req, "userid", tt.userID)
+
+			got, err := userService.AssignRole(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListRoles(t *testing.T) {
+	t.Run("success_case", func(t *testing.T) {
+		userService, m := newUserServiceWithMocks(t)
+		m.roleRepo.On("ListRoles", mock.Anything).Return([]internal.Role{
+			{Name: "customer", Description: "Shops on the site"},
+			{Name: "warehouse", Description: "Ships orders", Permissions: []internal.Rolepermission{{Role: "warehouse", Permission: "orders:read"}}},
+		}, nil).Once()
+
+		got, err := userService.ListRoles(httptest.NewRequest("GET", "/admin/roles", nil))
+
+		require.NoError(t, err)
+		assert.Equal(t, []dto.RoleDetails{
+			{Name: "customer", Description: "Shops on the site", Permissions: []string{}},
+			{Name: "warehouse", Description: "Ships orders", Permissions: []string{"orders:read"}},
+		}, got)
+	})
+
+	t.Run("fail_list_roles", func(t *testing.T) {
+		userService, m := newUserServiceWithMocks(t)
+		m.roleRepo.On("ListRoles", mock.Anything).Return(nil, errors.New("db error")).Once()
+
+		got, err := userService.ListRoles(httptest.NewRequest("GET", "/admin/roles", nil))
+
+		require.Error(t, err)
+		assert.Nil(t, got)
+		assert.Equal(t, e.ErrListRoles, err.(*e.WrapError).ErrorCode)
+	})
+}
+
+func TestVerifyMail(t *testing.T) {
+	hash := jwt.HashRefreshToken("mail-token")
+
+	tests := []struct {
+		name    string
+		rbody   string
+		mock    func(m userMocks)
+		want    *dto.VerifyMailResponse
+		errCode int
+	}{
+		{
+			name:    "fail_missing_token",
+			rbody:   `{}`,
+			mock:    func(m userMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_invalid_token",
+			rbody: `{"token": "mail-token"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("UseMailToken", mock.Anything, internal.MailTokenVerify, hash).Return(nil, internal.ErrMailTokenInvalid).Once()
+			},
+			errCode: e.ErrInvalidMailToken,
+		},
+		{
+			name:  "fail_use_token",
+			rbody: `{"token": "mail-token"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("UseMailToken", mock.Anything, internal.MailTokenVerify, hash).Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrVerifyMail,
+		},
+		{
+			name:  "fail_user_gone",
+			rbody: `{"token": "mail-token"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("UseMailToken", mock.Anything, internal.MailTokenVerify, hash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.userRepo.On("MarkMailVerified", mock.Anything, int64(2)).Return(gorm.ErrRecordNotFound).Once()
+			},
+			errCode: e.ErrUserNotFound,
+		},
+		{
+			name:  "success_case",
+			rbody: `{"token": " mail-token "}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("UseMailToken", mock.Anything, internal.MailTokenVerify, hash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.userRepo.On("MarkMailVerified", mock.Anything, int64(2)).Return(nil).Once()
+			},
+			want: &dto.VerifyMailResponse{UserID: 2, MailVerified: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("POST", "/verify-email", strings.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := userService.VerifyMail(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestResendVerificationMail(t *testing.T) {
+	verifiedAt := time.Now().Add(-time.Hour)
+	unverified := &internal.Userdetail{ID: 2, Username: "johndoe", Mail: "john@example.com", Status: true}
+
+	tests := []struct {
+		name    string
+		rbody   string
+		mock    func(m userMocks)
+		errCode int
+	}{
+		{
+			name:    "fail_missing_username",
+			rbody:   `{}`,
+			mock:    func(m userMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "unknown_user_looks_the_same",
+			rbody: `{"username": "nobody"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "nobody").Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+		},
+		{
+			name:  "already_verified",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(&internal.Userdetail{ID: 2, Status: true, MailVerifiedAt: &verifiedAt}, nil).Once()
+			},
+		},
+		{
+			name:  "blocked_user",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(&internal.Userdetail{ID: 2, Status: false}, nil).Once()
+			},
+		},
+		{
+			name:  "fail_db_error",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrVerifyMail,
+		},
+		{
+			name:  "revoke_fails_looks_the_same",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(unverified, nil).Once()
+				m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenVerify).Return(errors.New("db error")).Once()
+			},
+		},
+		{
+			name:  "mail_fails_looks_the_same",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(unverified, nil).Once()
+				m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenVerify).Return(nil).Once()
+				m.tokenRepo.On("CreateMailToken", mock.Anything, mock.Anything).Return(nil).Once()
+				m.mailer.On("Send", mock.Anything, mock.Anything).Return(errors.New("smtp down")).Once()
+			},
+		},
+		{
+			name:  "success_case",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(unverified, nil).Once()
+				// links in older mails stop working
+				m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenVerify).Return(nil).Once()
+				m.tokenRepo.On("CreateMailToken", mock.Anything, mock.MatchedBy(func(token *internal.Mailtoken) bool {
+					return token.UserID == 2 && token.Purpose == internal.MailTokenVerify && len(token.TokenHash) == 64
+				})).Return(nil).Once()
+				// the mail goes out after the request is over
+				m.mailer.On("Send", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), mock.MatchedBy(func(msg mail.Message) bool {
+					return msg.To == "john@example.com" && strings.Contains(msg.Body, "Hi johndoe") && strings.Contains(msg.Body, "valid for 24 hours")
+				})).Return(nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			req := httptest.NewRequestWithContext(ctx, "POST", "/verify-email/resend", strings.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := userService.ResendVerificationMail(req)
+			cancel()
+			waitForMails(userService)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &dto.ResendVerificationResponse{Message: resendAnswer}, got)
+			}
+		})
+	}
+}
+
+func TestResendVerificationMailInBackground(t *testing.T) {
+	userService, m := newUserServiceWithMocks(t)
+	m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(&internal.Userdetail{ID: 2, Username: "johndoe", Mail: "john@example.com", Status: true}, nil).Once()
+	m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenVerify).Return(nil).Once()
+	m.tokenRepo.On("CreateMailToken", mock.Anything, mock.Anything).Return(nil).Once()
+
+	// the answer must not wait for the mail, or it would tell known users apart
+	answered := make(chan struct{})
+	m.mailer.On("Send", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		select {
+		case <-answered:
+		case <-time.After(5 * time.Second):
+			t.Error("the answer waited for the mail")
+		}
+	}).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/verify-email/resend", strings.NewReader(`{"username": "johndoe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	got, err := userService.ResendVerificationMail(req)
+	close(answered)
+	waitForMails(userService)
+
+	require.NoError(t, err)
+	assert.Equal(t, &dto.ResendVerificationResponse{Message: resendAnswer}, got)
+}
+
+func TestValidFor(t *testing.T) {
+	assert.Equal(t, "1 hour", validFor(time.Hour))
+	assert.Equal(t, "24 hours", validFor(24*time.Hour))
+	assert.Equal(t, "1 minute", validFor(time.Minute))
+	assert.Equal(t, "90 minutes", validFor(90*time.Minute))
+}
+
+func TestMailQueue(t *testing.T) {
+	t.Run("close_sends_the_queued_mails", func(t *testing.T) {
+		q := NewMailQueue(1, 10)
+		var sent []int
+		release := make(chan struct{})
+		for i := range 3 {
+			q.Enqueue(context.Background(), func(ctx context.Context) error {
+				<-release
+				sent = append(sent, i)
+				return nil
+			})
+		}
+		close(release)
+		require.NoError(t, q.Close(context.Background()))
+		assert.Equal(t, []int{0, 1, 2}, sent)
+
+		// nothing is taken after close
+		q.Enqueue(context.Background(), func(ctx context.Context) error {
+			t.Error("mail queued after close")
+			return nil
+		})
+	})
+
+	t.Run("full_queue_drops", func(t *testing.T) {
+		q := NewMailQueue(1, 1)
+		release := make(chan struct{})
+		started := make(chan struct{})
+		var sent int
+		q.Enqueue(context.Background(), func(ctx context.Context) error {
+			close(started)
+			<-release
+			sent++
+			return nil
+		})
+		<-started
+		q.Enqueue(context.Background(), func(ctx context.Context) error { sent++; return nil })
+		q.Enqueue(context.Background(), func(ctx context.Context) error {
+			t.Error("mail queued past the queue size")
+			return nil
+		})
+		close(release)
+		require.NoError(t, q.Close(context.Background()))
+		assert.Equal(t, 2, sent)
+	})
+
+	t.Run("close_gives_up_after_the_timeout", func(t *testing.T) {
+		q := NewMailQueue(1, 10)
+		release := make(chan struct{})
+		defer close(release)
+		q.Enqueue(context.Background(), func(ctx context.Context) error { <-release; return nil })
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, q.Close(ctx), context.DeadlineExceeded)
+	})
+
+	t.Run("request_cancel_does_not_reach_the_mail", func(t *testing.T) {
+		q := NewMailQueue(1, 10)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		q.Enqueue(ctx, func(ctx context.Context) error {
+			assert.NoError(t, ctx.Err())
+			return nil
+		})
+		require.NoError(t, q.Close(context.Background()))
+	})
+}
+
+func TestForgotPassword(t *testing.T) {
+	user := &internal.Userdetail{ID: 2, Username: "johndoe", Mail: "john@example.com", Status: true}
+
+	tests := []struct {
+		name    string
+		rbody   string
+		mock    func(m userMocks)
+		errCode int
+	}{
+		{
+			name:    "fail_missing_username",
+			rbody:   `{}`,
+			mock:    func(m userMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "unknown_user_looks_the_same",
+			rbody: `{"username": "nobody"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "nobody").Return(nil, gorm.ErrRecordNotFound).Once()
+			},
+		},
+		{
+			name:  "blocked_user",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(&internal.Userdetail{ID: 2, Status: false}, nil).Once()
+			},
+		},
+		{
+			name:  "fail_db_error",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(nil, errors.New("db error")).Once()
+			},
+			errCode: e.ErrResetPassword,
+		},
+		{
+			name:  "revoke_fails_looks_the_same",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(user, nil).Once()
+				m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenResetPassword).Return(errors.New("db error")).Once()
+			},
+		},
+		{
+			name:  "mail_fails_looks_the_same",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(user, nil).Once()
+				m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenResetPassword).Return(nil).Once()
+				m.tokenRepo.On("CreateMailToken", mock.Anything, mock.Anything).Return(nil).Once()
+				m.mailer.On("Send", mock.Anything, mock.Anything).Return(errors.New("smtp down")).Once()
+			},
+		},
+		{
+			name:  "success_case",
+			rbody: `{"username": "johndoe"}`,
+			mock: func(m userMocks) {
+				m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(user, nil).Once()
+				m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenResetPassword).Return(nil).Once()
+				m.tokenRepo.On("CreateMailToken", mock.Anything, mock.MatchedBy(func(token *internal.Mailtoken) bool {
+					return token.UserID == 2 && token.Purpose == internal.MailTokenResetPassword && len(token.TokenHash) == 64 &&
+						time.Until(token.ExpiresAt) <= 30*time.Minute
+				})).Return(nil).Once()
+				// the mail goes out after the request is over
+				m.mailer.On("Send", mock.MatchedBy(func(ctx context.Context) bool { return ctx.Err() == nil }), mock.MatchedBy(func(msg mail.Message) bool {
+					return msg.To == "john@example.com" && strings.Contains(msg.Body, "https://shop.example/password/reset?token=") &&
+						strings.Contains(msg.Body, "valid for 30 minutes")
+				})).Return(nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			req := httptest.NewRequestWithContext(ctx, "POST", "/password/forgot", strings.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := userService.ForgotPassword(req)
+			cancel()
+			waitForMails(userService)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &dto.ForgotPasswordResponse{Message: forgotAnswer}, got)
+			}
+		})
+	}
+}
+
+func TestForgotPasswordLooksTheSame(t *testing.T) {
+	userService, m := newUserServiceWithMocks(t)
+	m.userRepo.On("GetUserByUsername", mock.Anything, "nobody").Return(nil, gorm.ErrRecordNotFound).Once()
+	m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(&internal.Userdetail{ID: 2, Username: "johndoe", Mail: "john@example.com", Status: true}, nil).Once()
+	m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenResetPassword).Return(nil).Once()
+	m.tokenRepo.On("CreateMailToken", mock.Anything, mock.Anything).Return(nil).Once()
+
+	// the answer must not wait for the mail, or it would tell known users apart
+	answered := make(chan struct{})
+	m.mailer.On("Send", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		select {
+		case <-answered:
+		case <-time.After(5 * time.Second):
+			t.Error("the answer waited for the mail")
+		}
+	}).Return(nil).Once()
+
+	forgot := func(username string) (*dto.ForgotPasswordResponse, error) {
+		req := httptest.NewRequest("POST", "/password/forgot", strings.NewReader(`{"username": "`+username+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return userService.ForgotPassword(req)
+	}
+	unknown, unknownErr := forgot("nobody")
+	known, knownErr := forgot("johndoe")
+	close(answered)
+	waitForMails(userService)
+
+	require.NoError(t, unknownErr)
+	require.NoError(t, knownErr)
+	assert.Equal(t, &dto.ForgotPasswordResponse{Message: forgotAnswer}, unknown)
+	assert.Equal(t, unknown, known)
+}
+
+func TestForgotPasswordMailOutlivesShutdown(t *testing.T) {
+	userService, m := newUserServiceWithMocks(t)
+	m.userRepo.On("GetUserByUsername", mock.Anything, "johndoe").Return(&internal.Userdetail{ID: 2, Username: "johndoe", Mail: "john@example.com", Status: true}, nil).Once()
+	m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenResetPassword).Return(nil).Once()
+	m.tokenRepo.On("CreateMailToken", mock.Anything, mock.Anything).Return(nil).Once()
+
+	release := make(chan struct{})
+	sent := false
+	m.mailer.On("Send", mock.Anything, mock.Anything).Run(func(mock.Arguments) {
+		<-release
+		sent = true
+	}).Return(nil).Once()
+
+	req := httptest.NewRequest("POST", "/password/forgot", strings.NewReader(`{"username": "johndoe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	_, err := userService.ForgotPassword(req)
+	require.NoError(t, err)
+
+	// the shutdown starts while the mail is still on its way and waits for it
+	time.AfterFunc(10*time.Millisecond, func() { close(release) })
+	waitForMails(userService)
+	assert.True(t, sent)
+}
+
+func TestResetPassword(t *testing.T) {
+	tokenHash := jwt.HashRefreshToken("reset-token")
+	user := &internal.Userdetail{ID: 2, Username: "johndoe", Status: true}
+	auditEntry := mock.MatchedBy(func(entry *internal.Auditlog) bool {
+		return entry.UserID == 2 && entry.ActorID == 2 && entry.Action == internal.AuditPasswordReset && entry.IP == "192.0.2.1"
+	})
+
+	tests := []struct {
+		name    string
+		rbody   string
+		mock    func(m userMocks)
+		errCode int
+	}{
+		{
+			name:    "fail_missing_token",
+			rbody:   `{"password": "new secret 1"}`,
+			mock:    func(m userMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_too_short_password",
+			rbody: `{"token": "reset-token", "password": "short"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("FindMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+			},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_invalid_token",
+			rbody: `{"token": "reset-token", "password": "new secret 1"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("FindMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(nil, internal.ErrMailTokenInvalid).Once()
+			},
+			errCode: e.ErrInvalidMailToken,
+		},
+		{
+			name:  "fail_rejected_password_keeps_token",
+			rbody: `{"token": "reset-token", "password": "johndoe secret"}`,
+			mock: func(m userMocks) {
+				// no UseMailToken, the mail can be used again with a better password
+				m.tokenRepo.On("FindMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+			},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_token_used_meanwhile",
+			rbody: `{"token": "reset-token", "password": "new secret 1"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("FindMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+				m.tokenRepo.On("UseMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(nil, internal.ErrMailTokenInvalid).Once()
+			},
+			errCode: e.ErrInvalidMailToken,
+		},
+		{
+			name:  "fail_blocked_user",
+			rbody: `{"token": "reset-token", "password": "new secret 1"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("FindMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(&internal.Userdetail{ID: 2, Status: false}, nil).Once()
+			},
+			errCode: e.ErrUserBlocked,
+		},
+		{
+			name:  "fail_revoke_sessions",
+			rbody: `{"token": "reset-token", "password": "new secret 1"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("FindMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+				m.tokenRepo.On("UseMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.hasher.On("Hash", "new secret 1").Return("new-hash", nil).Once()
+				m.userRepo.On("ChangePassword", mock.Anything, int64(2), "new-hash").Return(nil).Once()
+				m.tokenRepo.On("RevokeUserRefreshTokens", mock.Anything, int64(2)).Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrResetPassword,
+		},
+		{
+			name:  "success_case",
+			rbody: `{"token": " reset-token ", "password": "new secret 1"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("FindMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+				m.tokenRepo.On("UseMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.hasher.On("Hash", "new secret 1").Return("new-hash", nil).Once()
+				m.userRepo.On("ChangePassword", mock.Anything, int64(2), "new-hash").Return(nil).Once()
+				m.tokenRepo.On("RevokeUserRefreshTokens", mock.Anything, int64(2)).Return(nil).Once()
+				m.auditRepo.On("Record", mock.Anything, auditEntry).Return(nil).Once()
+			},
+		},
+		{
+			name:  "success_audit_fails",
+			rbody: `{"token": "reset-token", "password": "new secret 1"}`,
+			mock: func(m userMocks) {
+				m.tokenRepo.On("FindMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+				m.tokenRepo.On("UseMailToken", mock.Anything, internal.MailTokenResetPassword, tokenHash).Return(&internal.Mailtoken{UserID: 2}, nil).Once()
+				m.hasher.On("Hash", "new secret 1").Return("new-hash", nil).Once()
+				m.userRepo.On("ChangePassword", mock.Anything, int64(2), "new-hash").Return(nil).Once()
+				m.tokenRepo.On("RevokeUserRefreshTokens", mock.Anything, int64(2)).Return(nil).Once()
+				m.auditRepo.On("Record", mock.Anything, auditEntry).Return(errors.New("db error")).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("POST", "/password/reset", strings.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+			req.RemoteAddr = "192.0.2.1:4711"
+
+			got, err := userService.ResetPassword(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &dto.PasswordChangedResponse{UserID: 2, SessionsRevoked: true}, got)
+			}
+		})
+	}
+}
+
+func TestChangePassword(t *testing.T) {
+	user := &internal.Userdetail{ID: 2, Username: "johndoe", Password: "old-hash", Status: true}
+
+	tests := []struct {
+		name    string
+		rbody   string
+		mock    func(m userMocks)
+		errCode int
+	}{
+		{
+			name:    "fail_missing_old_password",
+			rbody:   `{"new_password": "new secret 1"}`,
+			mock:    func(m userMocks) {},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_wrong_old_password",
+			rbody: `{"old_password": "guess", "new_password": "new secret 1"}`,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(2), nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+				m.hasher.On("Verify", "old-hash", "guess").Return(false, nil).Once()
+			},
+			errCode: e.ErrWrongPassword,
+		},
+		{
+			name:  "fail_password_policy",
+			rbody: `{"old_password": "old secret 1", "new_password": "johndoe1"}`,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(2), nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+				m.hasher.On("Verify", "old-hash", "old secret 1").Return(true, nil).Once()
+			},
+			errCode: e.ErrValidateRequest,
+		},
+		{
+			name:  "fail_db_error",
+			rbody: `{"old_password": "old secret 1", "new_password": "new secret 1"}`,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(2), nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+				m.hasher.On("Verify", "old-hash", "old secret 1").Return(true, nil).Once()
+				m.hasher.On("Hash", "new secret 1").Return("new-hash", nil).Once()
+				m.userRepo.On("ChangePassword", mock.Anything, int64(2), "new-hash").Return(errors.New("db error")).Once()
+			},
+			errCode: e.ErrChangePassword,
+		},
+		{
+			name:  "success_case",
+			rbody: `{"old_password": "old secret 1", "new_password": "new secret 1"}`,
+			mock: func(m userMocks) {
+				m.helper.On("GetUserID", mock.Anything).Return(int64(2), nil).Once()
+				m.userRepo.On("GetUserByID", mock.Anything, int64(2)).Return(user, nil).Once()
+				m.hasher.On("Verify", "old-hash", "old secret 1").Return(true, nil).Once()
+				m.hasher.On("Hash", "new secret 1").Return("new-hash", nil).Once()
+				m.userRepo.On("ChangePassword", mock.Anything, int64(2), "new-hash").Return(nil).Once()
+				m.tokenRepo.On("RevokeUserRefreshTokens", mock.Anything, int64(2)).Return(nil).Once()
+				m.auditRepo.On("Record", mock.Anything, mock.MatchedBy(func(entry *internal.Auditlog) bool {
+					return entry.UserID == 2 && entry.ActorID == 2 && entry.Action == internal.AuditPasswordChanged
+				})).Return(nil).Once()
+				// a reset link sent before is no longer wanted
+				m.tokenRepo.On("RevokeMailTokens", mock.Anything, int64(2), internal.MailTokenResetPassword).Return(nil).Once()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userService, m := newUserServiceWithMocks(t)
+			tt.mock(m)
+
+			req := httptest.NewRequest("PUT", "/me/password", strings.NewReader(tt.rbody))
+			req.Header.Set("Content-Type", "application/json")
+
+			got, err := userService.ChangePassword(req)
+
+			if tt.errCode != 0 {
+				require.Error(t, err)
+				assert.Nil(t, got)
+				assert.Equal(t, tt.errCode, err.(*e.WrapError).ErrorCode)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, &dto.PasswordChangedResponse{UserID: 2, SessionsRevoked: true}, got)
+			}
+		})
+	}
+}
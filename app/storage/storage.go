@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"sonartest_cart/app/gormdb"
+	"sonartest_cart/app/internal"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/config"
+	"sonartest_cart/pkg/metrics"
+
+	"gorm.io/gorm"
+)
+
+// Storage is the backend picked by db.driver
+type Storage struct {
+	Driver string
+	Repos  internal.Repos
+	// DB backs every repository that is not kept in memory
+	DB *gorm.DB
+}
+
+// Open connects the configured storage driver
+func Open(ctx context.Context, cfg config.DBConfig) (*Storage, error) {
+	switch cfg.Driver {
+	case config.DriverPostgres:
+		db, err := gormdb.ConnectDb(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &Storage{Driver: cfg.Driver, Repos: internal.NewRepos(db), DB: db}, nil
+
+	case config.DriverSQLite:
+		db, err := gormdb.OpenSQLite(cfg.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		return &Storage{Driver: cfg.Driver, Repos: internal.NewRepos(db), DB: db}, nil
+
+	case config.DriverMemory:
+		db, err := gormdb.OpenSQLite(gormdb.MemorySQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		repos := internal.NewRepos(db)
+		repos.User = internal.NewMemoryUserRepo()
+		return &Storage{Driver: cfg.Driver, Repos: repos, DB: db}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// Close releases the database connections
+func (s *Storage) Close() {
+	gormdb.Close(s.DB)
+}
+
+// RegisterHealthChecks adds the readiness checks for this backend
+func (s *Storage) RegisterHealthChecks(health *api.Health) {
+	health.AddCheck("database", s.checkDatabase)
+	if s.Driver == config.DriverPostgres {
+		health.AddCheck("migrations", s.checkMigrations)
+	}
+}
+
+// DatabaseStatus is the detail of the database readiness check
+type DatabaseStatus struct {
+	Driver          string `json:"driver"`
+	OpenConnections int    `json:"open_connections"`
+	InUse           int    `json:"in_use"`
+	Idle            int    `json:"idle"`
+}
+
+func (s *Storage) checkDatabase(ctx context.Context) (interface{}, error) {
+	sqlDb, err := s.DB.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlDb.PingContext(ctx); err != nil {
+		return nil, err
+	}
+
+	stats := sqlDb.Stats()
+	return DatabaseStatus{
+		Driver:          s.Driver,
+		OpenConnections: stats.OpenConnections,
+		InUse:           stats.InUse,
+		Idle:            stats.Idle,
+	}, nil
+}
+
+func (s *Storage) checkMigrations(ctx context.Context) (interface{}, error) {
+	migrator, err := gormdb.NewMigrator(s.DB)
+	if err != nil {
+		return nil, err
+	}
+	version, err := migrator.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version.Current != version.Latest {
+		return version, fmt.Errorf("schema is at version %d, expected %d", version.Current, version.Latest)
+	}
+	return version, nil
+}
+
+// RegisterMetrics exports the connection pool stats of the database and the
+// carts untouched for cartAbandonedAfter
+func (s *Storage) RegisterMetrics(cartAbandonedAfter time.Duration) error {
+	sqlDb, err := s.DB.DB()
+	if err != nil {
+		return err
+	}
+	if err := metrics.RegisterDBStats(sqlDb, s.Driver); err != nil {
+		return err
+	}
+	return metrics.RegisterAbandonedCarts(cartAbandonedAfter, s.Repos.Cart.CountIdleCarts)
+}
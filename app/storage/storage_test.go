@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"sonartest_cart/app/dto"
+	"sonartest_cart/app/internal"
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        config.DBConfig
+		memoryUser bool
+	}{
+		{
+			name: "sqlite",
+			cfg:  config.DBConfig{Driver: config.DriverSQLite, SQLitePath: filepath.Join(t.TempDir(), "cart.db")},
+		},
+		{
+			name:       "memory",
+			cfg:        config.DBConfig{Driver: config.DriverMemory},
+			memoryUser: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := Open(context.Background(), tt.cfg)
+			require.NoError(t, err)
+			defer store.Close()
+
+			_, isMemory := store.Repos.User.(*internal.MemoryUserRepo)
+			assert.Equal(t, tt.memoryUser, isMemory)
+
+			// the schema is in place for the gorm backed repos
+			categories, err := store.Repos.Product.ListCategories(context.Background())
+			require.NoError(t, err)
+			assert.Empty(t, categories)
+
+			id, err := store.Repos.User.SaveUserDetails(context.Background(), &dto.UserDetailSaveRequest{UserName: "johndoe", Password: "hash"})
+			require.NoError(t, err)
+			_, err = store.Repos.Cart.AddItem(context.Background(), id, 1, 1, 1, 10)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestOpenUnknownDriver(t *testing.T) {
+	_, err := Open(context.Background(), config.DBConfig{Driver: "mongo"})
+	assert.EqualError(t, err, `unknown storage driver "mongo"`)
+}
+
+func TestHealthChecks(t *testing.T) {
+	store, err := Open(context.Background(), config.DBConfig{Driver: config.DriverMemory})
+	require.NoError(t, err)
+	defer store.Close()
+
+	health := api.NewHealth()
+	store.RegisterHealthChecks(health)
+
+	rec := httptest.NewRecorder()
+	health.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"database":{"status":"up","detail":{"driver":"memory"`)
+	// the schema of sqlite and memory comes from the models, not migrations
+	assert.NotContains(t, rec.Body.String(), "migrations")
+
+	store.Close()
+	rec = httptest.NewRecorder()
+	health.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
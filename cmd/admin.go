@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"sonartest_cart/app/dto"
+	"sonartest_cart/app/storage"
+	"sonartest_cart/pkg/config"
+	"sonartest_cart/pkg/password"
+	"sonartest_cart/pkg/rbac"
+	"sonartest_cart/pkg/validation"
+
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+func init() {
+	createAdminCmd.Flags().String("mail", "", "mail address of the admin")
+	_ = createAdminCmd.MarkFlagRequired("mail")
+
+	rootCmd.AddCommand(createAdminCmd)
+}
+
+var createAdminCmd = &cobra.Command{
+	Use:   "create-admin <username>",
+	Short: "Create a user with the admin role",
+	Long: "Create a user with the admin role, e.g. the first admin of a new database.\n" +
+		"The password is read from the first line of stdin, so it stays out of the shell history.",
+	Args: cobra.ExactArgs(1),
+	Run:  CreateAdmin,
+}
+
+func CreateAdmin(cmd *cobra.Command, args []string) {
+	cfg, err := config.Load(cmd.Flags())
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if cfg.DB.Driver == config.DriverMemory {
+		log.Fatalf("create-admin: the %s driver forgets the user when the command exits", config.DriverMemory)
+	}
+
+	username := strings.TrimSpace(args[0])
+	mail, _ := cmd.Flags().GetString("mail")
+	mail = strings.TrimSpace(mail)
+	if err := validation.Validator().Var(username, "required,username"); err != nil {
+		log.Fatalf("create-admin: invalid username %q", username)
+	}
+	if err := validation.Validator().Var(mail, "required,email,max=254"); err != nil {
+		log.Fatalf("create-admin: invalid mail address %q", mail)
+	}
+
+	plain, err := readPassword(cmd)
+	if err != nil {
+		log.Fatalf("create-admin: %v", err)
+	}
+	if err := passwordPolicy(cfg.Password).Check("password", plain, username); err != nil {
+		var msgs []string
+		for _, f := range validation.Fields(err, validation.Translator("")) {
+			msgs = append(msgs, f.Message)
+		}
+		log.Fatalf("create-admin: %s", strings.Join(msgs, ", "))
+	}
+	hasher, err := password.NewPasswordHasher(cfg.Password.Algorithm)
+	if err != nil {
+		log.Fatalf("invalid password configuration: %v", err)
+	}
+	hash, err := hasher.Hash(plain)
+	if err != nil {
+		log.Fatalf("create-admin: %v", err)
+	}
+
+	store, err := storage.Open(cmd.Context(), cfg.DB)
+	if err != nil {
+		log.Fatalf("failed to open %s storage: %v", cfg.DB.Driver, err)
+	}
+	defer store.Close()
+
+	id, err := store.Repos.User.SaveUserDetails(cmd.Context(), &dto.UserDetailSaveRequest{
+		UserName: username,
+		Mail:     mail,
+		Country:  dto.DefaultCountry,
+		Password: hash,
+		Role:     rbac.RoleAdmin,
+		// whoever runs the command vouches for the address
+		MailVerified: true,
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			log.Fatalf("create-admin: user %s already exists", username)
+		}
+		log.Fatalf("create-admin: %v", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "created admin %s with id %d\n", username, id)
+}
+
+// readPassword reads the first line of stdin, prompting when a person types it
+func readPassword(cmd *cobra.Command) (string, error) {
+	fmt.Fprint(cmd.ErrOrStderr(), "Password: ")
+	line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	fmt.Fprintln(cmd.ErrOrStderr())
+	if err != nil && line == "" {
+		return "", fmt.Errorf("no password on stdin: %w", err)
+	}
+	plain := strings.TrimRight(line, "\r\n")
+	if plain == "" {
+		return "", errors.New("password must not be empty")
+	}
+	return plain, nil
+}
@@ -1,15 +1,28 @@
 package cmd
 
 import (
+	"context"
+	"net/http"
 	"sonartest_cart/app"
-	gormdb "sonartest_cart/app/gormdb"
+	"sonartest_cart/app/service"
+	"sonartest_cart/app/storage"
 	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/config"
+	"sonartest_cart/pkg/jwt"
+	"sonartest_cart/pkg/mail"
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/password"
+	"sonartest_cart/pkg/ratelimit"
+	"sonartest_cart/pkg/tracing"
 	"log"
 
+	"github.com/rs/zerolog"
+	zlog "github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 )
 
 func init() {
+	config.RegisterFlags(rootCmd.PersistentFlags())
 	rootCmd.AddCommand(apiCmd)
 }
 
@@ -26,14 +39,97 @@ var apiCmd = &cobra.Command{
 	Run:   StartAPI,
 }
 
-func StartAPI(*cobra.Command, []string) {
-	db, err := gormdb.ConnectDb()
+func StartAPI(cmd *cobra.Command, _ []string) {
+	cfg, err := config.Load(cmd.Flags())
 	if err != nil {
-		log.Fatalf("failed to connect to the database: %v", err)
+		log.Fatalf("%v", err)
 	}
 
-	r := app.APIRouter(db)
-	api.Start(r)
+	shutdownTracing, err := tracing.Setup(cmd.Context(), cfg.Tracing)
+	if err != nil {
+		log.Fatalf("failed to set up tracing: %v", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("failed to flush traces: %v", err)
+		}
+	}()
+
+	store, err := storage.Open(cmd.Context(), cfg.DB)
+	if err != nil {
+		log.Fatalf("failed to open %s storage: %v", cfg.DB.Driver, err)
+	}
+	defer store.Close()
+
+	jwtService, err := jwt.NewJWTService(cfg.JWT.ServiceConfig())
+	if err != nil {
+		log.Fatalf("failed to load jwt keys: %v", err)
+	}
+	hasher, err := password.NewPasswordHasher(cfg.Password.Algorithm)
+	if err != nil {
+		log.Fatalf("invalid password configuration: %v", err)
+	}
+	policy := passwordPolicy(cfg.Password)
+	mailer, err := mail.NewMailer(cfg.Mail.MailerConfig())
+	if err != nil {
+		log.Fatalf("invalid mail configuration: %v", err)
+	}
+	if cfg.Mail.Driver == mail.DriverNone && cfg.Mail.UnverifiedLogin != config.UnverifiedAllow {
+		zlog.Warn().Str("unverified_login", cfg.Mail.UnverifiedLogin).Msg("No mail driver configured, new users cannot verify their mail address")
+	}
+	mailOpts := service.MailOptions{
+		Mailer:          mailer,
+		VerifyURL:       cfg.Mail.VerifyURL,
+		VerifyTokenTTL:  cfg.Mail.VerifyTokenTTL,
+		ResetURL:        cfg.Mail.ResetURL,
+		ResetTokenTTL:   cfg.Mail.ResetTokenTTL,
+		UnverifiedLogin: cfg.Mail.UnverifiedLogin,
+		Queue:           service.NewMailQueue(cfg.Mail.Workers, cfg.Mail.QueueSize),
+	}
+
+	// code running outside a request still logs through log.Ctx
+	zerolog.DefaultContextLogger = &zlog.Logger
+
+	health := api.NewHealth()
+	store.RegisterHealthChecks(health)
+	if err := store.RegisterMetrics(cfg.Metrics.CartAbandonedAfter); err != nil {
+		log.Fatalf("failed to register database metrics: %v", err)
+	}
+	var metricsHandler http.Handler
+	if cfg.Metrics.Enabled {
+		metricsHandler = metrics.Handler(cfg.Metrics.Token)
+		if cfg.Metrics.Token == "" {
+			zlog.Warn().Msg("No metrics token configured, /metrics is open to anyone")
+		}
+	}
+
+	var limits app.RateLimits
+	if cfg.RateLimit.Enabled {
+		limitStore := ratelimit.NewMemoryStore()
+		limits = app.RateLimits{
+			LoginPerIP:   ratelimit.NewLimiter("login_ip", cfg.RateLimit.LoginPerIP.Limit(), limitStore),
+			LoginPerUser: ratelimit.NewLimiter("login_user", cfg.RateLimit.LoginPerUser.Limit(), limitStore),
+			SignupPerIP:  ratelimit.NewLimiter("signup_ip", cfg.RateLimit.SignupPerIP.Limit(), limitStore),
+			MailPerIP:    ratelimit.NewLimiter("mail_ip", cfg.RateLimit.MailPerIP.Limit(), limitStore),
+			TokenPerIP:   ratelimit.NewLimiter("token_ip", cfg.RateLimit.TokenPerIP.Limit(), limitStore),
+		}
+	}
+	// turning off the rate limits leaves the account lockout on
+	if cfg.RateLimit.Lockout.Enabled {
+		limits.Lockout = ratelimit.NewMemoryLockout(cfg.RateLimit.Lockout.Policy())
+	}
+
+	r := app.APIRouter(store.Repos, jwtService, hasher, policy, mailOpts, health, metricsHandler, limits)
+	api.Start(r, cfg.Server, health)
+
+	// mails accepted before the shutdown still go out, the store is closed after
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := mailOpts.Queue.Close(ctx); err != nil {
+		zlog.Error().Err(err).Msg("Mail queue not drained before shutdown")
+	}
 
 }
 
@@ -43,3 +139,17 @@ func Execute() {
 	}
 
 }
+
+// passwordPolicy is the configured policy including the breached password list
+func passwordPolicy(cfg config.PasswordConfig) password.Policy {
+	policy := cfg.Policy()
+	if cfg.BreachedFile != "" {
+		var err error
+		policy.Breached, err = password.LoadBreachedList(cfg.BreachedFile)
+		if err != nil {
+			log.Fatalf("failed to load breached passwords: %v", err)
+		}
+		zlog.Info().Int("passwords", policy.Breached.Len()).Msg("Loaded breached password list")
+	}
+	return policy
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+
+	"sonartest_cart/pkg/config"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	configCmd.AddCommand(configPrintCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the service configuration",
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "Print the effective configuration with secrets redacted",
+	Run:   PrintConfig,
+}
+
+func PrintConfig(cmd *cobra.Command, _ []string) {
+	cfg, err := config.Load(cmd.Flags())
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+
+	out, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		log.Fatalf("failed to encode config: %v", err)
+	}
+	fmt.Fprint(cmd.OutOrStdout(), string(out))
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	gormdb "sonartest_cart/app/gormdb"
+	"sonartest_cart/pkg/config"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	migrateCreateCmd.Flags().String("dir", gormdb.MigrationsDir, "directory the migration files are written to")
+
+	migrateCmd.AddCommand(migrateUpCmd, migrateDownCmd, migrateStatusCmd, migrateCreateCmd)
+	rootCmd.AddCommand(migrateCmd)
+}
+
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database schema migrations",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply all pending migrations",
+	Args:  cobra.NoArgs,
+	Run:   MigrateUp,
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down N",
+	Short: "Revert the last N applied migrations",
+	Args:  cobra.ExactArgs(1),
+	Run:   MigrateDown,
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show which migrations are applied",
+	Args:  cobra.NoArgs,
+	Run:   MigrateStatus,
+}
+
+var migrateCreateCmd = &cobra.Command{
+	Use:   "create <name>",
+	Short: "Create an empty up/down migration pair",
+	Args:  cobra.ExactArgs(1),
+	Run:   MigrateCreate,
+}
+
+func newMigrator(cmd *cobra.Command) *gormdb.Migrator {
+	cfg, err := config.Load(cmd.Flags())
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	// gormdb.Open always dials postgres, whatever driver is configured
+	if cfg.DB.Driver != config.DriverPostgres {
+		log.Fatalf("migrations only apply to the %s driver, %s gets its schema from the models (AutoMigrate) on startup", config.DriverPostgres, cfg.DB.Driver)
+	}
+	db, err := gormdb.Open(cmd.Context(), cfg.DB)
+	if err != nil {
+		log.Fatalf("failed to connect to the database: %v", err)
+	}
+	migrator, err := gormdb.NewMigrator(db)
+	if err != nil {
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+	return migrator
+}
+
+func MigrateUp(cmd *cobra.Command, _ []string) {
+	applied, err := newMigrator(cmd).Up(cmd.Context())
+	if err != nil {
+		log.Fatalf("migrate up: %v", err)
+	}
+	if len(applied) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "schema is up to date")
+	}
+	for _, m := range applied {
+		fmt.Fprintf(cmd.OutOrStdout(), "applied %04d_%s\n", m.Version, m.Name)
+	}
+}
+
+func MigrateDown(cmd *cobra.Command, args []string) {
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		log.Fatalf("migrate down: N must be a positive number, got %q", args[0])
+	}
+
+	reverted, err := newMigrator(cmd).Down(cmd.Context(), n)
+	if err != nil {
+		log.Fatalf("migrate down: %v", err)
+	}
+	for _, m := range reverted {
+		fmt.Fprintf(cmd.OutOrStdout(), "reverted %04d_%s\n", m.Version, m.Name)
+	}
+}
+
+func MigrateStatus(cmd *cobra.Command, _ []string) {
+	statuses, err := newMigrator(cmd).Status(cmd.Context())
+	if err != nil {
+		log.Fatalf("migrate status: %v", err)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range statuses {
+		appliedAt := "pending"
+		if s.AppliedAt != nil {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		if s.Unknown {
+			appliedAt += " (unknown to this build)"
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+	w.Flush()
+}
+
+func MigrateCreate(cmd *cobra.Command, args []string) {
+	dir, _ := cmd.Flags().GetString("dir")
+	files, err := gormdb.CreateMigration(dir, args[0])
+	if err != nil {
+		log.Fatalf("migrate create: %v", err)
+	}
+	for _, f := range files {
+		fmt.Fprintf(cmd.OutOrStdout(), "created %s\n", f)
+	}
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"sonartest_cart/pkg/e"
+)
+
+const (
+	CheckUp   = "up"
+	CheckDown = "down"
+
+	// defaultCheckTimeout bounds every readiness check, a hanging dependency must not hang the probe
+	defaultCheckTimeout = 2 * time.Second
+)
+
+// CheckFunc checks one dependency. The returned detail is shown in the
+// readiness response, e.g. pool stats or the schema version.
+type CheckFunc func(ctx context.Context) (interface{}, error)
+
+// HealthStatus is the result of /healthz and /readyz
+type HealthStatus struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the outcome of a single readiness check
+type CheckResult struct {
+	Status string      `json:"status"`
+	Detail interface{} `json:"detail,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+type namedCheck struct {
+	name string
+	fn   CheckFunc
+}
+
+// Health serves the liveness and readiness probes. Readiness fails once
+// shutdown starts so load balancers stop sending traffic before the
+// listener closes.
+type Health struct {
+	mu           sync.RWMutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+	timeout      time.Duration
+}
+
+func NewHealth() *Health {
+	return &Health{timeout: defaultCheckTimeout}
+}
+
+// AddCheck registers a dependency that must be up for the service to be ready
+func (h *Health) AddCheck(name string, fn CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, fn: fn})
+}
+
+// ShuttingDown makes readiness fail from now on
+func (h *Health) ShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Liveness only tells the process is serving requests, it never looks at dependencies
+func (h *Health) Liveness(w http.ResponseWriter, r *http.Request) {
+	Success(w, http.StatusOK, HealthStatus{Status: "alive"})
+}
+
+// Readiness runs every check and answers 503 when one is down
+func (h *Health) Readiness(w http.ResponseWriter, r *http.Request) {
+	if h.shuttingDown.Load() {
+		notReady(w, HealthStatus{Status: "shutting_down"}, []string{"server is shutting down"})
+		return
+	}
+
+	status := HealthStatus{Status: "ready", Checks: h.runChecks(r.Context())}
+
+	var failed []string
+	for name, result := range status.Checks {
+		if result.Status != CheckUp {
+			failed = append(failed, fmt.Sprintf("%s: %s", name, result.Error))
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		status.Status = "not_ready"
+		notReady(w, status, failed)
+		return
+	}
+	Success(w, http.StatusOK, status)
+}
+
+func (h *Health) runChecks(ctx context.Context) map[string]CheckResult {
+	h.mu.RLock()
+	checks := h.checks
+	h.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]CheckResult, len(checks))
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+			result := CheckResult{Status: CheckUp}
+			detail, err := c.fn(ctx)
+			result.Detail = detail
+			if err != nil {
+				result.Status = CheckDown
+				result.Error = err.Error()
+			}
+			mu.Lock()
+			results[c.name] = result
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+	return results
+}
+
+// notReady sends the failure envelope and keeps the check results in it
+func notReady(w http.ResponseWriter, status HealthStatus, details []string) {
+	result, err := json.Marshal(status)
+	if err != nil {
+		Fail(w, http.StatusServiceUnavailable, e.ErrNotReady, "service is not ready", details...)
+		return
+	}
+
+	respJson, err := json.Marshal(&Response{
+		Status: StatusFail,
+		Error: &ResponseError{
+			Code:      e.ErrNotReady,
+			Message:   "service is not ready",
+			Details:   details,
+			RequestID: w.Header().Get(RequestIDHeader),
+		},
+		Result: result,
+	})
+	if err != nil {
+		http.Error(
+			w,
+			http.StatusText(http.StatusInternalServerError),
+			http.StatusInternalServerError,
+		)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	w.Write(respJson)
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"sonartest_cart/pkg/e"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, handler http.HandlerFunc) (int, Response, HealthStatus) {
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var resp Response
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	var status HealthStatus
+	require.NoError(t, json.Unmarshal(resp.Result, &status))
+	return rec.Code, resp, status
+}
+
+func TestLiveness(t *testing.T) {
+	h := NewHealth()
+	h.AddCheck("database", func(ctx context.Context) (interface{}, error) {
+		return nil, errors.New("liveness must not run checks")
+	})
+
+	code, resp, status := serve(t, h.Liveness)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, StatusOk, resp.Status)
+	assert.Equal(t, "alive", status.Status)
+}
+
+func TestReadiness(t *testing.T) {
+	up := func(ctx context.Context) (interface{}, error) { return map[string]int{"version": 3}, nil }
+	down := func(ctx context.Context) (interface{}, error) { return nil, errors.New("connection refused") }
+	slow := func(ctx context.Context) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	tests := []struct {
+		name        string
+		checks      map[string]CheckFunc
+		shutdown    bool
+		wantCode    int
+		wantStatus  string
+		wantDetails []string
+	}{
+		{
+			name:       "all_up",
+			checks:     map[string]CheckFunc{"database": up, "migrations": up},
+			wantCode:   http.StatusOK,
+			wantStatus: "ready",
+		},
+		{
+			name:        "dependency_down",
+			checks:      map[string]CheckFunc{"database": down, "migrations": up},
+			wantCode:    http.StatusServiceUnavailable,
+			wantStatus:  "not_ready",
+			wantDetails: []string{"database: connection refused"},
+		},
+		{
+			name:        "check_times_out",
+			checks:      map[string]CheckFunc{"database": slow},
+			wantCode:    http.StatusServiceUnavailable,
+			wantStatus:  "not_ready",
+			wantDetails: []string{"database: context deadline exceeded"},
+		},
+		{
+			name:        "shutting_down",
+			checks:      map[string]CheckFunc{"database": up},
+			shutdown:    true,
+			wantCode:    http.StatusServiceUnavailable,
+			wantStatus:  "shutting_down",
+			wantDetails: []string{"server is shutting down"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealth()
+			h.timeout = 20 * time.Millisecond
+			for name, fn := range tt.checks {
+				h.AddCheck(name, fn)
+			}
+			if tt.shutdown {
+				h.ShuttingDown()
+			}
+
+			code, resp, status := serve(t, h.Readiness)
+			assert.Equal(t, tt.wantCode, code)
+			assert.Equal(t, tt.wantStatus, status.Status)
+			if tt.wantDetails == nil {
+				assert.Equal(t, StatusOk, resp.Status)
+				assert.Nil(t, resp.Error)
+				assert.Equal(t, CheckUp, status.Checks["database"].Status)
+				assert.Equal(t, map[string]interface{}{"version": float64(3)}, status.Checks["migrations"].Detail)
+				return
+			}
+			assert.Equal(t, StatusFail, resp.Status)
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, e.ErrNotReady, resp.Error.Code)
+			assert.Equal(t, tt.wantDetails, resp.Error.Details)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"sonartest_cart/pkg/e"
+)
+
+// DecodeJSON decodes the JSON body of r into dst. The body must be
+// application/json, hold exactly one JSON value and only fields dst knows.
+// Failures are *e.WrapError with a code per failure.
+func DecodeJSON(r *http.Request, dst interface{}) error {
+	return decodeJSON(r, dst, false)
+}
+
+// DecodeOptionalJSON is DecodeJSON for requests that may come without a
+// body, dst is left alone then
+func DecodeOptionalJSON(r *http.Request, dst interface{}) error {
+	return decodeJSON(r, dst, true)
+}
+
+func decodeJSON(r *http.Request, dst interface{}, optional bool) error {
+	if optional && (r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0) {
+		return nil
+	}
+	if err := checkContentType(r.Header.Get("Content-Type")); err != nil {
+		return e.NewError(e.ErrUnsupportedMediaType, "unsupported content type", err)
+	}
+	if r.Body == nil {
+		return e.NewError(e.ErrEmptyRequestBody, "empty request body", errors.New("request body must not be empty"))
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		if optional && errors.Is(err, io.EOF) {
+			return nil
+		}
+		return decodeError(err)
+	}
+
+	// anything but the end of the body after the value is rejected
+	var extra json.RawMessage
+	if err := decoder.Decode(&extra); !errors.Is(err, io.EOF) {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return decodeError(err)
+		}
+		return e.NewError(e.ErrTrailingData, "trailing data in request body", errors.New("request body must only contain a single JSON value"))
+	}
+	return nil
+}
+
+// checkContentType accepts application/json and JSON based types such as
+// application/merge-patch+json
+func checkContentType(contentType string) error {
+	if contentType == "" {
+		return errors.New("content type must be application/json")
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return fmt.Errorf("invalid content type: %w", err)
+	}
+	if mediaType != "application/json" && !(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")) {
+		return fmt.Errorf("content type must be application/json, got %s", mediaType)
+	}
+	return nil
+}
+
+// decodeError gives the client a readable reason instead of the raw
+// encoding/json message
+func decodeError(err error) error {
+	var (
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+		maxBytesErr *http.MaxBytesError
+	)
+	switch {
+	case errors.Is(err, io.EOF):
+		return e.NewError(e.ErrEmptyRequestBody, "empty request body", errors.New("request body must not be empty"))
+	case errors.As(err, &maxBytesErr):
+		return e.NewError(e.ErrRequestTooLarge, "request body too large", fmt.Errorf("request body must not be larger than %d bytes", maxBytesErr.Limit))
+	case errors.As(err, &syntaxErr):
+		return e.NewError(e.ErrDecodeRequestBody, "malformed request body", fmt.Errorf("request body contains malformed JSON at position %d", syntaxErr.Offset))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return e.NewError(e.ErrDecodeRequestBody, "malformed request body", errors.New("request body contains malformed JSON"))
+	case errors.As(err, &typeErr):
+		if typeErr.Field != "" {
+			return e.NewError(e.ErrDecodeRequestBody, "malformed request body", fmt.Errorf("request body field %q must be of type %s", typeErr.Field, typeErr.Type))
+		}
+		return e.NewError(e.ErrDecodeRequestBody, "malformed request body", fmt.Errorf("request body must be of type %s", typeErr.Type))
+	default:
+		if field, ok := unknownField(err); ok {
+			return e.NewError(e.ErrUnknownField, "unknown field in request body", fmt.Errorf("request body contains unknown field %s", field))
+		}
+		return e.NewError(e.ErrDecodeRequestBody, "malformed request body", err)
+	}
+}
+
+// unknownFieldPrefix starts the error of a json.Decoder with
+// DisallowUnknownFields for a field dst lacks. encoding/json has no error type
+// for it, so this pins the text of encoding/json/decode.go, TestUnknownField
+// breaks if a Go release changes it.
+const unknownFieldPrefix = "json: unknown field "
+
+// unknownField returns the quoted field name if err is the unknown field error
+// of encoding/json
+func unknownField(err error) (string, bool) {
+	return strings.CutPrefix(err.Error(), unknownFieldPrefix)
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sonartest_cart/pkg/e"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loginBody struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		// maxBody applies MaxBodySize when set
+		maxBody  int64
+		want     loginBody
+		wantCode int
+		wantErr  string
+	}{
+		{
+			name:        "valid",
+			contentType: "application/json",
+			body:        `{"username": "johndoe", "password": "secret"}`,
+			want:        loginBody{Username: "johndoe", Password: "secret"},
+		},
+		{
+			name:        "charset_and_whitespace",
+			contentType: "application/json; charset=utf-8",
+			body:        "\n{\"username\": \"johndoe\"}\n\n",
+			want:        loginBody{Username: "johndoe"},
+		},
+		{
+			name:        "json_suffix",
+			contentType: "application/merge-patch+json",
+			body:        `{"username": "johndoe"}`,
+			want:        loginBody{Username: "johndoe"},
+		},
+		{
+			name:     "missing_content_type",
+			body:     `{"username": "johndoe"}`,
+			wantCode: e.ErrUnsupportedMediaType,
+			wantErr:  "content type must be application/json",
+		},
+		{
+			name:        "form_content_type",
+			contentType: "application/x-www-form-urlencoded",
+			body:        `username=johndoe`,
+			wantCode:    e.ErrUnsupportedMediaType,
+			wantErr:     "content type must be application/json, got application/x-www-form-urlencoded",
+		},
+		{
+			name:        "empty_body",
+			contentType: "application/json",
+			wantCode:    e.ErrEmptyRequestBody,
+			wantErr:     "request body must not be empty",
+		},
+		{
+			name:        "unknown_field",
+			contentType: "application/json",
+			body:        `{"username": "johndoe", "isadmin": true}`,
+			wantCode:    e.ErrUnknownField,
+			wantErr:     `request body contains unknown field "isadmin"`,
+		},
+		{
+			name:        "two_values",
+			contentType: "application/json",
+			body:        `{"username": "johndoe"}{"username": "janedoe"}`,
+			wantCode:    e.ErrTrailingData,
+			wantErr:     "request body must only contain a single JSON value",
+		},
+		{
+			name:        "trailing_garbage",
+			contentType: "application/json",
+			body:        `{"username": "johndoe"} x`,
+			wantCode:    e.ErrTrailingData,
+			wantErr:     "request body must only contain a single JSON value",
+		},
+		{
+			name:        "malformed",
+			contentType: "application/json",
+			body:        `{"username": "johndoe",}`,
+			wantCode:    e.ErrDecodeRequestBody,
+			wantErr:     "request body contains malformed JSON at position 24",
+		},
+		{
+			name:        "truncated",
+			contentType: "application/json",
+			body:        `{"username": "johndoe"`,
+			wantCode:    e.ErrDecodeRequestBody,
+			wantErr:     "request body contains malformed JSON",
+		},
+		{
+			name:        "wrong_type",
+			contentType: "application/json",
+			body:        `{"username": 42}`,
+			wantCode:    e.ErrDecodeRequestBody,
+			wantErr:     `request body field "username" must be of type string`,
+		},
+		{
+			name:        "too_large",
+			contentType: "application/json",
+			body:        `{"username": "` + strings.Repeat("a", 100) + `"}`,
+			maxBody:     64,
+			wantCode:    e.ErrRequestTooLarge,
+			wantErr:     "request body must not be larger than 64 bytes",
+		},
+		{
+			name:        "too_large_after_value",
+			contentType: "application/json",
+			body:        `{"username": "johndoe"}` + strings.Repeat(" ", 100),
+			maxBody:     64,
+			wantCode:    e.ErrRequestTooLarge,
+			wantErr:     "request body must not be larger than 64 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got loginBody
+			var err error
+			handler := http.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				err = DecodeJSON(r, &got)
+			}))
+			if tt.maxBody > 0 {
+				handler = MaxBodySize(tt.maxBody, handler)
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			if tt.wantCode == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				return
+			}
+			require.Error(t, err)
+			wrapErr, ok := err.(*e.WrapError)
+			require.True(t, ok, "expected *e.WrapError, got %T", err)
+			assert.Equal(t, tt.wantCode, wrapErr.ErrorCode)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUnknownField(t *testing.T) {
+	// the error comes from encoding/json itself, not from DecodeJSON
+	decoder := json.NewDecoder(strings.NewReader(`{"username": "johndoe", "admin": true}`))
+	decoder.DisallowUnknownFields()
+	var dst loginBody
+	err := decoder.Decode(&dst)
+	require.Error(t, err)
+
+	field, ok := unknownField(err)
+	require.True(t, ok, "encoding/json changed its unknown field error: %q", err.Error())
+	assert.Equal(t, `"admin"`, field)
+
+	_, ok = unknownField(errors.New("json: cannot unmarshal number into Go value of type string"))
+	assert.False(t, ok)
+}
+
+func TestDecodeOptionalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     io.Reader
+		want     loginBody
+		wantCode int
+	}{
+		{name: "no_body", body: nil},
+		{name: "empty_body", body: strings.NewReader("")},
+		{name: "body", body: strings.NewReader(`{"username": "johndoe"}`), want: loginBody{Username: "johndoe"}},
+		{name: "unknown_field", body: strings.NewReader(`{"refresh": "x"}`), wantCode: e.ErrUnknownField},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/logout", tt.body)
+			req.Header.Set("Content-Type", "application/json")
+
+			var got loginBody
+			err := DecodeOptionalJSON(req, &got)
+			if tt.wantCode == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, err.(*e.WrapError).ErrorCode)
+		})
+	}
+}
@@ -3,11 +3,17 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/validation"
 )
 
 const (
 	StatusOk   = "ok"
 	StatusFail = "notok"
+
+	// RequestIDHeader carries the id of the request, it is set on the response before any handler runs
+	RequestIDHeader = "X-Request-ID"
 )
 
 type Response struct {
@@ -17,9 +23,12 @@ type Response struct {
 }
 
 type ResponseError struct {
-	Code    int      `json:"code"`
-	Message string   `json:"message"`
-	Details []string `json:"details"`
+	Code      int      `json:"code"`
+	Message   string   `json:"message"`
+	Details   []string `json:"details"`
+	RequestID string   `json:"request_id,omitempty"`
+	// Fields is set when the request body failed validation
+	Fields []validation.FieldError `json:"fields,omitempty"`
 }
 
 func (e ResponseError) Error() string {
@@ -62,14 +71,43 @@ func Success(w http.ResponseWriter, status int, result interface{}) {
 
 // Fail sends an unsuccesful JSON response with the standared failure format
 func Fail(w http.ResponseWriter, status, errCode int, msg string, details ...string) {
+	writeFail(w, status, &ResponseError{
+		Code:    errCode,
+		Message: msg,
+		Details: details,
+	})
+}
+
+// Error sends the failure response for err. Validation failures list every
+// field with a message in the language asked for by Accept-Language.
+func Error(w http.ResponseWriter, r *http.Request, status, errCode int, msg string, err error) {
+	respErr := &ResponseError{
+		Code:    errCode,
+		Message: msg,
+		Details: []string{err.Error()},
+	}
+
+	trans := validation.Translator(r.Header.Get("Accept-Language"))
+	if fields := validation.Fields(err, trans); fields != nil {
+		respErr.Fields = fields
+		respErr.Details = make([]string, len(fields))
+		for i, field := range fields {
+			respErr.Details[i] = field.Message
+		}
+		w.Header().Set("Content-Language", trans.Locale())
+		w.Header().Add("Vary", "Accept-Language")
+	}
+	writeFail(w, status, respErr)
+}
+
+func writeFail(w http.ResponseWriter, status int, respErr *ResponseError) {
+	metrics.APIError(respErr.Code)
+	respErr.RequestID = w.Header().Get(RequestIDHeader)
+
 	// Give error response to client
 	r := &Response{
 		Status: StatusFail,
-		Error: &ResponseError{
-			Code:    errCode,
-			Message: msg,
-			Details: details,
-		},
+		Error:  respErr,
 	}
 
 	respJson, err := json.Marshal(r)
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"sonartest_cart/pkg/e"
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/validation"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type signup struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+func TestError(t *testing.T) {
+	validationErr := e.NewError(e.ErrValidateRequest, "error while validating",
+		validation.Struct(&signup{Password: "short"}))
+
+	tests := []struct {
+		name            string
+		acceptLanguage  string
+		err             error
+		wantDetails     []string
+		wantFields      []validation.FieldError
+		wantContentLang string
+	}{
+		{
+			name:        "plain_error",
+			err:         e.NewError(e.ErrUserNotFound, "user not found", errors.New("record not found")),
+			wantDetails: []string{"record not found"},
+		},
+		{
+			name:        "validation_error",
+			err:         validationErr,
+			wantDetails: []string{"username is required", "password must be at least 8 characters long"},
+			wantFields: []validation.FieldError{
+				{Field: "username", Rule: "required", Message: "username is required"},
+				{Field: "password", Rule: "min", Param: "8", Message: "password must be at least 8 characters long"},
+			},
+			wantContentLang: "en",
+		},
+		{
+			name:           "validation_error_in_french",
+			acceptLanguage: "fr-FR,fr;q=0.9",
+			err:            validationErr,
+			wantDetails:    []string{"username est obligatoire", "password doit contenir au moins 8 caractères"},
+			wantFields: []validation.FieldError{
+				{Field: "username", Rule: "required", Message: "username est obligatoire"},
+				{Field: "password", Rule: "min", Param: "8", Message: "password doit contenir au moins 8 caractères"},
+			},
+			wantContentLang: "fr",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/signup", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			rec := httptest.NewRecorder()
+			rec.Header().Set(RequestIDHeader, "req-1")
+
+			apiErr := e.NewAPIError(tt.err, "failed to create user")
+			count := testutil.ToFloat64(metrics.APIErrors.WithLabelValues(strconv.Itoa(apiErr.Code)))
+			Error(rec, req, apiErr.StatusCode, apiErr.Code, apiErr.Message, tt.err)
+			assert.Equal(t, count+1, testutil.ToFloat64(metrics.APIErrors.WithLabelValues(strconv.Itoa(apiErr.Code))))
+
+			var resp Response
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			require.NotNil(t, resp.Error)
+			assert.Equal(t, apiErr.StatusCode, rec.Code)
+			assert.Equal(t, StatusFail, resp.Status)
+			assert.Equal(t, apiErr.Code, resp.Error.Code)
+			assert.Equal(t, "req-1", resp.Error.RequestID)
+			assert.Equal(t, tt.wantDetails, resp.Error.Details)
+			assert.Equal(t, tt.wantFields, resp.Error.Fields)
+			assert.Equal(t, tt.wantContentLang, rec.Header().Get("Content-Language"))
+		})
+	}
+}
+
+func TestFailCountsErrorCode(t *testing.T) {
+	code := func(c string) float64 { return testutil.ToFloat64(metrics.APIErrors.WithLabelValues(c)) }
+	before, other := code("401999"), code("403999")
+
+	Fail(httptest.NewRecorder(), http.StatusUnauthorized, 401999, "Invalid token")
+	Fail(httptest.NewRecorder(), http.StatusUnauthorized, 401999, "Invalid token")
+
+	assert.Equal(t, before+2, code("401999"))
+	assert.Equal(t, other, code("403999"), "only the code of the response is counted")
+}
@@ -2,70 +2,72 @@ package api
 
 import (
 	"context"
-	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"syscall"
 	"time"
 
-	"github.com/go-chi/chi/v5"
-)
-
-const (
-	// DefaultReadHeaderTimeOut is to read deadline just after connection is accepted
-	// The default is 5 seconds
-	DefaultReadHeaderTimeOut = 5 * time.Second
-
-	// DefaultReadTimeOut is to read deadline just after connection is accepted
-	// The default is 120 seconds
-	DefaultReadTimeOut = 120 * time.Second
+	"sonartest_cart/pkg/config"
 
-	// DefaultWriteTimeOut is the write deadline,
-	// default is 0 second
-	DefaultWriteTimeOut = 0 * time.Second
-
-	// DefaultIdleTimeOut is the write deadline,
-	DefaultIdleTimeOut = 60 * time.Second
-
-	// Shutdown timeout
-	ShutdownTimeout = 5 * time.Second
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
 )
 
-func Start(r chi.Router) {
+func Start(r chi.Router, cfg config.ServerConfig, health *Health) {
 
 	server := http.Server{
-		Addr:              ":8080",
-		ReadHeaderTimeout: DefaultReadHeaderTimeOut,
-		ReadTimeout:       DefaultReadTimeOut,
-		WriteTimeout:      DefaultWriteTimeOut,
-		IdleTimeout:       DefaultIdleTimeOut,
-		Handler:           r,
+		Addr:              cfg.Addr,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		Handler:           MaxBodySize(cfg.MaxBodyBytes, r),
 	}
-	StartHTTPServer(&server)
+	StartHTTPServer(&server, cfg, health)
 
 }
-func StartHTTPServer(s *http.Server) {
+
+// MaxBodySize caps the request bodies read by next, DecodeJSON turns
+// reading past the cap into a 413
+func MaxBodySize(limit int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = http.MaxBytesReader(w, r.Body, limit)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func StartHTTPServer(s *http.Server, cfg config.ServerConfig, health *Health) {
 	shutdownComplete := make(chan struct{})
 
-	// handle SIGINT and perform graceful shutdown
+	// handle SIGINT/SIGTERM and perform graceful shutdown
 	go func() {
 		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, os.Interrupt)
+		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
 		<-sigint
 
-		ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+		// fail readiness first and give load balancers time to notice
+		health.ShuttingDown()
+		if cfg.DrainDelay > 0 {
+			log.Info().Dur("drain_delay", cfg.DrainDelay).Msg("HTTP server draining")
+			time.Sleep(cfg.DrainDelay)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
 		defer cancel()
 
 		if err := s.Shutdown(ctx); err != nil {
-			log.Printf("HTTP server Shutdown: %v", err)
+			log.Error().Err(err).Msg("HTTP server Shutdown")
 			os.Exit(1)
 		}
 		close(shutdownComplete)
 	}()
 
-	log.Printf("HTTP server listening to %v", s.Addr)
+	log.Info().Str("addr", s.Addr).Msg("HTTP server listening")
 	if err := s.ListenAndServe(); err != http.ErrServerClosed {
-		log.Fatalf("HTTP server ListenAndServe: %v", err)
+		log.Fatal().Err(err).Msg("HTTP server ListenAndServe")
 	}
 
 	<-shutdownComplete
@@ -0,0 +1,8 @@
+// Package auth holds what the repositories and the HTTP middleware share
+// about the users they authenticate, without depending on each other.
+package auth
+
+import "errors"
+
+// ErrUserNotFound is returned by a user lookup for a user that doesn't exist
+var ErrUserNotFound = errors.New("user not found")
@@ -0,0 +1,763 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	netmail "net/mail"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"sonartest_cart/pkg/jwt"
+	"sonartest_cart/pkg/mail"
+	"sonartest_cart/pkg/password"
+	"sonartest_cart/pkg/ratelimit"
+
+	"github.com/joho/godotenv"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+const (
+	// DefaultKeyID is the kid given to the HS256 key built from jwt.secret
+	DefaultKeyID = "default"
+
+	// minSecretLength is the shortest HS256 secret we accept (256 bits)
+	minSecretLength = 32
+
+	redacted = "[REDACTED]"
+)
+
+// Storage drivers
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+	// DriverMemory keeps users in memory and everything else in an in-memory
+	// SQLite database, nothing survives a restart
+	DriverMemory = "memory"
+)
+
+// Tracing exporters
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// What users who haven't verified their mail address yet may do
+const (
+	UnverifiedAllow = "allow"
+	// UnverifiedLimit lets them log in but not place orders
+	UnverifiedLimit = "limit"
+	UnverifiedBlock = "block"
+)
+
+// Config is the effective configuration of the service. Values are loaded in
+// increasing order of precedence: defaults, the config file, environment
+// variables and command line flags.
+type Config struct {
+	Server    ServerConfig    `mapstructure:"server" yaml:"server"`
+	DB        DBConfig        `mapstructure:"db" yaml:"db"`
+	JWT       JWTConfig       `mapstructure:"jwt" yaml:"jwt"`
+	Password  PasswordConfig  `mapstructure:"password" yaml:"password"`
+	Tracing   TracingConfig   `mapstructure:"tracing" yaml:"tracing"`
+	RateLimit RateLimitConfig `mapstructure:"ratelimit" yaml:"ratelimit"`
+	Mail      MailConfig      `mapstructure:"mail" yaml:"mail"`
+	Metrics   MetricsConfig   `mapstructure:"metrics" yaml:"metrics"`
+}
+
+// ServerConfig holds the HTTP server settings
+type ServerConfig struct {
+	Addr              string        `mapstructure:"addr" yaml:"addr"`
+	ReadHeaderTimeout time.Duration `mapstructure:"read_header_timeout" yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `mapstructure:"read_timeout" yaml:"read_timeout"`
+	WriteTimeout      time.Duration `mapstructure:"write_timeout" yaml:"write_timeout"` // 0 means no timeout
+	IdleTimeout       time.Duration `mapstructure:"idle_timeout" yaml:"idle_timeout"`
+	ShutdownTimeout   time.Duration `mapstructure:"shutdown_timeout" yaml:"shutdown_timeout"`
+	// DrainDelay is how long /readyz fails before the listener closes, so
+	// load balancers notice and stop routing new requests
+	DrainDelay time.Duration `mapstructure:"drain_delay" yaml:"drain_delay"`
+	// MaxBodyBytes caps every request body, larger bodies get a 413
+	MaxBodyBytes int64 `mapstructure:"max_body_bytes" yaml:"max_body_bytes"`
+}
+
+// DBConfig holds the storage settings, the connection fields apply to postgres
+type DBConfig struct {
+	Driver     string `mapstructure:"driver" yaml:"driver"`
+	SQLitePath string `mapstructure:"sqlite_path" yaml:"sqlite_path"`
+
+	Host     string `mapstructure:"host" yaml:"host"`
+	Port     int    `mapstructure:"port" yaml:"port"`
+	User     string `mapstructure:"user" yaml:"user"`
+	Password string `mapstructure:"password" yaml:"password"`
+	Name     string `mapstructure:"name" yaml:"name"`
+	SSLMode  string `mapstructure:"sslmode" yaml:"sslmode"`
+
+	// Pool settings, 0 leaves the database/sql default (unlimited)
+	MaxOpenConns    int           `mapstructure:"max_open_conns" yaml:"max_open_conns"`
+	MaxIdleConns    int           `mapstructure:"max_idle_conns" yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime" yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"conn_max_idle_time" yaml:"conn_max_idle_time"`
+
+	// StatementTimeout aborts any statement running longer, 0 disables it
+	StatementTimeout time.Duration `mapstructure:"statement_timeout" yaml:"statement_timeout"`
+
+	// ConnectAttempts is how often to try reaching the database at startup,
+	// waiting ConnectBackoff after the first failure and doubling up to ConnectMaxBackoff
+	ConnectAttempts   int           `mapstructure:"connect_attempts" yaml:"connect_attempts"`
+	ConnectBackoff    time.Duration `mapstructure:"connect_backoff" yaml:"connect_backoff"`
+	ConnectMaxBackoff time.Duration `mapstructure:"connect_max_backoff" yaml:"connect_max_backoff"`
+}
+
+// JWTConfig holds the token signing keys and lifetimes. Secret is a shortcut
+// for a single HS256 key, Keys lists HS256/RS256/EdDSA keys for rotation.
+type JWTConfig struct {
+	ActiveKeyID     string         `mapstructure:"active_kid" yaml:"active_kid"`
+	Secret          string         `mapstructure:"secret" yaml:"secret"`
+	Keys            []JWTKeyConfig `mapstructure:"keys" yaml:"keys"`
+	AccessTokenTTL  time.Duration  `mapstructure:"access_token_ttl" yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration  `mapstructure:"refresh_token_ttl" yaml:"refresh_token_ttl"`
+}
+
+// JWTKeyConfig is a single signing or verification key
+type JWTKeyConfig struct {
+	ID        string `mapstructure:"id" yaml:"id"`
+	Algorithm string `mapstructure:"algorithm" yaml:"algorithm"`
+	Secret    string `mapstructure:"secret" yaml:"secret,omitempty"`
+	KeyFile   string `mapstructure:"key_file" yaml:"key_file,omitempty"`
+}
+
+// PasswordConfig selects the hashing algorithm and the strength policy for new passwords
+type PasswordConfig struct {
+	Algorithm     string `mapstructure:"algorithm" yaml:"algorithm"`
+	MinLength     int    `mapstructure:"min_length" yaml:"min_length"`
+	MaxLength     int    `mapstructure:"max_length" yaml:"max_length"`
+	RequireUpper  bool   `mapstructure:"require_upper" yaml:"require_upper"`
+	RequireLower  bool   `mapstructure:"require_lower" yaml:"require_lower"`
+	RequireDigit  bool   `mapstructure:"require_digit" yaml:"require_digit"`
+	RequireSymbol bool   `mapstructure:"require_symbol" yaml:"require_symbol"`
+	// BreachedFile lists passwords known from breaches, one per line as
+	// plain text or SHA-1 hex. Empty skips the check.
+	BreachedFile string `mapstructure:"breached_file" yaml:"breached_file"`
+}
+
+// Policy is the strength policy without the breached list, that one has to
+// be loaded from BreachedFile
+func (c PasswordConfig) Policy() password.Policy {
+	policy := password.Policy{
+		MinLength:     c.MinLength,
+		MaxLength:     c.MaxLength,
+		RequireUpper:  c.RequireUpper,
+		RequireLower:  c.RequireLower,
+		RequireDigit:  c.RequireDigit,
+		RequireSymbol: c.RequireSymbol,
+	}
+	// max_length counts characters, a multi-byte password can still pass 72 bytes
+	if c.Algorithm == password.AlgorithmBcrypt {
+		policy.MaxBytes = password.BcryptMaxBytes
+	}
+	return policy
+}
+
+// MailConfig selects how mails are sent and what users with an unverified
+// mail address may do
+type MailConfig struct {
+	Driver string `mapstructure:"driver" yaml:"driver"`
+	From   string `mapstructure:"from" yaml:"from"`
+
+	SMTPHost     string        `mapstructure:"smtp_host" yaml:"smtp_host"`
+	SMTPPort     int           `mapstructure:"smtp_port" yaml:"smtp_port"`
+	SMTPUsername string        `mapstructure:"smtp_username" yaml:"smtp_username"`
+	SMTPPassword string        `mapstructure:"smtp_password" yaml:"smtp_password"`
+	SMTPTimeout  time.Duration `mapstructure:"smtp_timeout" yaml:"smtp_timeout"`
+
+	// OutboxDir is where the file driver writes its .eml files
+	OutboxDir string `mapstructure:"outbox_dir" yaml:"outbox_dir"`
+
+	// VerifyURL is the page the verification link points to, the token is
+	// added as the token query parameter
+	VerifyURL      string        `mapstructure:"verify_url" yaml:"verify_url"`
+	VerifyTokenTTL time.Duration `mapstructure:"verify_token_ttl" yaml:"verify_token_ttl"`
+	// ResetURL is the page the password reset link points to, the same way
+	ResetURL      string        `mapstructure:"reset_url" yaml:"reset_url"`
+	ResetTokenTTL time.Duration `mapstructure:"reset_token_ttl" yaml:"reset_token_ttl"`
+	// UnverifiedLogin is one of the Unverified* values
+	UnverifiedLogin string `mapstructure:"unverified_login" yaml:"unverified_login"`
+
+	// Workers send the mails in the background, up to QueueSize mails wait
+	// for them before new ones are dropped
+	Workers   int `mapstructure:"workers" yaml:"workers"`
+	QueueSize int `mapstructure:"queue_size" yaml:"queue_size"`
+}
+
+// MailerConfig converts the settings into a mail.Config
+func (c MailConfig) MailerConfig() mail.Config {
+	return mail.Config{
+		Driver:       c.Driver,
+		From:         c.From,
+		SMTPHost:     c.SMTPHost,
+		SMTPPort:     c.SMTPPort,
+		SMTPUsername: c.SMTPUsername,
+		SMTPPassword: c.SMTPPassword,
+		SMTPTimeout:  c.SMTPTimeout,
+		OutboxDir:    c.OutboxDir,
+	}
+}
+
+// TracingConfig selects where OpenTelemetry spans are sent
+type TracingConfig struct {
+	Exporter string `mapstructure:"exporter" yaml:"exporter"`
+	// Endpoint is the host:port of the OTLP/HTTP collector, empty falls back
+	// to OTEL_EXPORTER_OTLP_ENDPOINT and then localhost:4318
+	Endpoint    string  `mapstructure:"endpoint" yaml:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure" yaml:"insecure"`
+	ServiceName string  `mapstructure:"service_name" yaml:"service_name"`
+	SampleRatio float64 `mapstructure:"sample_ratio" yaml:"sample_ratio"`
+}
+
+// MetricsConfig controls the Prometheus endpoint /metrics
+type MetricsConfig struct {
+	Enabled bool `mapstructure:"enabled" yaml:"enabled"`
+	// Token is the bearer token scrapers have to send, empty leaves /metrics open
+	Token string `mapstructure:"token" yaml:"token"`
+	// CartAbandonedAfter is how long a cart stays untouched before it counts
+	// as abandoned
+	CartAbandonedAfter time.Duration `mapstructure:"cart_abandoned_after" yaml:"cart_abandoned_after"`
+}
+
+// RateLimitConfig protects /login, /signup and the mail and token endpoints against brute force
+type RateLimitConfig struct {
+	Enabled      bool          `mapstructure:"enabled" yaml:"enabled"`
+	LoginPerIP   LimitConfig   `mapstructure:"login_per_ip" yaml:"login_per_ip"`
+	LoginPerUser LimitConfig   `mapstructure:"login_per_user" yaml:"login_per_user"`
+	SignupPerIP  LimitConfig   `mapstructure:"signup_per_ip" yaml:"signup_per_ip"`
+	MailPerIP    LimitConfig   `mapstructure:"mail_per_ip" yaml:"mail_per_ip"`   // endpoints that send a mail on request
+	TokenPerIP   LimitConfig   `mapstructure:"token_per_ip" yaml:"token_per_ip"` // endpoints that redeem a refresh or mail token
+	Lockout      LockoutConfig `mapstructure:"lockout" yaml:"lockout"`
+}
+
+// LimitConfig allows Burst requests at once, refilled at Requests per Per
+type LimitConfig struct {
+	Requests int           `mapstructure:"requests" yaml:"requests"`
+	Per      time.Duration `mapstructure:"per" yaml:"per"`
+	Burst    int           `mapstructure:"burst" yaml:"burst"`
+}
+
+func (c LimitConfig) Limit() ratelimit.Limit {
+	return ratelimit.Limit{Requests: c.Requests, Per: c.Per, Burst: c.Burst}
+}
+
+// LockoutConfig locks an account for Duration after Threshold failed logins
+// within Window. It is switched on and off apart from the rate limits.
+type LockoutConfig struct {
+	Enabled   bool          `mapstructure:"enabled" yaml:"enabled"`
+	Threshold int           `mapstructure:"threshold" yaml:"threshold"`
+	Window    time.Duration `mapstructure:"window" yaml:"window"`
+	Duration  time.Duration `mapstructure:"duration" yaml:"duration"`
+}
+
+func (c LockoutConfig) Policy() ratelimit.LockoutPolicy {
+	return ratelimit.LockoutPolicy{Threshold: c.Threshold, Window: c.Window, Duration: c.Duration}
+}
+
+var defaults = map[string]interface{}{
+	"server.addr":                ":8080",
+	"server.read_header_timeout": 5 * time.Second,
+	"server.read_timeout":        120 * time.Second,
+	"server.write_timeout":       0 * time.Second,
+	"server.idle_timeout":        60 * time.Second,
+	"server.shutdown_timeout":    5 * time.Second,
+	"server.drain_delay":         5 * time.Second,
+	"server.max_body_bytes":      1 << 20,
+
+	"db.driver":      DriverPostgres,
+	"db.sqlite_path": "sonartest_cart.db",
+
+	"db.host":     "localhost",
+	"db.port":     5432,
+	"db.user":     "",
+	"db.password": "",
+	"db.name":     "",
+	"db.sslmode":  "disable",
+
+	"db.max_open_conns":      25,
+	"db.max_idle_conns":      10,
+	"db.conn_max_lifetime":   30 * time.Minute,
+	"db.conn_max_idle_time":  5 * time.Minute,
+	"db.statement_timeout":   30 * time.Second,
+	"db.connect_attempts":    5,
+	"db.connect_backoff":     500 * time.Millisecond,
+	"db.connect_max_backoff": 10 * time.Second,
+
+	"jwt.active_kid":        "",
+	"jwt.secret":            "",
+	"jwt.keys":              []JWTKeyConfig{},
+	"jwt.access_token_ttl":  jwt.DefaultAccessTokenTTL,
+	"jwt.refresh_token_ttl": jwt.DefaultRefreshTokenTTL,
+
+	"password.algorithm":      password.AlgorithmBcrypt,
+	"password.min_length":     8,
+	"password.max_length":     64,
+	"password.require_upper":  true,
+	"password.require_lower":  true,
+	"password.require_digit":  true,
+	"password.require_symbol": false,
+	"password.breached_file":  "",
+
+	"tracing.exporter":     ExporterNone,
+	"tracing.endpoint":     "",
+	"tracing.insecure":     false,
+	"tracing.service_name": "sonartest_cart",
+	"tracing.sample_ratio": 1.0,
+
+	"ratelimit.enabled":                 true,
+	"ratelimit.login_per_ip.requests":   20,
+	"ratelimit.login_per_ip.per":        time.Minute,
+	"ratelimit.login_per_ip.burst":      10,
+	"ratelimit.login_per_user.requests": 10,
+	"ratelimit.login_per_user.per":      time.Minute,
+	"ratelimit.login_per_user.burst":    5,
+	"ratelimit.signup_per_ip.requests":  5,
+	"ratelimit.signup_per_ip.per":       time.Minute,
+	"ratelimit.signup_per_ip.burst":     5,
+	"ratelimit.mail_per_ip.requests":    3,
+	"ratelimit.mail_per_ip.per":         time.Minute,
+	"ratelimit.mail_per_ip.burst":       3,
+	"ratelimit.token_per_ip.requests":   30,
+	"ratelimit.token_per_ip.per":        time.Minute,
+	"ratelimit.token_per_ip.burst":      10,
+	"ratelimit.lockout.enabled":         true,
+	"ratelimit.lockout.threshold":       5,
+	"ratelimit.lockout.window":          15 * time.Minute,
+	"ratelimit.lockout.duration":        15 * time.Minute,
+
+	"mail.driver":           mail.DriverNone,
+	"mail.from":             "sonartest_cart <no-reply@localhost>",
+	"mail.smtp_host":        "",
+	"mail.smtp_port":        587,
+	"mail.smtp_username":    "",
+	"mail.smtp_password":    "",
+	"mail.smtp_timeout":     mail.DefaultSMTPTimeout,
+	"mail.outbox_dir":       "outbox",
+	"mail.verify_url":       "http://localhost:8080/verify-email",
+	"mail.verify_token_ttl": 24 * time.Hour,
+	"mail.reset_url":        "http://localhost:8080/password/reset",
+	"mail.reset_token_ttl":  30 * time.Minute,
+	"mail.unverified_login": UnverifiedLimit,
+	"mail.workers":          4,
+	"mail.queue_size":       256,
+
+	"metrics.enabled":              true,
+	"metrics.token":                "",
+	"metrics.cart_abandoned_after": 24 * time.Hour,
+}
+
+// flagKeys maps command line flags to config keys. Secrets are deliberately
+// left out, flags show up in the process list.
+var flagKeys = map[string]string{
+	"addr":               "server.addr",
+	"db-driver":          "db.driver",
+	"db-host":            "db.host",
+	"db-port":            "db.port",
+	"db-user":            "db.user",
+	"db-name":            "db.name",
+	"db-sslmode":         "db.sslmode",
+	"jwt-active-kid":     "jwt.active_kid",
+	"password-algorithm": "password.algorithm",
+	"tracing-exporter":   "tracing.exporter",
+	"mail-driver":        "mail.driver",
+}
+
+// RegisterFlags adds the config flags to a cobra/pflag flag set
+func RegisterFlags(flags *pflag.FlagSet) {
+	flags.String("config", "", "path to a YAML or TOML config file (env CONFIG_FILE)")
+	flags.String("addr", "", "HTTP listen address")
+	flags.String("db-driver", "", "storage driver (postgres, sqlite or memory)")
+	flags.String("db-host", "", "database host")
+	flags.Int("db-port", 0, "database port")
+	flags.String("db-user", "", "database user")
+	flags.String("db-name", "", "database name")
+	flags.String("db-sslmode", "", "database sslmode")
+	flags.String("jwt-active-kid", "", "id of the key that signs new tokens")
+	flags.String("password-algorithm", "", "password hashing algorithm (bcrypt or argon2id)")
+	flags.String("tracing-exporter", "", "where spans are sent (otlp, stdout or none)")
+	flags.String("mail-driver", "", "how mails are sent (smtp, file or none)")
+}
+
+// Load builds and validates the config. Environment variables use the key
+// with dots replaced by underscores, e.g. DB_HOST or JWT_ACCESS_TOKEN_TTL.
+// A .env file in the working directory is read when present.
+func Load(flags *pflag.FlagSet) (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("config: reading .env: %w", err)
+	}
+
+	v := viper.New()
+	for key, value := range defaults {
+		v.SetDefault(key, value)
+	}
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if flags != nil {
+		for name, key := range flagKeys {
+			if f := flags.Lookup(name); f != nil {
+				if err := v.BindPFlag(key, f); err != nil {
+					return nil, fmt.Errorf("config: binding flag %q: %w", name, err)
+				}
+			}
+		}
+	}
+
+	file := v.GetString("config_file")
+	if flags != nil {
+		if f := flags.Lookup("config"); f != nil && f.Changed {
+			file = f.Value.String()
+		}
+	}
+	if file != "" {
+		v.SetConfigFile(file)
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("config: reading %s: %w", file, err)
+		}
+	}
+
+	var cfg Config
+	hook := viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		keySpecHook,
+	))
+	if err := v.Unmarshal(&cfg, hook); err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// keySpecHook lets JWT_KEYS use the compact kid:ALG:file form
+func keySpecHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf([]JWTKeyConfig{}) {
+		return data, nil
+	}
+	keys, err := jwt.ParseKeySpec(data.(string))
+	if err != nil {
+		return nil, err
+	}
+	out := make([]JWTKeyConfig, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, JWTKeyConfig{ID: k.ID, Algorithm: k.Algorithm, KeyFile: k.KeyFile})
+	}
+	return out, nil
+}
+
+// Validate checks every value and reports all problems at once
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server.addr is required"))
+	}
+	for name, d := range map[string]time.Duration{
+		"server.read_header_timeout": c.Server.ReadHeaderTimeout,
+		"server.read_timeout":        c.Server.ReadTimeout,
+		"server.write_timeout":       c.Server.WriteTimeout,
+		"server.idle_timeout":        c.Server.IdleTimeout,
+		"server.drain_delay":         c.Server.DrainDelay,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server.shutdown_timeout must be positive"))
+	}
+	if c.Server.MaxBodyBytes <= 0 {
+		errs = append(errs, errors.New("server.max_body_bytes must be positive"))
+	}
+
+	switch c.DB.Driver {
+	case DriverPostgres:
+		errs = append(errs, c.DB.validatePostgres()...)
+	case DriverSQLite:
+		if c.DB.SQLitePath == "" {
+			errs = append(errs, errors.New("db.sqlite_path is required for the sqlite driver"))
+		}
+	case DriverMemory:
+	default:
+		errs = append(errs, fmt.Errorf("db.driver %q is not supported", c.DB.Driver))
+	}
+	if c.DB.MaxOpenConns < 0 || c.DB.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("db.max_open_conns and db.max_idle_conns must not be negative"))
+	}
+	if c.DB.MaxOpenConns > 0 && c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		errs = append(errs, errors.New("db.max_idle_conns must not exceed db.max_open_conns"))
+	}
+	for name, d := range map[string]time.Duration{
+		"db.conn_max_lifetime":  c.DB.ConnMaxLifetime,
+		"db.conn_max_idle_time": c.DB.ConnMaxIdleTime,
+		"db.statement_timeout":  c.DB.StatementTimeout,
+	} {
+		if d < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+	if c.DB.ConnectAttempts < 1 {
+		errs = append(errs, errors.New("db.connect_attempts must be at least 1"))
+	}
+	if c.DB.ConnectBackoff <= 0 || c.DB.ConnectMaxBackoff < c.DB.ConnectBackoff {
+		errs = append(errs, errors.New("db.connect_backoff must be positive and not above db.connect_max_backoff"))
+	}
+
+	errs = append(errs, c.JWT.validate()...)
+
+	switch c.Password.Algorithm {
+	case password.AlgorithmBcrypt, password.AlgorithmArgon2id:
+	default:
+		errs = append(errs, fmt.Errorf("password.algorithm %q is not supported", c.Password.Algorithm))
+	}
+	if c.Password.MinLength < 1 {
+		errs = append(errs, errors.New("password.min_length must be at least 1"))
+	}
+	if c.Password.MaxLength < c.Password.MinLength {
+		errs = append(errs, errors.New("password.max_length must not be less than password.min_length"))
+	}
+	// bcrypt ignores everything after 72 bytes
+	if c.Password.Algorithm == password.AlgorithmBcrypt && c.Password.MaxLength > password.BcryptMaxBytes {
+		errs = append(errs, fmt.Errorf("password.max_length must not exceed %d with bcrypt", password.BcryptMaxBytes))
+	}
+
+	switch c.Tracing.Exporter {
+	case ExporterOTLP, ExporterStdout, ExporterNone:
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter %q is not supported", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, errors.New("tracing.sample_ratio must be between 0 and 1"))
+	}
+
+	if c.Metrics.CartAbandonedAfter <= 0 {
+		errs = append(errs, errors.New("metrics.cart_abandoned_after must be positive"))
+	}
+
+	errs = append(errs, c.RateLimit.validate()...)
+	errs = append(errs, c.Mail.validate()...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c RateLimitConfig) validate() []error {
+	var errs []error
+
+	for name, l := range map[string]LimitConfig{
+		"ratelimit.login_per_ip":   c.LoginPerIP,
+		"ratelimit.login_per_user": c.LoginPerUser,
+		"ratelimit.signup_per_ip":  c.SignupPerIP,
+		"ratelimit.mail_per_ip":    c.MailPerIP,
+		"ratelimit.token_per_ip":   c.TokenPerIP,
+	} {
+		if l.Requests < 1 || l.Per <= 0 || l.Burst < 1 {
+			errs = append(errs, fmt.Errorf("%s needs positive requests, per and burst", name))
+		}
+	}
+	if c.Lockout.Threshold < 1 || c.Lockout.Window <= 0 || c.Lockout.Duration <= 0 {
+		errs = append(errs, errors.New("ratelimit.lockout needs positive threshold, window and duration"))
+	}
+	return errs
+}
+
+func (c MailConfig) validate() []error {
+	var errs []error
+
+	switch c.Driver {
+	case mail.DriverSMTP:
+		if c.SMTPHost == "" {
+			errs = append(errs, errors.New("mail.smtp_host is required for the smtp driver"))
+		}
+		if c.SMTPPort < 1 || c.SMTPPort > 65535 {
+			errs = append(errs, fmt.Errorf("mail.smtp_port %d is out of range", c.SMTPPort))
+		}
+		if c.SMTPTimeout < 0 {
+			errs = append(errs, errors.New("mail.smtp_timeout must not be negative"))
+		}
+	case mail.DriverFile:
+		if c.OutboxDir == "" {
+			errs = append(errs, errors.New("mail.outbox_dir is required for the file driver"))
+		}
+	case mail.DriverNone:
+	default:
+		errs = append(errs, fmt.Errorf("mail.driver %q is not supported", c.Driver))
+	}
+	if _, err := netmail.ParseAddress(c.From); err != nil {
+		errs = append(errs, fmt.Errorf("mail.from %q is not a mail address", c.From))
+	}
+
+	for key, link := range map[string]string{"mail.verify_url": c.VerifyURL, "mail.reset_url": c.ResetURL} {
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s %q must be an absolute http(s) URL", key, link))
+		}
+	}
+	if c.VerifyTokenTTL <= 0 {
+		errs = append(errs, errors.New("mail.verify_token_ttl must be positive"))
+	}
+	if c.ResetTokenTTL <= 0 {
+		errs = append(errs, errors.New("mail.reset_token_ttl must be positive"))
+	}
+	switch c.UnverifiedLogin {
+	case UnverifiedAllow, UnverifiedLimit, UnverifiedBlock:
+	default:
+		errs = append(errs, fmt.Errorf("mail.unverified_login %q is not supported", c.UnverifiedLogin))
+	}
+	if c.Workers < 1 {
+		errs = append(errs, errors.New("mail.workers must be at least 1"))
+	}
+	if c.QueueSize < 1 {
+		errs = append(errs, errors.New("mail.queue_size must be at least 1"))
+	}
+	return errs
+}
+
+func (c DBConfig) validatePostgres() []error {
+	var errs []error
+
+	if c.Host == "" {
+		errs = append(errs, errors.New("db.host is required"))
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("db.port %d is out of range", c.Port))
+	}
+	if c.User == "" {
+		errs = append(errs, errors.New("db.user is required"))
+	}
+	if c.Name == "" {
+		errs = append(errs, errors.New("db.name is required"))
+	}
+	switch c.SSLMode {
+	case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+	default:
+		errs = append(errs, fmt.Errorf("db.sslmode %q is not supported", c.SSLMode))
+	}
+	return errs
+}
+
+func (c JWTConfig) validate() []error {
+	var errs []error
+
+	if c.Secret == "" && len(c.Keys) == 0 {
+		errs = append(errs, errors.New("jwt.secret or jwt.keys is required"))
+	}
+	if c.Secret != "" && len(c.Secret) < minSecretLength {
+		errs = append(errs, fmt.Errorf("jwt.secret must be at least %d characters", minSecretLength))
+	}
+
+	ids := make(map[string]bool, len(c.Keys))
+	if c.Secret != "" {
+		ids[DefaultKeyID] = true
+	}
+	for _, k := range c.Keys {
+		if k.ID == "" {
+			errs = append(errs, errors.New("jwt.keys: every key needs an id"))
+			continue
+		}
+		if ids[k.ID] {
+			errs = append(errs, fmt.Errorf("jwt.keys: duplicate key id %q", k.ID))
+		}
+		ids[k.ID] = true
+		switch k.Algorithm {
+		case jwt.AlgorithmHS256, jwt.AlgorithmRS256, jwt.AlgorithmEdDSA:
+		default:
+			errs = append(errs, fmt.Errorf("jwt.keys: key %q has unsupported algorithm %q", k.ID, k.Algorithm))
+		}
+	}
+
+	if len(c.Keys) > 0 && c.ActiveKeyID == "" && c.Secret == "" {
+		errs = append(errs, errors.New("jwt.active_kid is required when jwt.keys is set"))
+	}
+	if c.ActiveKeyID != "" && !ids[c.ActiveKeyID] {
+		errs = append(errs, fmt.Errorf("jwt.active_kid %q is not a configured key", c.ActiveKeyID))
+	}
+
+	if c.AccessTokenTTL <= 0 {
+		errs = append(errs, errors.New("jwt.access_token_ttl must be positive"))
+	}
+	if c.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("jwt.refresh_token_ttl must be positive"))
+	}
+	return errs
+}
+
+// ServiceConfig converts the settings into a jwt.Config
+func (c JWTConfig) ServiceConfig() jwt.Config {
+	cfg := jwt.Config{
+		ActiveKeyID:     c.ActiveKeyID,
+		AccessTokenTTL:  c.AccessTokenTTL,
+		RefreshTokenTTL: c.RefreshTokenTTL,
+	}
+	if c.Secret != "" {
+		cfg.Keys = append(cfg.Keys, jwt.KeyConfig{ID: DefaultKeyID, Algorithm: jwt.AlgorithmHS256, Secret: c.Secret})
+		if cfg.ActiveKeyID == "" {
+			cfg.ActiveKeyID = DefaultKeyID
+		}
+	}
+	for _, k := range c.Keys {
+		cfg.Keys = append(cfg.Keys, jwt.KeyConfig{ID: k.ID, Algorithm: k.Algorithm, Secret: k.Secret, KeyFile: k.KeyFile})
+	}
+	return cfg
+}
+
+// DSN returns the postgres connection string, it contains the password so never log it
+func (c DBConfig) DSN() string {
+	params := []string{
+		"user=" + quoteDSN(c.User),
+		"password=" + quoteDSN(c.Password),
+		"host=" + quoteDSN(c.Host),
+		fmt.Sprintf("port=%d", c.Port),
+		"dbname=" + quoteDSN(c.Name),
+		"sslmode=" + quoteDSN(c.SSLMode),
+	}
+	if c.StatementTimeout > 0 {
+		// sent as a runtime parameter, so it applies to every pooled connection
+		params = append(params, fmt.Sprintf("statement_timeout=%d", c.StatementTimeout.Milliseconds()))
+	}
+	return strings.Join(params, " ")
+}
+
+// quoteDSN quotes a keyword/value DSN value so spaces and quotes survive
+func quoteDSN(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// Redacted returns a copy that is safe to print, secrets are masked
+func (c Config) Redacted() Config {
+	c.DB.Password = redact(c.DB.Password)
+	c.JWT.Secret = redact(c.JWT.Secret)
+	c.Mail.SMTPPassword = redact(c.Mail.SMTPPassword)
+	c.Metrics.Token = redact(c.Metrics.Token)
+
+	keys := make([]JWTKeyConfig, len(c.JWT.Keys))
+	for i, k := range c.JWT.Keys {
+		k.Secret = redact(k.Secret)
+		keys[i] = k
+	}
+	c.JWT.Keys = keys
+	return c
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return redacted
+}
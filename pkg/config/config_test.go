@@ -0,0 +1,359 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sonartest_cart/pkg/jwt"
+	"sonartest_cart/pkg/password"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func newFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	RegisterFlags(flags)
+	require.NoError(t, flags.Parse(args))
+	return flags
+}
+
+// requiredEnv sets the values that have no usable default
+func requiredEnv(t *testing.T) {
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_NAME", "cart")
+	t.Setenv("JWT_SECRET", testSecret)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	requiredEnv(t)
+	file := writeFile(t, "config.yaml", `
+server:
+  addr: ":7000"
+  idle_timeout: 30s
+db:
+  host: file-host
+  port: 6543
+jwt:
+  access_token_ttl: 5m
+`)
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("SERVER_ADDR", ":7100")
+
+	cfg, err := Load(newFlags(t, "--config", file, "--addr", ":7200"))
+	require.NoError(t, err)
+
+	assert.Equal(t, ":7200", cfg.Server.Addr, "flag beats env")
+	assert.Equal(t, "env-host", cfg.DB.Host, "env beats file")
+	assert.Equal(t, 6543, cfg.DB.Port, "file beats default")
+	assert.Equal(t, 30*time.Second, cfg.Server.IdleTimeout)
+	assert.Equal(t, 5*time.Minute, cfg.JWT.AccessTokenTTL)
+	assert.Equal(t, 120*time.Second, cfg.Server.ReadTimeout, "default")
+	assert.Equal(t, "disable", cfg.DB.SSLMode, "default")
+}
+
+func TestLoadTOMLFromEnv(t *testing.T) {
+	requiredEnv(t)
+	file := writeFile(t, "config.toml", `
+[password]
+algorithm = "argon2id"
+`)
+	t.Setenv("CONFIG_FILE", file)
+
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "argon2id", cfg.Password.Algorithm)
+}
+
+func TestLoadJWTKeysFromEnv(t *testing.T) {
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_NAME", "cart")
+	t.Setenv("JWT_KEYS", "2024-06:RS256:/keys/new.pem, 2024-01:RS256:/keys/old.pub.pem")
+	t.Setenv("JWT_ACTIVE_KID", "2024-06")
+
+	cfg, err := Load(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []JWTKeyConfig{
+		{ID: "2024-06", Algorithm: jwt.AlgorithmRS256, KeyFile: "/keys/new.pem"},
+		{ID: "2024-01", Algorithm: jwt.AlgorithmRS256, KeyFile: "/keys/old.pub.pem"},
+	}, cfg.JWT.Keys)
+	assert.Equal(t, "2024-06", cfg.JWT.ServiceConfig().ActiveKeyID)
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "missing_jwt_key",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart"},
+			wantErr: "jwt.secret or jwt.keys is required",
+		},
+		{
+			name:    "missing_db_settings",
+			env:     map[string]string{"JWT_SECRET": testSecret},
+			wantErr: "db.user is required",
+		},
+		{
+			name:    "invalid_duration",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "SERVER_READ_TIMEOUT": "soon"},
+			wantErr: "read_timeout",
+		},
+		{
+			name:    "bad_flag_value",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret},
+			args:    []string{"--db-port", "70000", "--password-algorithm", "md5"},
+			wantErr: "db.port 70000 is out of range",
+		},
+		{
+			name:    "pool_settings",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "DB_MAX_OPEN_CONNS": "5", "DB_MAX_IDLE_CONNS": "10"},
+			wantErr: "db.max_idle_conns must not exceed db.max_open_conns",
+		},
+		{
+			name:    "no_connect_attempts",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "DB_CONNECT_ATTEMPTS": "0"},
+			wantErr: "db.connect_attempts must be at least 1",
+		},
+		{
+			name:    "unknown_tracing_exporter",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret},
+			args:    []string{"--tracing-exporter", "zipkin"},
+			wantErr: `tracing.exporter "zipkin" is not supported`,
+		},
+		{
+			name:    "ratelimit_without_burst",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "RATELIMIT_LOGIN_PER_IP_BURST": "0"},
+			wantErr: "ratelimit.login_per_ip needs positive requests, per and burst",
+		},
+		{
+			name:    "no_body_limit",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "SERVER_MAX_BODY_BYTES": "0"},
+			wantErr: "server.max_body_bytes must be positive",
+		},
+		{
+			name:    "password_lengths",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "PASSWORD_MIN_LENGTH": "12", "PASSWORD_MAX_LENGTH": "10"},
+			wantErr: "password.max_length must not be less than password.min_length",
+		},
+		{
+			name:    "unknown_mail_driver",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret},
+			args:    []string{"--mail-driver", "pigeon"},
+			wantErr: `mail.driver "pigeon" is not supported`,
+		},
+		{
+			name:    "smtp_without_host",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "MAIL_DRIVER": "smtp"},
+			wantErr: "mail.smtp_host is required for the smtp driver",
+		},
+		{
+			name:    "relative_verify_url",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "MAIL_VERIFY_URL": "/verify-email"},
+			wantErr: `mail.verify_url "/verify-email" must be an absolute http(s) URL`,
+		},
+		{
+			name:    "zero_reset_token_ttl",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "MAIL_RESET_TOKEN_TTL": "0s"},
+			wantErr: "mail.reset_token_ttl must be positive",
+		},
+		{
+			name:    "unknown_unverified_login",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "MAIL_UNVERIFIED_LOGIN": "maybe"},
+			wantErr: `mail.unverified_login "maybe" is not supported`,
+		},
+		{
+			name:    "zero_cart_abandoned_after",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "METRICS_CART_ABANDONED_AFTER": "0s"},
+			wantErr: "metrics.cart_abandoned_after must be positive",
+		},
+		{
+			name:    "no_mail_workers",
+			env:     map[string]string{"DB_USER": "postgres", "DB_NAME": "cart", "JWT_SECRET": testSecret, "MAIL_WORKERS": "0"},
+			wantErr: "mail.workers must be at least 1",
+		},
+		{
+			name:    "missing_config_file",
+			env:     map[string]string{"CONFIG_FILE": "/does/not/exist.yaml"},
+			wantErr: "config: reading /does/not/exist.yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load(newFlags(t, tt.args...))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestValidateJWT(t *testing.T) {
+	valid := JWTConfig{Secret: testSecret, AccessTokenTTL: time.Minute, RefreshTokenTTL: time.Hour}
+
+	tests := []struct {
+		name    string
+		modify  func(c *JWTConfig)
+		wantErr string
+	}{
+		{name: "valid", modify: func(c *JWTConfig) {}},
+		{
+			name:    "short_secret",
+			modify:  func(c *JWTConfig) { c.Secret = "short" },
+			wantErr: "jwt.secret must be at least 32 characters",
+		},
+		{
+			name:    "unknown_active_kid",
+			modify:  func(c *JWTConfig) { c.ActiveKeyID = "k9" },
+			wantErr: `jwt.active_kid "k9" is not a configured key`,
+		},
+		{
+			name: "duplicate_key",
+			modify: func(c *JWTConfig) {
+				c.Keys = []JWTKeyConfig{{ID: "k1", Algorithm: "HS256"}, {ID: "k1", Algorithm: "HS256"}}
+			},
+			wantErr: `duplicate key id "k1"`,
+		},
+		{
+			name:    "unsupported_algorithm",
+			modify:  func(c *JWTConfig) { c.Keys = []JWTKeyConfig{{ID: "k1", Algorithm: "none"}} },
+			wantErr: `unsupported algorithm "none"`,
+		},
+		{
+			name:    "zero_ttl",
+			modify:  func(c *JWTConfig) { c.AccessTokenTTL = 0 },
+			wantErr: "jwt.access_token_ttl must be positive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.modify(&c)
+			errs := c.validate()
+			if tt.wantErr == "" {
+				assert.Empty(t, errs)
+				return
+			}
+			require.NotEmpty(t, errs)
+			assert.Contains(t, errs[0].Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestServiceConfig(t *testing.T) {
+	c := JWTConfig{
+		Secret:          testSecret,
+		Keys:            []JWTKeyConfig{{ID: "old", Algorithm: jwt.AlgorithmRS256, KeyFile: "/keys/old.pub.pem"}},
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	}
+
+	got := c.ServiceConfig()
+	assert.Equal(t, DefaultKeyID, got.ActiveKeyID)
+	assert.Equal(t, []jwt.KeyConfig{
+		{ID: DefaultKeyID, Algorithm: jwt.AlgorithmHS256, Secret: testSecret},
+		{ID: "old", Algorithm: jwt.AlgorithmRS256, KeyFile: "/keys/old.pub.pem"},
+	}, got.Keys)
+	assert.Equal(t, time.Minute, got.AccessTokenTTL)
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Config{
+		DB:      DBConfig{User: "postgres", Password: "hunter2"},
+		Mail:    MailConfig{SMTPUsername: "shop", SMTPPassword: "smtp-secret"},
+		Metrics: MetricsConfig{Enabled: true, Token: "scrape-secret"},
+		JWT: JWTConfig{
+			Secret: testSecret,
+			Keys: []JWTKeyConfig{
+				{ID: "k1", Algorithm: jwt.AlgorithmHS256, Secret: "key-secret"},
+				{ID: "k2", Algorithm: jwt.AlgorithmRS256, KeyFile: "/keys/k2.pem"},
+			},
+		},
+	}
+
+	got := cfg.Redacted()
+	assert.Equal(t, "postgres", got.DB.User)
+	assert.Equal(t, redacted, got.DB.Password)
+	assert.Equal(t, redacted, got.JWT.Secret)
+	assert.Equal(t, redacted, got.JWT.Keys[0].Secret)
+	assert.Equal(t, "", got.JWT.Keys[1].Secret)
+	assert.Equal(t, "/keys/k2.pem", got.JWT.Keys[1].KeyFile)
+	assert.Equal(t, "shop", got.Mail.SMTPUsername)
+	assert.Equal(t, redacted, got.Mail.SMTPPassword)
+	assert.Equal(t, redacted, got.Metrics.Token)
+
+	// the original is untouched
+	assert.Equal(t, "hunter2", cfg.DB.Password)
+	assert.Equal(t, "key-secret", cfg.JWT.Keys[0].Secret)
+}
+
+func TestPasswordPolicy(t *testing.T) {
+	cfg := PasswordConfig{Algorithm: password.AlgorithmBcrypt, MinLength: 8, MaxLength: 64}
+	assert.Equal(t, password.BcryptMaxBytes, cfg.Policy().MaxBytes, "bcrypt cuts passwords off")
+
+	cfg.Algorithm = password.AlgorithmArgon2id
+	assert.Zero(t, cfg.Policy().MaxBytes)
+}
+
+func TestLockoutApartFromRateLimits(t *testing.T) {
+	requiredEnv(t)
+	t.Setenv("RATELIMIT_ENABLED", "false")
+
+	cfg, err := Load(newFlags(t))
+	require.NoError(t, err)
+	assert.False(t, cfg.RateLimit.Enabled)
+	assert.True(t, cfg.RateLimit.Lockout.Enabled, "the lockout has its own switch")
+
+	t.Setenv("RATELIMIT_LOCKOUT_ENABLED", "false")
+	cfg, err = Load(newFlags(t))
+	require.NoError(t, err)
+	assert.False(t, cfg.RateLimit.Lockout.Enabled)
+}
+
+func TestDSN(t *testing.T) {
+	c := DBConfig{
+		Host:             "db",
+		Port:             5432,
+		User:             "cart",
+		Password:         `it's a secret\`,
+		Name:             "cart",
+		SSLMode:          "require",
+		StatementTimeout: 1500 * time.Millisecond,
+	}
+	assert.Equal(t, `user=cart password='it\'s a secret\\' host=db port=5432 dbname=cart sslmode=require statement_timeout=1500`, c.DSN())
+
+	c.Password = ""
+	c.StatementTimeout = 0
+	assert.Equal(t, `user=cart password='' host=db port=5432 dbname=cart sslmode=require`, c.DSN())
+}
+
+func TestLoadDriver(t *testing.T) {
+	t.Setenv("JWT_SECRET", testSecret)
+
+	// no postgres settings needed without postgres
+	cfg, err := Load(newFlags(t, "--db-driver", DriverMemory))
+	require.NoError(t, err)
+	assert.Equal(t, DriverMemory, cfg.DB.Driver)
+
+	t.Setenv("DB_DRIVER", "mongo")
+	_, err = Load(nil)
+	assert.ErrorContains(t, err, `db.driver "mongo" is not supported`)
+}
@@ -1,6 +1,7 @@
 package e
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 )
@@ -27,7 +28,15 @@ type HttpError struct {
 // }
 
 func (e *WrapError) Error() string {
-	return e.RootCause.Error()
+	if e.RootCause != nil {
+		return e.RootCause.Error()
+	}
+	return e.Msg
+}
+
+// Unwrap lets errors.Is and errors.As see the root cause
+func (e *WrapError) Unwrap() error {
+	return e.RootCause
 }
 
 // NewError : create a new error instance, get rootcause error and return as WrapError.
@@ -40,6 +49,16 @@ func NewError(errCode int, msg string, rootCause error) *WrapError {
 	return err
 }
 
+// NewParseError : wrap an error of a dto Parse method with errCode. Errors
+// that already have a code, like the request body checks, keep theirs.
+func NewParseError(errCode int, err error) *WrapError {
+	var wrapErr *WrapError
+	if errors.As(err, &wrapErr) {
+		return wrapErr
+	}
+	return NewError(errCode, "error while parsing", err)
+}
+
 // NewAPIError : create http error from NewError to pass api.Fail.
 // err is expecting WrapError type.
 func NewAPIError(err error, msg string) *HttpError {
@@ -118,6 +118,66 @@ const (
 
 	// ErrUpdateUserProfile : error while updating user profile
 	ErrUpdateUserProfile
+
+	// ErrLogout : error while logging out
+	ErrLogout
+
+	// ErrEmptyRequestBody : when a request needs a body and has none
+	ErrEmptyRequestBody
+
+	// ErrUnknownField : when the request body has a field the request doesn't know
+	ErrUnknownField
+
+	// ErrTrailingData : when the request body has more than one JSON value
+	ErrTrailingData
+
+	// ErrAssignRole : error while giving a user another role
+	ErrAssignRole
+
+	// ErrListRoles : error while listing the roles
+	ErrListRoles
+
+	// ErrVerifyMail : error while verifying a mail address
+	ErrVerifyMail
+
+	// ErrInvalidMailToken : when a token from a mail is unknown, expired or already used
+	ErrInvalidMailToken
+
+	// ErrChangePassword : error while changing the password of a logged in user
+	ErrChangePassword
+
+	// ErrResetPassword : error while resetting a forgotten password
+	ErrResetPassword
+
+	// ErrWrongPassword : when the current password given to change it is wrong
+	ErrWrongPassword
+
+	// ErrUsernameTaken : when another user already has the username
+	ErrUsernameTaken
+)
+
+// 401 errors
+const (
+	// ErrUnauthorized : when the request is not authenticated
+	ErrUnauthorized int = 401000 + iota
+
+	// ErrInvalidRefreshToken : when the refresh token is unknown, expired or revoked
+	ErrInvalidRefreshToken
+
+	// ErrRefreshTokenReused : when an already used refresh token is presented again
+	ErrRefreshTokenReused
+
+	// ErrLoginFailed : when the username or password is wrong, deliberately not saying which
+	ErrLoginFailed
+)
+
+// 403 errors
+const (
+	// ErrForbidden : when the user may not do what the request asks for
+	ErrForbidden int = 403000 + iota
+
+	// ErrMailNotVerified : when the user has to verify their mail address first
+	ErrMailNotVerified
 )
 
 // 404 errors
@@ -142,6 +202,30 @@ const (
 
 	// ErrBrandNotFound : when brand is not found
 	ErrBrandNotFound
+
+	// ErrRoleNotFound : when role is not found
+	ErrRoleNotFound
+)
+
+// 413 errors
+const (
+	// ErrRequestTooLarge : when the request body is over the size limit
+	ErrRequestTooLarge int = 413000 + iota
+)
+
+// 415 errors
+const (
+	// ErrUnsupportedMediaType : when the request body is not application/json
+	ErrUnsupportedMediaType int = 415000 + iota
+)
+
+// 429 errors
+const (
+	// ErrTooManyRequests : when a client exceeds a rate limit
+	ErrTooManyRequests int = 429000 + iota
+
+	// ErrAccountLocked : when an account is locked after too many failed logins
+	ErrAccountLocked
 )
 
 // 500 errors
@@ -161,3 +245,9 @@ const (
 	// ErrTransactionError : when database transaction fails
 	ErrTransactionError
 )
+
+// 503 errors
+const (
+	// ErrNotReady : when a readiness check fails or the server is shutting down
+	ErrNotReady int = 503000 + iota
+)
@@ -2,20 +2,26 @@ package jwt
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v4"
 )
 
 var (
 	ErrExpiredToken = errors.New("token is expired")
-	jwtKey          = []byte("wA2I7VqLMbKP5RtUoD7M1jsJYWD9edxBS6cOgFXElwo=")
+	ErrUnknownKey   = errors.New("token signed with an unknown key")
+	ErrNoSigningKey = errors.New("no active signing key configured")
+	ErrAlgMismatch  = errors.New("token algorithm does not match its key")
 )
 
 type Claims struct {
 	UserID   int64  `json:"id"` //here we including id and name here so that will be there on the token, so we can use it in the other layers
 	Username string `json:"username"`
-	IsAdmin  bool   `json:"isadmin"`
+	// Role and Permissions are copied from the database at login, a new role
+	// takes effect with the next token
+	Role        string   `json:"role"`
+	Permissions []string `json:"perms,omitempty"`
 	jwt.StandardClaims
 }
 
@@ -24,63 +30,149 @@ type Claims struct {
 //
 //go:generate mockgen -destination=mock_jwtservice.go -package=jwt . JWTService
 type JWTService interface {
-	GenerateToken(userID int64, username string, isadmin bool) (string, error)
+	GenerateToken(userID int64, username, role string, permissions []string) (string, error)
 	ValidateToken(tokenStr string) (*Claims, error)
+	AccessTokenTTL() time.Duration
+	RefreshTokenTTL() time.Duration
 }
 
 // JWTServiceImpl is the concrete implementation of JWTService
-// It holds the signing key
+// It signs with the active key and validates against every configured key
 type JWTServiceImpl struct {
-	key []byte
+	activeKey       *signingKey
+	keys            map[string]*signingKey
+	accessTokenTTL  time.Duration
+	refreshTokenTTL time.Duration
 }
 
-// NewJWTService creates a new JWTService with the default key
-func NewJWTService() JWTService {
-	return &JWTServiceImpl{key: jwtKey}
+// NewJWTService creates a new JWTService from the given configuration.
+// A config without ActiveKeyID gives a verify-only service, which is all a
+// service holding just the public keys needs.
+func NewJWTService(cfg Config) (JWTService, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, errors.New("jwt: no keys configured")
+	}
+
+	s := &JWTServiceImpl{
+		keys:            make(map[string]*signingKey, len(cfg.Keys)),
+		accessTokenTTL:  cfg.AccessTokenTTL,
+		refreshTokenTTL: cfg.RefreshTokenTTL,
+	}
+	if s.accessTokenTTL <= 0 {
+		s.accessTokenTTL = DefaultAccessTokenTTL
+	}
+	if s.refreshTokenTTL <= 0 {
+		s.refreshTokenTTL = DefaultRefreshTokenTTL
+	}
+
+	for _, kc := range cfg.Keys {
+		if _, ok := s.keys[kc.ID]; ok {
+			return nil, fmt.Errorf("jwt: duplicate key id %q", kc.ID)
+		}
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, err
+		}
+		s.keys[kc.ID] = key
+	}
+
+	if cfg.ActiveKeyID != "" {
+		active, ok := s.keys[cfg.ActiveKeyID]
+		if !ok {
+			return nil, fmt.Errorf("jwt: active key %q is not configured", cfg.ActiveKeyID)
+		}
+		if active.signKey == nil {
+			return nil, fmt.Errorf("jwt: active key %q has no private key to sign with", cfg.ActiveKeyID)
+		}
+		s.activeKey = active
+	}
+
+	return s, nil
 }
 
-// GenerateToken generates a new JWT token
-func (j *JWTServiceImpl) GenerateToken(userID int64, username string, isadmin bool) (string, error) {
-	expirationTime := time.Now().Add(24 * time.Hour) // Token valid for 24 hours
+// GenerateToken generates a new access token signed with the active key.
+// Every token gets a unique jti so it can be revoked on logout.
+func (j *JWTServiceImpl) GenerateToken(userID int64, username, role string, permissions []string) (string, error) {
+	if j.activeKey == nil {
+		return "", ErrNoSigningKey
+	}
+
+	jti, err := NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		IsAdmin:  isadmin,
+		UserID:      userID,
+		Username:    username,
+		Role:        role,
+		Permissions: permissions,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: expirationTime.Unix(),
+			Id:        jti,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(j.accessTokenTTL).Unix(),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(j.key)
+	token := jwt.NewWithClaims(j.activeKey.method, claims)
+	token.Header["kid"] = j.activeKey.id
+	tokenString, err := token.SignedString(j.activeKey.signKey)
 	if err != nil {
 		return "", err
 	}
 	return tokenString, nil
 }
 
-// ValidateToken validates the JWT token and checks for expiration
+// ValidateToken validates the JWT token and checks for expiration.
+// The kid header picks the key, so tokens signed with a retired key stay
+// valid until they expire.
 func (j *JWTServiceImpl) ValidateToken(tokenStr string) (*Claims, error) {
 	claims := &Claims{}
-	token, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (interface{}, error) {
-		return j.key, nil
-	})
-
+	token, err := jwt.ParseWithClaims(tokenStr, claims, j.keyFunc)
 	if err != nil {
-		if err == jwt.ErrSignatureInvalid {
-			return nil, err
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
 		}
 		return nil, err
 	}
 
 	if !token.Valid {
-		return nil, err
+		return nil, errors.New("invalid token")
 	}
 
-	// Check if the token has expired
-	if claims.ExpiresAt < time.Now().Unix() {
-		return nil, ErrExpiredToken
+	return claims, nil
+}
+
+func (j *JWTServiceImpl) AccessTokenTTL() time.Duration {
+	return j.accessTokenTTL
+}
+
+func (j *JWTServiceImpl) RefreshTokenTTL() time.Duration {
+	return j.refreshTokenTTL
+}
+
+func (j *JWTServiceImpl) keyFunc(token *jwt.Token) (interface{}, error) {
+	var key *signingKey
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		// tokens issued before key ids were introduced
+		if j.activeKey == nil {
+			return nil, ErrUnknownKey
+		}
+		key = j.activeKey
+	} else {
+		var ok bool
+		key, ok = j.keys[kid]
+		if !ok {
+			return nil, ErrUnknownKey
+		}
 	}
 
-	return claims, nil
+	// never let the token choose the algorithm, e.g. HS256 signed with a public key
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, ErrAlgMismatch
+	}
+	return key.verifyKey, nil
 }
@@ -0,0 +1,227 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair writes a private and a public PEM file and returns their paths
+func writeKeyPair(t *testing.T, name string, priv interface{}, pub interface{}) (string, string) {
+	dir := t.TempDir()
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+
+	privFile := filepath.Join(dir, name+".pem")
+	pubFile := filepath.Join(dir, name+".pub.pem")
+	require.NoError(t, os.WriteFile(privFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600))
+	require.NoError(t, os.WriteFile(pubFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o644))
+	return privFile, pubFile
+}
+
+func rsaKeyFiles(t *testing.T, name string) (string, string) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return writeKeyPair(t, name, priv, &priv.PublicKey)
+}
+
+func edKeyFiles(t *testing.T, name string) (string, string) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return writeKeyPair(t, name, priv, pub)
+}
+
+const testSecret = "jwt-test-secret"
+
+// hsConfig is a single HS256 key config
+func hsConfig() Config {
+	return Config{
+		ActiveKeyID: "hs",
+		Keys:        []KeyConfig{{ID: "hs", Algorithm: AlgorithmHS256, Secret: testSecret}},
+	}
+}
+
+func TestGenerateAndValidateToken(t *testing.T) {
+	rsaPriv, rsaPub := rsaKeyFiles(t, "rsa")
+	edPriv, edPub := edKeyFiles(t, "ed")
+
+	tests := []struct {
+		name      string
+		signer    Config
+		validator Config
+		wantErr   error
+	}{
+		{
+			name:      "hs256",
+			signer:    hsConfig(),
+			validator: hsConfig(),
+		},
+		{
+			name:      "rs256_public_key_only_validator",
+			signer:    Config{ActiveKeyID: "r1", Keys: []KeyConfig{{ID: "r1", Algorithm: AlgorithmRS256, KeyFile: rsaPriv}}},
+			validator: Config{Keys: []KeyConfig{{ID: "r1", Algorithm: AlgorithmRS256, KeyFile: rsaPub}}},
+		},
+		{
+			name:      "eddsa_public_key_only_validator",
+			signer:    Config{ActiveKeyID: "e1", Keys: []KeyConfig{{ID: "e1", Algorithm: AlgorithmEdDSA, KeyFile: edPriv}}},
+			validator: Config{Keys: []KeyConfig{{ID: "e1", Algorithm: AlgorithmEdDSA, KeyFile: edPub}}},
+		},
+		{
+			name:   "retired_key_still_validates",
+			signer: Config{ActiveKeyID: "old", Keys: []KeyConfig{{ID: "old", Algorithm: AlgorithmHS256, Secret: "old-secret"}}},
+			validator: Config{ActiveKeyID: "new", Keys: []KeyConfig{
+				{ID: "new", Algorithm: AlgorithmEdDSA, KeyFile: edPriv},
+				{ID: "old", Algorithm: AlgorithmHS256, Secret: "old-secret"},
+			}},
+		},
+		{
+			name:      "unknown_kid",
+			signer:    Config{ActiveKeyID: "gone", Keys: []KeyConfig{{ID: "gone", Algorithm: AlgorithmHS256, Secret: "gone-secret"}}},
+			validator: Config{ActiveKeyID: "new", Keys: []KeyConfig{{ID: "new", Algorithm: AlgorithmHS256, Secret: "new-secret"}}},
+			wantErr:   ErrUnknownKey,
+		},
+		{
+			name:      "algorithm_mismatch",
+			signer:    Config{ActiveKeyID: "k1", Keys: []KeyConfig{{ID: "k1", Algorithm: AlgorithmHS256, Secret: "secret"}}},
+			validator: Config{Keys: []KeyConfig{{ID: "k1", Algorithm: AlgorithmRS256, KeyFile: rsaPub}}},
+			wantErr:   ErrAlgMismatch,
+		},
+		{
+			name:      "wrong_secret",
+			signer:    Config{ActiveKeyID: "k1", Keys: []KeyConfig{{ID: "k1", Algorithm: AlgorithmHS256, Secret: "secret"}}},
+			validator: Config{ActiveKeyID: "k1", Keys: []KeyConfig{{ID: "k1", Algorithm: AlgorithmHS256, Secret: "other"}}},
+			wantErr:   jwt.ErrSignatureInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := NewJWTService(tt.signer)
+			require.NoError(t, err)
+			validator, err := NewJWTService(tt.validator)
+			require.NoError(t, err)
+
+			token, err := signer.GenerateToken(7, "johndoe", "support", []string{"users:read", "orders:read"})
+			require.NoError(t, err)
+
+			claims, err := validator.ValidateToken(token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, claims)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, int64(7), claims.UserID)
+			assert.Equal(t, "johndoe", claims.Username)
+			assert.Equal(t, "support", claims.Role)
+			assert.Equal(t, []string{"users:read", "orders:read"}, claims.Permissions)
+		})
+	}
+}
+
+func TestValidateTokenExpired(t *testing.T) {
+	claims := &Claims{UserID: 7, Username: "johndoe", StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(-time.Minute).Unix()}}
+	unsigned := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	unsigned.Header["kid"] = "hs"
+	token, err := unsigned.SignedString([]byte(testSecret))
+	require.NoError(t, err)
+
+	s, err := NewJWTService(hsConfig())
+	require.NoError(t, err)
+
+	_, err = s.ValidateToken(token)
+	assert.Equal(t, ErrExpiredToken, err)
+}
+
+func TestValidateTokenWithoutKid(t *testing.T) {
+	// tokens issued before key ids existed are checked against the active key
+	claims := &Claims{UserID: 7, Username: "johndoe", StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Hour).Unix()}}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(testSecret))
+	require.NoError(t, err)
+
+	s, err := NewJWTService(hsConfig())
+	require.NoError(t, err)
+
+	got, err := s.ValidateToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), got.UserID)
+}
+
+func TestNewJWTService(t *testing.T) {
+	_, rsaPub := rsaKeyFiles(t, "rsa")
+
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{
+			name: "no_keys",
+			cfg:  Config{},
+		},
+		{
+			name: "unknown_active_key",
+			cfg:  Config{ActiveKeyID: "missing", Keys: []KeyConfig{{ID: "k1", Algorithm: AlgorithmHS256, Secret: "s"}}},
+		},
+		{
+			name: "active_key_without_private_key",
+			cfg:  Config{ActiveKeyID: "r1", Keys: []KeyConfig{{ID: "r1", Algorithm: AlgorithmRS256, KeyFile: rsaPub}}},
+		},
+		{
+			name: "duplicate_key_id",
+			cfg: Config{Keys: []KeyConfig{
+				{ID: "k1", Algorithm: AlgorithmHS256, Secret: "a"},
+				{ID: "k1", Algorithm: AlgorithmHS256, Secret: "b"},
+			}},
+		},
+		{
+			name: "unsupported_algorithm",
+			cfg:  Config{Keys: []KeyConfig{{ID: "k1", Algorithm: "none"}}},
+		},
+		{
+			name: "missing_key_file",
+			cfg:  Config{Keys: []KeyConfig{{ID: "k1", Algorithm: AlgorithmEdDSA, KeyFile: "/does/not/exist.pem"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewJWTService(tt.cfg)
+			assert.Error(t, err)
+			assert.Nil(t, s)
+		})
+	}
+}
+
+func TestVerifyOnlyServiceCannotSign(t *testing.T) {
+	_, rsaPub := rsaKeyFiles(t, "rsa")
+	s, err := NewJWTService(Config{Keys: []KeyConfig{{ID: "r1", Algorithm: AlgorithmRS256, KeyFile: rsaPub}}})
+	require.NoError(t, err)
+
+	_, err = s.GenerateToken(7, "johndoe", "customer", nil)
+	assert.Equal(t, ErrNoSigningKey, err)
+}
+
+func TestParseKeySpec(t *testing.T) {
+	keys, err := ParseKeySpec("2024-06:RS256:/keys/2024-06.pem, 2024-01:EdDSA:/keys/2024-01.pub.pem")
+	require.NoError(t, err)
+	assert.Equal(t, []KeyConfig{
+		{ID: "2024-06", Algorithm: AlgorithmRS256, KeyFile: "/keys/2024-06.pem"},
+		{ID: "2024-01", Algorithm: AlgorithmEdDSA, KeyFile: "/keys/2024-01.pub.pem"},
+	}, keys)
+
+	_, err = ParseKeySpec("broken")
+	assert.Error(t, err)
+}
@@ -0,0 +1,154 @@
+package jwt
+
+import (
+	"crypto/ed25519"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+
+	// DefaultAccessTokenTTL is how long an access token is valid when the config doesn't say
+	DefaultAccessTokenTTL = 15 * time.Minute
+
+	// DefaultRefreshTokenTTL is how long a refresh token is valid when the config doesn't say
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// Config describes the keys a JWTService signs and validates with
+type Config struct {
+	// ActiveKeyID is the key new tokens are signed with, empty for verify-only
+	ActiveKeyID string
+	// Keys holds the active key and any retired keys still accepted
+	Keys            []KeyConfig
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// KeyConfig is a single key. HS256 keys use Secret (or a KeyFile holding the
+// secret). RS256 and EdDSA keys use a PEM KeyFile: a private key can sign and
+// verify, a public key can only verify.
+type KeyConfig struct {
+	ID        string
+	Algorithm string
+	Secret    string
+	KeyFile   string
+}
+
+type signingKey struct {
+	id        string
+	method    jwt.SigningMethod
+	signKey   interface{} // nil for verify-only keys
+	verifyKey interface{}
+}
+
+func loadKey(kc KeyConfig) (*signingKey, error) {
+	if kc.ID == "" {
+		return nil, errors.New("jwt: key id is required")
+	}
+
+	switch kc.Algorithm {
+	case AlgorithmHS256:
+		secret := []byte(kc.Secret)
+		if len(secret) == 0 && kc.KeyFile != "" {
+			data, err := os.ReadFile(kc.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("jwt: key %q: %w", kc.ID, err)
+			}
+			secret = []byte(strings.TrimSpace(string(data)))
+		}
+		if len(secret) == 0 {
+			return nil, fmt.Errorf("jwt: key %q: HS256 needs a secret", kc.ID)
+		}
+		return &signingKey{id: kc.ID, method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+
+	case AlgorithmRS256:
+		data, err := readPEM(kc)
+		if err != nil {
+			return nil, err
+		}
+		if isPrivatePEM(data) {
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("jwt: key %q: %w", kc.ID, err)
+			}
+			return &signingKey{id: kc.ID, method: jwt.SigningMethodRS256, signKey: priv, verifyKey: &priv.PublicKey}, nil
+		}
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %q: %w", kc.ID, err)
+		}
+		return &signingKey{id: kc.ID, method: jwt.SigningMethodRS256, verifyKey: pub}, nil
+
+	case AlgorithmEdDSA:
+		data, err := readPEM(kc)
+		if err != nil {
+			return nil, err
+		}
+		if isPrivatePEM(data) {
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(data)
+			if err != nil {
+				return nil, fmt.Errorf("jwt: key %q: %w", kc.ID, err)
+			}
+			edPriv, ok := priv.(ed25519.PrivateKey)
+			if !ok {
+				return nil, fmt.Errorf("jwt: key %q: not an Ed25519 key", kc.ID)
+			}
+			return &signingKey{id: kc.ID, method: jwt.SigningMethodEdDSA, signKey: edPriv, verifyKey: edPriv.Public()}, nil
+		}
+		pub, err := jwt.ParseEdPublicKeyFromPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: key %q: %w", kc.ID, err)
+		}
+		return &signingKey{id: kc.ID, method: jwt.SigningMethodEdDSA, verifyKey: pub}, nil
+
+	default:
+		return nil, fmt.Errorf("jwt: key %q: unsupported algorithm %q", kc.ID, kc.Algorithm)
+	}
+}
+
+func readPEM(kc KeyConfig) ([]byte, error) {
+	if kc.KeyFile == "" {
+		return nil, fmt.Errorf("jwt: key %q: %s needs a PEM key file", kc.ID, kc.Algorithm)
+	}
+	data, err := os.ReadFile(kc.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: key %q: %w", kc.ID, err)
+	}
+	return data, nil
+}
+
+func isPrivatePEM(data []byte) bool {
+	block, _ := pem.Decode(data)
+	return block != nil && strings.HasSuffix(block.Type, "PRIVATE KEY")
+}
+
+// ParseKeySpec parses comma separated kid:ALG:file entries
+func ParseKeySpec(spec string) ([]KeyConfig, error) {
+	var keys []KeyConfig
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("jwt: invalid key entry %q, want kid:ALG:file", entry)
+		}
+		keys = append(keys, KeyConfig{
+			ID:        parts[0],
+			Algorithm: parts[1],
+			KeyFile:   parts[2],
+		})
+	}
+	return keys, nil
+}
@@ -6,6 +6,8 @@ import (
 	jwt "sonartest_cart/pkg/jwt"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // JWTService is an autogenerated mock type for the JWTService type
@@ -13,9 +15,27 @@ type JWTService struct {
 	mock.Mock
 }
 
-// GenerateToken provides a mock function with given fields: userID, username, isadmin
-func (_m *JWTService) GenerateToken(userID int64, username string, isadmin bool) (string, error) {
-	ret := _m.Called(userID, username, isadmin)
+// AccessTokenTTL provides a mock function with given fields:
+func (_m *JWTService) AccessTokenTTL() time.Duration {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for AccessTokenTTL")
+	}
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func() time.Duration); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	return r0
+}
+
+// GenerateToken provides a mock function with given fields: userID, username, role, permissions
+func (_m *JWTService) GenerateToken(userID int64, username string, role string, permissions []string) (string, error) {
+	ret := _m.Called(userID, username, role, permissions)
 
 	if len(ret) == 0 {
 		panic("no return value specified for GenerateToken")
@@ -23,17 +43,17 @@ func (_m *JWTService) GenerateToken(userID int64, username string, isadmin bool)
 
 	var r0 string
 	var r1 error
-	if rf, ok := ret.Get(0).(func(int64, string, bool) (string, error)); ok {
-		return rf(userID, username, isadmin)
+	if rf, ok := ret.Get(0).(func(int64, string, string, []string) (string, error)); ok {
+		return rf(userID, username, role, permissions)
 	}
-	if rf, ok := ret.Get(0).(func(int64, string, bool) string); ok {
-		r0 = rf(userID, username, isadmin)
+	if rf, ok := ret.Get(0).(func(int64, string, string, []string) string); ok {
+		r0 = rf(userID, username, role, permissions)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
-	if rf, ok := ret.Get(1).(func(int64, string, bool) error); ok {
-		r1 = rf(userID, username, isadmin)
+	if rf, ok := ret.Get(1).(func(int64, string, string, []string) error); ok {
+		r1 = rf(userID, username, role, permissions)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -41,6 +61,24 @@ func (_m *JWTService) GenerateToken(userID int64, username string, isadmin bool)
 	return r0, r1
 }
 
+// RefreshTokenTTL provides a mock function with given fields:
+func (_m *JWTService) RefreshTokenTTL() time.Duration {
+	ret := _m.Called()
+
+	if len(ret) == 0 {
+		panic("no return value specified for RefreshTokenTTL")
+	}
+
+	var r0 time.Duration
+	if rf, ok := ret.Get(0).(func() time.Duration); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(time.Duration)
+	}
+
+	return r0
+}
+
 // ValidateToken provides a mock function with given fields: tokenStr
 func (_m *JWTService) ValidateToken(tokenStr string) (*jwt.Claims, error) {
 	ret := _m.Called(tokenStr)
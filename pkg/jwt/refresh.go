@@ -0,0 +1,34 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewRefreshToken returns an opaque random refresh token for the client.
+// Only HashRefreshToken of it should ever be stored.
+func NewRefreshToken() (string, error) {
+	return randomString(32)
+}
+
+// HashRefreshToken returns the value stored for a refresh token. The token is
+// random with 256 bits of entropy, so a plain sha256 is enough here.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewTokenID returns a random id used for jti claims and token families
+func NewTokenID() (string, error) {
+	return randomString(16)
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
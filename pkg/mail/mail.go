@@ -0,0 +1,121 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Drivers
+const (
+	DriverSMTP = "smtp"
+	// DriverFile writes every mail to an outbox directory, for local development
+	DriverFile = "file"
+	// DriverNone drops every mail
+	DriverNone = "none"
+)
+
+var ErrUnknownDriver = errors.New("unknown mail driver")
+
+// Message is a plain text mail to a single recipient
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer defines the interface for sending mails
+//
+//go:generate mockery --name Mailer --output mocks --outpkg mocks
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config selects and configures a Mailer
+type Config struct {
+	Driver string
+	From   string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	SMTPTimeout  time.Duration
+
+	OutboxDir string
+}
+
+// NewMailer creates the Mailer for cfg.Driver
+func NewMailer(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg)
+	case DriverFile:
+		return NewFileMailer(cfg.OutboxDir, cfg.From)
+	case DriverNone:
+		return NopMailer{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownDriver, cfg.Driver)
+	}
+}
+
+// NopMailer drops every mail, used when no mail driver is configured
+type NopMailer struct{}
+
+func (NopMailer) Send(ctx context.Context, msg Message) error {
+	log.Ctx(ctx).Debug().Str("subject", msg.Subject).Msg("Mail dropped, no mail driver configured")
+	return nil
+}
+
+// build renders msg as an RFC 5322 message with CRLF line endings
+func build(from string, msg Message, date time.Time) ([]byte, error) {
+	// header injection, a newline would let the value add headers of its own
+	for _, v := range []string{from, msg.To, msg.Subject} {
+		if strings.ContainsAny(v, "\r\n") {
+			return nil, errors.New("mail: header value contains a newline")
+		}
+	}
+	if _, err := mail.ParseAddress(msg.To); err != nil {
+		return nil, fmt.Errorf("mail: invalid recipient: %w", err)
+	}
+
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		buf.WriteString(name + ": " + value + "\r\n")
+	}
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	w := quotedprintable.NewWriter(&buf)
+	body := strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n")
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// address returns the bare address of a From value like "Shop <no-reply@shop.example>"
+func address(from string) (string, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return "", fmt.Errorf("mail: invalid sender: %w", err)
+	}
+	return addr.Address, nil
+}
@@ -0,0 +1,155 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"mime"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testFrom = "Shop <no-reply@shop.example>"
+
+func TestNewMailer(t *testing.T) {
+	m, err := NewMailer(Config{Driver: DriverNone})
+	require.NoError(t, err)
+	assert.NoError(t, m.Send(context.Background(), Message{To: "a@b.io"}))
+
+	m, err = NewMailer(Config{Driver: DriverFile, From: testFrom, OutboxDir: t.TempDir()})
+	require.NoError(t, err)
+	assert.IsType(t, &FileMailer{}, m)
+
+	_, err = NewMailer(Config{Driver: DriverSMTP, From: testFrom})
+	assert.EqualError(t, err, "mail: smtp host is required")
+
+	_, err = NewMailer(Config{Driver: "carrier-pigeon"})
+	assert.ErrorIs(t, err, ErrUnknownDriver)
+}
+
+func TestBuild(t *testing.T) {
+	date := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	data, err := build(testFrom, Message{To: "john@example.com", Subject: "Grüße", Body: "line one\nline two"}, date)
+	require.NoError(t, err)
+
+	msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+	require.NoError(t, err)
+	assert.Equal(t, testFrom, msg.Header.Get("From"))
+	assert.Equal(t, "john@example.com", msg.Header.Get("To"))
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	assert.Equal(t, "Grüße", subject)
+	assert.Contains(t, string(data), "line one\r\nline two")
+}
+
+func TestBuildRejectsHeaderInjection(t *testing.T) {
+	for name, msg := range map[string]Message{
+		"subject":   {To: "john@example.com", Subject: "hi\r\nBcc: everyone@example.com"},
+		"recipient": {To: "john@example.com\nBcc: everyone@example.com"},
+		"invalid":   {To: "not an address"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := build(testFrom, msg, time.Now())
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m, err := NewFileMailer(dir, testFrom)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Send(context.Background(), Message{To: "john@example.com", Subject: "Verify", Body: "https://shop.example/verify?token=abc"}))
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.True(t, strings.HasSuffix(files[0].Name(), ".eml"))
+
+	info, err := files[0].Info()
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	data, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "To: john@example.com")
+	assert.Contains(t, string(data), "token=3Dabc") // quoted-printable '='
+}
+
+func TestSMTPMailer(t *testing.T) {
+	received := make(chan string, 1)
+	addr := fakeSMTPServer(t, received)
+	host, port, _ := net.SplitHostPort(addr)
+	portNum, _ := strconv.Atoi(port)
+
+	m, err := NewSMTPMailer(Config{From: testFrom, SMTPHost: host, SMTPPort: portNum, SMTPTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	require.NoError(t, m.Send(context.Background(), Message{To: "john@example.com", Subject: "Verify", Body: "hello"}))
+
+	transcript := <-received
+	assert.Contains(t, transcript, "MAIL FROM:<no-reply@shop.example>")
+	assert.Contains(t, transcript, "RCPT TO:<john@example.com>")
+	assert.Contains(t, transcript, "Subject: Verify")
+	assert.Contains(t, transcript, "QUIT")
+}
+
+// fakeSMTPServer accepts a single delivery and sends everything the client
+// said to received
+func fakeSMTPServer(t *testing.T, received chan<- string) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var transcript strings.Builder
+		r := bufio.NewReader(conn)
+		reply := func(s string) { _, _ = io.WriteString(conn, s+"\r\n") }
+
+		reply("220 fake ESMTP")
+		inData := false
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			transcript.WriteString(line)
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case inData:
+				if cmd == "." {
+					inData = false
+					reply("250 queued")
+				}
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250 fake")
+			case cmd == "DATA":
+				inData = true
+				reply("354 go ahead")
+			case cmd == "QUIT":
+				reply("221 bye")
+				received <- transcript.String()
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+		received <- transcript.String()
+	}()
+	return ln.Addr().String()
+}
@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// DefaultSMTPTimeout bounds a whole delivery when the context has no deadline
+const DefaultSMTPTimeout = 10 * time.Second
+
+// SMTPMailer delivers mails to an SMTP relay. STARTTLS is used whenever the
+// server offers it, credentials are only sent over TLS or to localhost.
+type SMTPMailer struct {
+	addr    string
+	host    string
+	from    string
+	auth    smtp.Auth
+	timeout time.Duration
+}
+
+func NewSMTPMailer(cfg Config) (*SMTPMailer, error) {
+	if cfg.SMTPHost == "" {
+		return nil, errors.New("mail: smtp host is required")
+	}
+	if _, err := address(cfg.From); err != nil {
+		return nil, err
+	}
+
+	m := &SMTPMailer{
+		addr:    net.JoinHostPort(cfg.SMTPHost, strconv.Itoa(cfg.SMTPPort)),
+		host:    cfg.SMTPHost,
+		from:    cfg.From,
+		timeout: cfg.SMTPTimeout,
+	}
+	if m.timeout <= 0 {
+		m.timeout = DefaultSMTPTimeout
+	}
+	if cfg.SMTPUsername != "" {
+		m.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return m, nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	data, err := build(m.from, msg, time.Now())
+	if err != nil {
+		return err
+	}
+	from, _ := address(m.from)
+	to, _ := address(msg.To)
+
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", m.addr)
+	if err != nil {
+		return fmt.Errorf("mail: dial %s: %w", m.addr, err)
+	}
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	c, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mail: smtp greeting: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: m.host}); err != nil {
+			return fmt.Errorf("mail: starttls: %w", err)
+		}
+	}
+	if m.auth != nil {
+		if err := c.Auth(m.auth); err != nil {
+			return fmt.Errorf("mail: smtp auth: %w", err)
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return fmt.Errorf("mail: smtp MAIL FROM: %w", err)
+	}
+	if err := c.Rcpt(to); err != nil {
+		return fmt.Errorf("mail: smtp RCPT TO: %w", err)
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("mail: smtp DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("mail: smtp DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mail: smtp DATA: %w", err)
+	}
+	return c.Quit()
+}
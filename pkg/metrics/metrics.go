@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/rs/zerolog/log"
+)
+
+const namespace = "cart"
+
+// Login outcomes
+const (
+	LoginSuccess            = "success"
+	LoginInvalidCredentials = "invalid_credentials"
+	LoginBlocked            = "blocked"
+	LoginLocked             = "locked"
+	LoginUnverified         = "unverified"
+	LoginError              = "error"
+)
+
+// Registry holds every collector of the service, it is served on /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, chi route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, chi route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	APIErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_errors_total",
+		Help:      "Error responses by application error code.",
+	}, []string{"code"})
+
+	RateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_total",
+		Help:      "Requests rejected by a rate limiter, by limiter.",
+	}, []string{"limiter"})
+
+	AccountLockouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "account_lockouts_total",
+		Help:      "Accounts locked after too many failed logins.",
+	})
+
+	Signups = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "signups_total",
+		Help:      "Users that signed up.",
+	})
+
+	Logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Login attempts by outcome.",
+	}, []string{"outcome"})
+
+	OrdersPlaced = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "orders_placed_total",
+		Help:      "Orders placed.",
+	})
+
+	CartsCleared = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "carts_cleared_total",
+		Help:      "Non-empty carts the user emptied.",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests, HTTPDuration, APIErrors, RateLimited, AccountLockouts,
+		Signups, Logins, OrdersPlaced, CartsCleared,
+	)
+	for _, outcome := range []string{LoginSuccess, LoginInvalidCredentials, LoginBlocked, LoginLocked, LoginUnverified, LoginError} {
+		Logins.WithLabelValues(outcome)
+	}
+}
+
+// Handler serves the registry in the Prometheus text format. With a token
+// scrapers have to send it as a bearer token, empty leaves it open.
+func Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+	if token == "" {
+		return handler
+	}
+	want := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// RegisterDBStats exports the connection pool stats of db
+func RegisterDBStats(db *sql.DB, name string) error {
+	return Registry.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterAbandonedCarts exports the number of carts nobody touched for
+// idleAfter, count is asked on every scrape with the carts idle since then
+func RegisterAbandonedCarts(idleAfter time.Duration, count func(ctx context.Context, idleSince time.Time) (int64, error)) error {
+	return Registry.Register(abandonedCarts(idleAfter, count))
+}
+
+func abandonedCarts(idleAfter time.Duration, count func(ctx context.Context, idleSince time.Time) (int64, error)) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace:   namespace,
+		Name:        "carts_abandoned",
+		Help:        "Non-empty carts without a change for longer than idle_after.",
+		ConstLabels: prometheus.Labels{"idle_after": idleAfter.String()},
+	}, func() float64 {
+		// a slow database must not hold up the scrape
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		n, err := count(ctx, time.Now().Add(-idleAfter))
+		if err != nil {
+			log.Error().Err(err).Msg("failed to count abandoned carts")
+			return math.NaN()
+		}
+		return float64(n)
+	})
+}
+
+// Login counts a login attempt with one of the Login* outcomes
+func Login(outcome string) {
+	Logins.WithLabelValues(outcome).Inc()
+}
+
+// APIError counts an error response with the given application error code
+func APIError(code int) {
+	APIErrors.WithLabelValues(strconv.Itoa(code)).Inc()
+}
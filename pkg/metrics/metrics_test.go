@@ -0,0 +1,63 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		header string
+		status int
+	}{
+		{name: "open", status: http.StatusOK},
+		{name: "token", token: "scrape-secret", header: "Bearer scrape-secret", status: http.StatusOK},
+		{name: "missing_token", token: "scrape-secret", status: http.StatusUnauthorized},
+		{name: "wrong_token", token: "scrape-secret", header: "Bearer scrape-secre", status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			Handler(tt.token).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+			if tt.status == http.StatusOK {
+				assert.Contains(t, rec.Body.String(), "cart_carts_cleared_total")
+			} else {
+				assert.NotContains(t, rec.Body.String(), "cart_")
+			}
+		})
+	}
+}
+
+func TestAbandonedCarts(t *testing.T) {
+	var asked time.Time
+	gauge := abandonedCarts(24*time.Hour, func(_ context.Context, idleSince time.Time) (int64, error) {
+		asked = idleSince
+		return 3, nil
+	})
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(gauge))
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), asked, time.Minute)
+
+	failing := abandonedCarts(time.Hour, func(context.Context, time.Time) (int64, error) {
+		return 0, errors.New("db down")
+	})
+	assert.True(t, math.IsNaN(testutil.ToFloat64(failing)))
+}
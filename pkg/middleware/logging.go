@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"sonartest_cart/pkg/api"
+
+	"github.com/go-chi/chi/v5"
+	chimw "github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const RequestIDKey contextKey = "requestid"
+
+// maxRequestIDLength keeps clients from stuffing the logs through the header
+const maxRequestIDLength = 128
+
+// RequestID keeps the X-Request-ID sent by the client (or a proxy in front
+// of us) and generates one otherwise. The id is echoed on the response and
+// stored in the context.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(api.RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(api.RequestIDHeader, id)
+		ctx := context.WithValue(r.Context(), RequestIDKey, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetRequestID returns the id stored by RequestID, or "" outside a request
+func GetRequestID(ctx context.Context) string {
+	id, _ := ctx.Value(RequestIDKey).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// still unique enough to find the request in the logs
+		return time.Now().UTC().Format("20060102T150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestLogger puts a logger tagged with the request id and route in the
+// context, services log through log.Ctx(r.Context()). Once the request is
+// served it writes one access log line. Must run after RequestID and Tracing.
+func RequestLogger(base zerolog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			logCtx := base.With().Str("request_id", GetRequestID(r.Context()))
+			// ties log lines to the trace when Tracing runs first
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				logCtx = logCtx.Str("trace_id", sc.TraceID().String())
+			}
+			logger := logCtx.Logger()
+			// the route pattern is only known once chi has routed the request
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				logger = logger.Hook(routeHook{rctx: rctx})
+			}
+
+			ctx := logger.WithContext(r.Context())
+			ww := chimw.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			// the context holds its own copy, it may have gained the user id meanwhile
+			logger = *zerolog.Ctx(ctx)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			var event *zerolog.Event
+			switch {
+			case status >= http.StatusInternalServerError:
+				event = logger.Error()
+			case status >= http.StatusBadRequest:
+				event = logger.Warn()
+			default:
+				event = logger.Info()
+			}
+			event.
+				Str("method", r.Method).
+				Str("path", r.URL.Path).
+				Int("status", status).
+				Int("bytes", ww.BytesWritten()).
+				Dur("duration", time.Since(start)).
+				Str("remote_addr", r.RemoteAddr).
+				Str("user_agent", r.UserAgent()).
+				Msg("request completed")
+		})
+	}
+}
+
+type routeHook struct {
+	rctx *chi.Context
+}
+
+func (h routeHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if route := h.rctx.RoutePattern(); route != "" {
+		e.Str("route", route)
+	}
+}
+
+// addUserToLogger tags every later log line of the request, including the
+// access log line, with the authenticated user
+func addUserToLogger(ctx context.Context, userID int64) {
+	logger := zerolog.Ctx(ctx)
+	// without a request logger Ctx hands out the shared default logger, which must not be touched
+	if logger == zerolog.DefaultContextLogger {
+		return
+	}
+	logger.UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Int64("user_id", userID)
+	})
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sonartest_cart/pkg/api"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{name: "generated", header: ""},
+		{name: "propagated", header: "2f1c-abc_1.2", keep: true},
+		{name: "invalid_characters", header: "abc\ndef"},
+		{name: "too_long", header: strings.Repeat("a", maxRequestIDLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inCtx string
+			handler := RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				inCtx = GetRequestID(r.Context())
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(api.RequestIDHeader, tt.header)
+			}
+			res := httptest.NewRecorder()
+			handler.ServeHTTP(res, req)
+
+			got := res.Header().Get(api.RequestIDHeader)
+			require.NotEmpty(t, got)
+			assert.Equal(t, got, inCtx)
+			if tt.keep {
+				assert.Equal(t, tt.header, got)
+			} else {
+				assert.NotEqual(t, tt.header, got)
+				assert.Len(t, got, 32)
+			}
+		})
+	}
+}
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	r := chi.NewRouter()
+	r.Use(RequestID, RequestLogger(zerolog.New(&buf)))
+	r.Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		addUserToLogger(r.Context(), 7)
+		zerolog.Ctx(r.Context()).Info().Msg("loading order")
+		api.Fail(w, http.StatusNotFound, 404000, "order not found")
+	})
+
+	req := httptest.NewRequest("GET", "/orders/42", nil)
+	req.Header.Set(api.RequestIDHeader, "req-1")
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+
+	// the request id is echoed in the error body
+	var body api.Response
+	require.NoError(t, json.Unmarshal(res.Body.Bytes(), &body))
+	assert.Equal(t, "req-1", body.Error.RequestID)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var serviceLine, accessLine map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &serviceLine))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &accessLine))
+
+	assert.Equal(t, "loading order", serviceLine["message"])
+	assert.Equal(t, "req-1", serviceLine["request_id"])
+	assert.Equal(t, "/orders/{id}", serviceLine["route"])
+	assert.Equal(t, float64(7), serviceLine["user_id"])
+
+	assert.Equal(t, "request completed", accessLine["message"])
+	assert.Equal(t, "warn", accessLine["level"])
+	assert.Equal(t, "req-1", accessLine["request_id"])
+	assert.Equal(t, "/orders/{id}", accessLine["route"])
+	assert.Equal(t, "/orders/42", accessLine["path"])
+	assert.Equal(t, float64(7), accessLine["user_id"])
+	assert.Equal(t, float64(http.StatusNotFound), accessLine["status"])
+}
+
+func TestAddUserToLoggerWithoutRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	shared := zerolog.New(&buf)
+	previous := zerolog.DefaultContextLogger
+	zerolog.DefaultContextLogger = &shared
+	t.Cleanup(func() { zerolog.DefaultContextLogger = previous })
+
+	addUserToLogger(httptest.NewRequest("GET", "/", nil).Context(), 7)
+	shared.Info().Msg("unrelated")
+
+	assert.NotContains(t, buf.String(), "user_id")
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/metrics"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	r := chi.NewRouter()
+	r.Use(Metrics)
+	r.Get("/cart/{brandid}", func(w http.ResponseWriter, r *http.Request) {
+		api.Fail(w, http.StatusNotFound, 404999, "not in cart")
+	})
+	r.Get("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	})
+
+	requests := func(method, route, status string) float64 {
+		return testutil.ToFloat64(metrics.HTTPRequests.WithLabelValues(method, route, status))
+	}
+	before := map[string]float64{
+		"pattern":   requests("GET", "/cart/{brandid}", "404"),
+		"implicit":  requests("GET", "/hello", "200"),
+		"unmatched": requests("GET", unmatchedRoute, "404"),
+		"error":     testutil.ToFloat64(metrics.APIErrors.WithLabelValues("404999")),
+	}
+
+	for _, path := range []string{"/cart/1", "/cart/2", "/hello", "/nope/3"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	// ids in the path must not end up in the labels
+	assert.Equal(t, before["pattern"]+2, requests("GET", "/cart/{brandid}", "404"))
+	assert.Equal(t, before["implicit"]+1, requests("GET", "/hello", "200"), "no WriteHeader means 200")
+	assert.Equal(t, before["unmatched"]+1, requests("GET", unmatchedRoute, "404"))
+	assert.Equal(t, before["error"]+2, testutil.ToFloat64(metrics.APIErrors.WithLabelValues("404999")))
+}
@@ -2,18 +2,27 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/auth"
 	"sonartest_cart/pkg/jwt"
+	"sonartest_cart/pkg/rbac"
 	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
 )
 
 type contextKey string
 
 const (
-	UserIDKey   contextKey = "userid"
-	UsernameKey contextKey = "username"
-	IsAdminKey  contextKey = "isadmin"
+	UserIDKey      contextKey = "userid"
+	UsernameKey    contextKey = "username"
+	RoleKey        contextKey = "role"
+	PermissionsKey contextKey = "perms"
+	TokenIDKey     contextKey = "jti"
+	ExpiresKey     contextKey = "exp"
 )
 
 // JWTMiddleware defines the interface for middleware methods
@@ -21,18 +30,41 @@ const (
 //go:generate mockgen -destination=mock_jwtmiddleware.go -package=middleware . JWTMiddleware
 type JWTMiddleware interface {
 	JWTAuthMiddleware(next http.Handler) http.Handler
-	AdminOnlyMiddleware(next http.Handler) http.Handler
+	RequirePermission(permission rbac.Permission) func(next http.Handler) http.Handler
+}
+
+// UserStatusChecker reports whether a user may still use the API and when
+// they last changed their password (zero if never). A token stays valid until
+// it expires, so blocked users and sessions older than the password are caught here.
+type UserStatusChecker interface {
+	UserStatus(ctx context.Context, userID int64) (bool, time.Time, error)
+}
+
+// TokenRevocationChecker reports whether an access token was revoked (e.g. on logout)
+type TokenRevocationChecker interface {
+	IsTokenRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// MailVerificationChecker reports whether a user confirmed their mail address
+type MailVerificationChecker interface {
+	IsMailVerified(ctx context.Context, userID int64) (bool, error)
 }
 
 // JWTMiddlewareImpl is the concrete implementation
 // It holds a reference to a JWTService
 type JWTMiddlewareImpl struct {
-	jwtService jwt.JWTService
+	jwtService        jwt.JWTService
+	statusChecker     UserStatusChecker
+	revocationChecker TokenRevocationChecker
 }
 
 // NewJWTMiddleware creates a new JWTMiddleware with the given JWTService
-func NewJWTMiddleware(jwtService jwt.JWTService) JWTMiddleware {
-	return &JWTMiddlewareImpl{jwtService: jwtService}
+func NewJWTMiddleware(jwtService jwt.JWTService, statusChecker UserStatusChecker, revocationChecker TokenRevocationChecker) JWTMiddleware {
+	return &JWTMiddlewareImpl{
+		jwtService:        jwtService,
+		statusChecker:     statusChecker,
+		revocationChecker: revocationChecker,
+	}
 }
 
 // middleware for users routes
@@ -64,10 +96,57 @@ func (m *JWTMiddlewareImpl) JWTAuthMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
+		// Every access token carries an id, without one it could neither be
+		// checked for revocation nor be logged out
+		if claims.Id == "" {
+			api.Fail(w, http.StatusUnauthorized, 401, "Invalid token, please log in again", "token has no id")
+			return
+		}
+
+		// Reject tokens revoked by logout
+		revoked, err := m.revocationChecker.IsTokenRevoked(r.Context(), claims.Id)
+		if err != nil {
+			log.Ctx(r.Context()).Error().Err(err).Msg("Could not check token revocation")
+			api.Fail(w, http.StatusInternalServerError, 500, "Could not verify token", "")
+			return
+		}
+		if revoked {
+			api.Fail(w, http.StatusUnauthorized, 401, "Token revoked, please log in again", "")
+			return
+		}
+
+		// Reject tokens of users blocked after the token was issued
+		isActive, passwordChangedAt, err := m.statusChecker.UserStatus(r.Context(), claims.UserID)
+		if err != nil {
+			if errors.Is(err, auth.ErrUserNotFound) {
+				api.Fail(w, http.StatusUnauthorized, 401, "Invalid token", "")
+				return
+			}
+			log.Ctx(r.Context()).Error().Err(err).Int64("userid", claims.UserID).Msg("Could not check user status")
+			api.Fail(w, http.StatusInternalServerError, 500, "Could not verify user", "")
+			return
+		}
+		if !isActive {
+			api.Fail(w, http.StatusForbidden, 403, "User is blocked", "")
+			return
+		}
+
+		// Reject tokens issued before the password was changed, iat only has
+		// second precision so a token of the same second is still accepted
+		if !passwordChangedAt.IsZero() && claims.IssuedAt < passwordChangedAt.Unix() {
+			api.Fail(w, http.StatusUnauthorized, 401, "Password changed, please log in again", "")
+			return
+		}
+
+		addUserToLogger(r.Context(), claims.UserID)
+
 		// Store userid and username in context
 		ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, UsernameKey, claims.Username)
-		ctx = context.WithValue(ctx, IsAdminKey, claims.IsAdmin)
+		ctx = context.WithValue(ctx, RoleKey, claims.Role)
+		ctx = context.WithValue(ctx, PermissionsKey, claims.Permissions)
+		ctx = context.WithValue(ctx, TokenIDKey, claims.Id)
+		ctx = context.WithValue(ctx, ExpiresKey, time.Unix(claims.ExpiresAt, 0))
 
 		// Pass control to the next handler
 		r = r.WithContext(ctx)
@@ -75,14 +154,41 @@ func (m *JWTMiddlewareImpl) JWTAuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// middleware for admin-only routes
-func (m *JWTMiddlewareImpl) AdminOnlyMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		isAdmin, ok := r.Context().Value(IsAdminKey).(bool)
-		if !ok || !isAdmin {
-			api.Fail(w, http.StatusForbidden, 403, "Admin access required", "")
-			return
-		}
-		next.ServeHTTP(w, r)
-	})
+// RequirePermission lets only users whose token grants permission through,
+// it has to run after JWTAuthMiddleware
+func (m *JWTMiddlewareImpl) RequirePermission(permission rbac.Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted, _ := r.Context().Value(PermissionsKey).([]string)
+			if !rbac.Has(granted, permission) {
+				role, _ := r.Context().Value(RoleKey).(string)
+				log.Ctx(r.Context()).Warn().Str("role", role).Str("permission", permission).Msg("Permission denied")
+				api.Fail(w, http.StatusForbidden, 403, "Permission denied", "missing permission "+permission)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireVerifiedMail lets only users who verified their mail address
+// through. It asks checker on every request, so a verification counts
+// without logging in again. It has to run after JWTAuthMiddleware.
+func RequireVerifiedMail(checker MailVerificationChecker) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, _ := r.Context().Value(UserIDKey).(int64)
+			verified, err := checker.IsMailVerified(r.Context(), userID)
+			if err != nil {
+				log.Ctx(r.Context()).Error().Err(err).Int64("userid", userID).Msg("Could not check mail verification")
+				api.Fail(w, http.StatusInternalServerError, 500, "Could not verify mail address", "")
+				return
+			}
+			if !verified {
+				api.Fail(w, http.StatusForbidden, 403, "Mail address not verified", "follow the link in the verification mail first")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
 }
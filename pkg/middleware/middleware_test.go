@@ -0,0 +1,269 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sonartest_cart/pkg/auth"
+	"sonartest_cart/pkg/jwt"
+	jwtmocks "sonartest_cart/pkg/jwt/mocks"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStatusChecker struct {
+	active            bool
+	passwordChangedAt time.Time
+	err               error
+}
+
+func (f fakeStatusChecker) UserStatus(_ context.Context, userID int64) (bool, time.Time, error) {
+	return f.active, f.passwordChangedAt, f.err
+}
+
+type fakeRevocationChecker struct {
+	revoked bool
+	err     error
+}
+
+func (f fakeRevocationChecker) IsTokenRevoked(_ context.Context, jti string) (bool, error) {
+	return f.revoked, f.err
+}
+
+func TestJWTAuthMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    string
+		mock      func(m *jwtmocks.JWTService)
+		checker   fakeStatusChecker
+		revoker   fakeRevocationChecker
+		status    int
+		body      string
+		reachNext bool
+	}{
+		{
+			name:   "fail_missing_header",
+			mock:   func(m *jwtmocks.JWTService) {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "fail_invalid_format",
+			header: "token",
+			mock:   func(m *jwtmocks.JWTService) {},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "fail_expired_token",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(nil, jwt.ErrExpiredToken).Once()
+			},
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "fail_token_without_id",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(&jwt.Claims{UserID: 2, Username: "johndoe"}, nil).Once()
+			},
+			checker: fakeStatusChecker{active: true},
+			status:  http.StatusUnauthorized,
+			body:    `"message":"Invalid token, please log in again"`,
+		},
+		{
+			name:   "fail_token_revoked",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(&jwt.Claims{UserID: 2, Username: "johndoe", StandardClaims: gojwt.StandardClaims{Id: "jti-1"}}, nil).Once()
+			},
+			checker: fakeStatusChecker{active: true},
+			revoker: fakeRevocationChecker{revoked: true},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:   "fail_revocation_check_error",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(&jwt.Claims{UserID: 2, Username: "johndoe", StandardClaims: gojwt.StandardClaims{Id: "jti-1"}}, nil).Once()
+			},
+			revoker: fakeRevocationChecker{err: errors.New("db error")},
+			status:  http.StatusInternalServerError,
+			body:    `"message":"Could not verify token"`,
+		},
+		{
+			name:   "fail_user_not_found",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(&jwt.Claims{UserID: 2, Username: "johndoe", StandardClaims: gojwt.StandardClaims{Id: "jti-1"}}, nil).Once()
+			},
+			checker: fakeStatusChecker{err: auth.ErrUserNotFound},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:   "fail_status_check_error",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(&jwt.Claims{UserID: 2, Username: "johndoe", StandardClaims: gojwt.StandardClaims{Id: "jti-1"}}, nil).Once()
+			},
+			checker: fakeStatusChecker{err: errors.New("db error")},
+			status:  http.StatusInternalServerError,
+			body:    `"message":"Could not verify user"`,
+		},
+		{
+			name:   "fail_user_blocked",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(&jwt.Claims{UserID: 2, Username: "johndoe", StandardClaims: gojwt.StandardClaims{Id: "jti-1"}}, nil).Once()
+			},
+			checker: fakeStatusChecker{active: false},
+			status:  http.StatusForbidden,
+		},
+		{
+			name:   "fail_password_changed_after_issue",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(&jwt.Claims{UserID: 2, Username: "johndoe", StandardClaims: gojwt.StandardClaims{Id: "jti-1", IssuedAt: 1000}}, nil).Once()
+			},
+			checker: fakeStatusChecker{active: true, passwordChangedAt: time.Unix(1001, 0)},
+			status:  http.StatusUnauthorized,
+		},
+		{
+			name:   "success_issued_after_password_change",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(&jwt.Claims{UserID: 2, Username: "johndoe", Role: "support", Permissions: []string{"users:read"}, StandardClaims: gojwt.StandardClaims{Id: "jti-1", IssuedAt: 1001}}, nil).Once()
+			},
+			checker:   fakeStatusChecker{active: true, passwordChangedAt: time.Unix(1001, 500)},
+			status:    http.StatusOK,
+			reachNext: true,
+		},
+		{
+			name:   "success_case",
+			header: "Bearer token",
+			mock: func(m *jwtmocks.JWTService) {
+				m.On("ValidateToken", "token").Return(&jwt.Claims{UserID: 2, Username: "johndoe", Role: "support", Permissions: []string{"users:read"}, StandardClaims: gojwt.StandardClaims{Id: "jti-1"}}, nil).Once()
+			},
+			checker:   fakeStatusChecker{active: true},
+			status:    http.StatusOK,
+			reachNext: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jwtMock := jwtmocks.NewJWTService(t)
+			tt.mock(jwtMock)
+			m := NewJWTMiddleware(jwtMock, tt.checker, tt.revoker)
+
+			reached := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+				assert.Equal(t, int64(2), r.Context().Value(UserIDKey))
+				assert.Equal(t, "jti-1", r.Context().Value(TokenIDKey))
+				assert.Equal(t, "support", r.Context().Value(RoleKey))
+				assert.Equal(t, []string{"users:read"}, r.Context().Value(PermissionsKey))
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/cart", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			res := httptest.NewRecorder()
+
+			m.JWTAuthMiddleware(next).ServeHTTP(res, req)
+
+			assert.Equal(t, tt.status, res.Code)
+			assert.Equal(t, tt.reachNext, reached)
+			if tt.body != "" {
+				assert.Contains(t, res.Body.String(), tt.body)
+				assert.NotContains(t, res.Body.String(), "db error")
+			}
+		})
+	}
+}
+
+type fakeMailChecker struct {
+	verified map[int64]bool
+	err      error
+}
+
+func (f fakeMailChecker) IsMailVerified(_ context.Context, userID int64) (bool, error) {
+	return f.verified[userID], f.err
+}
+
+func TestRequirePermission(t *testing.T) {
+	tests := []struct {
+		name      string
+		perms     interface{}
+		status    int
+		reachNext bool
+	}{
+		{name: "granted", perms: []string{"users:read", "users:block"}, status: http.StatusOK, reachNext: true},
+		{name: "other_permission", perms: []string{"catalog:write"}, status: http.StatusForbidden},
+		{name: "no_permissions", perms: []string(nil), status: http.StatusForbidden},
+		{name: "no_token", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewJWTMiddleware(jwtmocks.NewJWTService(t), fakeStatusChecker{}, fakeRevocationChecker{})
+
+			reached := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("GET", "/admin/users", nil)
+			if tt.perms != nil {
+				req = req.WithContext(context.WithValue(req.Context(), PermissionsKey, tt.perms))
+			}
+			res := httptest.NewRecorder()
+
+			m.RequirePermission("users:block")(next).ServeHTTP(res, req)
+
+			assert.Equal(t, tt.status, res.Code)
+			assert.Equal(t, tt.reachNext, reached)
+		})
+	}
+}
+
+func TestRequireVerifiedMail(t *testing.T) {
+	checker := fakeMailChecker{verified: map[int64]bool{1: true}}
+	tests := []struct {
+		name      string
+		checker   MailVerificationChecker
+		userID    int64
+		status    int
+		reachNext bool
+	}{
+		{name: "verified", checker: checker, userID: 1, status: http.StatusOK, reachNext: true},
+		{name: "unverified", checker: checker, userID: 2, status: http.StatusForbidden},
+		{name: "check_fails", checker: fakeMailChecker{err: errors.New("db down")}, userID: 1, status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reached := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				reached = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest("POST", "/orders", nil)
+			req = req.WithContext(context.WithValue(req.Context(), UserIDKey, tt.userID))
+			res := httptest.NewRecorder()
+
+			RequireVerifiedMail(tt.checker)(next).ServeHTTP(res, req)
+
+			assert.Equal(t, tt.status, res.Code)
+			assert.Equal(t, tt.reachNext, reached)
+			assert.NotContains(t, res.Body.String(), "db down")
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/e"
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/ratelimit"
+
+	"github.com/rs/zerolog"
+)
+
+// maxPeekBody bounds how much of the body KeyByUsername reads
+const maxPeekBody = 1 << 20
+
+// KeyFunc picks the bucket of a request, "" skips the limiter
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP limits per client address. Behind a proxy every client shares
+// the proxy address unless the proxy sets RemoteAddr (e.g. chi's RealIP).
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// KeyByUsername limits per username of a JSON login body. The body is put
+// back for the handler, a body without a username is left to the handler.
+func KeyByUsername(r *http.Request) string {
+	if r.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPeekBody))
+	// the handler reads what was peeked followed by the rest, so it still
+	// sees read errors such as the body size limit
+	r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), r.Body), Closer: r.Body}
+	if err != nil {
+		return ""
+	}
+
+	var creds struct {
+		Username string `json:"username"`
+	}
+	if json.Unmarshal(body, &creds) != nil {
+		return ""
+	}
+	// usernames are case sensitive, "JohnDoe" must not use up the budget of "johndoe"
+	return strings.TrimSpace(creds.Username)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// RateLimit rejects requests with 429 once the bucket picked by key is
+// empty. The limiter fails open: when the store is down requests go
+// through, we'd rather be briefly unprotected than refuse every login.
+func RateLimit(limiter *ratelimit.Limiter, key KeyFunc) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			k := key(r)
+			if k == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			result, err := limiter.Take(r.Context(), k)
+			if err != nil {
+				zerolog.Ctx(r.Context()).Error().Err(err).Str("limiter", limiter.Name).Msg("rate limit store failed, letting the request through")
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !result.Allowed {
+				metrics.RateLimited.WithLabelValues(limiter.Name).Inc()
+				zerolog.Ctx(r.Context()).Warn().Str("limiter", limiter.Name).Dur("retry_after", result.RetryAfter).Msg("rate limited")
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+				api.Fail(w, http.StatusTooManyRequests, e.ErrTooManyRequests, "too many requests, try again later")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sonartest_cart/pkg/api"
+	"sonartest_cart/pkg/e"
+	"sonartest_cart/pkg/metrics"
+	"sonartest_cart/pkg/ratelimit"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store unavailable")
+}
+
+func TestRateLimit(t *testing.T) {
+	limiter := ratelimit.NewLimiter("test_ip", ratelimit.Limit{Requests: 1, Per: time.Minute, Burst: 2}, ratelimit.NewMemoryStore())
+	handler := RateLimit(limiter, KeyByIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	limitedBefore := testutil.ToFloat64(metrics.RateLimited.WithLabelValues("test_ip"))
+
+	serve := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/login", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusNoContent, serve("10.0.0.1:1234").Code)
+	// the port changes with every connection, the limit must not
+	assert.Equal(t, http.StatusNoContent, serve("10.0.0.1:5678").Code)
+
+	rec := serve("10.0.0.1:9999")
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+	var body api.Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, e.ErrTooManyRequests, body.Error.Code)
+	assert.Equal(t, limitedBefore+1, testutil.ToFloat64(metrics.RateLimited.WithLabelValues("test_ip")))
+
+	assert.Equal(t, http.StatusNoContent, serve("10.0.0.2:1234").Code)
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	limiter := ratelimit.NewLimiter("test_down", ratelimit.Limit{Requests: 1, Per: time.Minute, Burst: 1}, failingStore{})
+	handler := RateLimit(limiter, KeyByIP)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("POST", "/login", nil))
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+	}
+}
+
+func TestKeyByUsername(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{name: "username", body: `{"username": " JohnDoe ", "password": "secret"}`, want: "JohnDoe"},
+		{name: "no_username", body: `{"password": "secret"}`, want: ""},
+		{name: "not_json", body: `username=johndoe`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/login", strings.NewReader(tt.body))
+			assert.Equal(t, tt.want, KeyByUsername(req))
+
+			// the handler still gets the whole body
+			body, err := io.ReadAll(req.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+		})
+	}
+}
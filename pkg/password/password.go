@@ -0,0 +1,215 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+
+	argon2idPrefix = "$argon2id$"
+
+	// BcryptMaxBytes is how much of a password bcrypt looks at, the rest is ignored
+	BcryptMaxBytes = 72
+)
+
+var ErrUnknownAlgorithm = errors.New("unknown password hashing algorithm")
+
+// PasswordHasher defines the interface for hashing and verifying passwords
+//
+//go:generate mockery --name PasswordHasher --output mocks --outpkg mocks
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(encoded, password string) (bool, error)
+	NeedsRehash(encoded string) bool
+}
+
+// Argon2idParams holds the tuning parameters for argon2id
+type Argon2idParams struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2idParams follows the OWASP recommendation (19 MiB, 2 iterations, 1 lane)
+var DefaultArgon2idParams = Argon2idParams{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// NewPasswordHasher creates a PasswordHasher for the given algorithm name
+func NewPasswordHasher(algorithm string) (PasswordHasher, error) {
+	switch algorithm {
+	case AlgorithmBcrypt:
+		return NewBcryptHasher(bcrypt.DefaultCost), nil
+	case AlgorithmArgon2id:
+		return NewArgon2idHasher(DefaultArgon2idParams), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, algorithm)
+	}
+}
+
+// IsHashed reports whether the stored value is a hash produced by one of the
+// supported algorithms. Anything else is treated as a legacy plaintext password.
+func IsHashed(encoded string) bool {
+	return isBcrypt(encoded) || strings.HasPrefix(encoded, argon2idPrefix)
+}
+
+func isBcrypt(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") ||
+		strings.HasPrefix(encoded, "$2b$") ||
+		strings.HasPrefix(encoded, "$2y$")
+}
+
+// verify checks a password against any supported encoding, so switching the
+// configured algorithm does not lock out users with older hashes.
+func verify(encoded, password string) (bool, error) {
+	switch {
+	case isBcrypt(encoded):
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return true, nil
+	case strings.HasPrefix(encoded, argon2idPrefix):
+		params, salt, key, err := decodeArgon2id(encoded)
+		if err != nil {
+			return false, err
+		}
+		other := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+		return subtle.ConstantTimeCompare(key, other) == 1, nil
+	default:
+		// legacy plaintext row
+		return subtle.ConstantTimeCompare([]byte(encoded), []byte(password)) == 1, nil
+	}
+}
+
+// bcryptHasher hashes passwords with bcrypt
+type bcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a PasswordHasher using bcrypt with the given cost
+func NewBcryptHasher(cost int) PasswordHasher {
+	return &bcryptHasher{cost: cost}
+}
+
+func (h *bcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func (h *bcryptHasher) Verify(encoded, password string) (bool, error) {
+	return verify(encoded, password)
+}
+
+func (h *bcryptHasher) NeedsRehash(encoded string) bool {
+	if !isBcrypt(encoded) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	if err != nil {
+		return true
+	}
+	return cost != h.cost
+}
+
+// argon2idHasher hashes passwords with argon2id in the PHC string format
+type argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewArgon2idHasher creates a PasswordHasher using argon2id with the given parameters
+func NewArgon2idHasher(params Argon2idParams) PasswordHasher {
+	return &argon2idHasher{params: params}
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	p := h.params
+	key := argon2.IDKey([]byte(password), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h *argon2idHasher) Verify(encoded, password string) (bool, error) {
+	return verify(encoded, password)
+}
+
+func (h *argon2idHasher) NeedsRehash(encoded string) bool {
+	if !strings.HasPrefix(encoded, argon2idPrefix) {
+		return true
+	}
+	params, salt, key, err := decodeArgon2id(encoded)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.params.Memory ||
+		params.Iterations != h.params.Iterations ||
+		params.Parallelism != h.params.Parallelism ||
+		uint32(len(salt)) != h.params.SaltLength ||
+		uint32(len(key)) != h.params.KeyLength
+}
+
+func decodeArgon2id(encoded string) (Argon2idParams, []byte, []byte, error) {
+	var params Argon2idParams
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return params, nil, nil, errors.New("invalid argon2id hash format")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return params, nil, nil, err
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, err
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return params, nil, nil, err
+	}
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
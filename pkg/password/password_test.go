@@ -0,0 +1,75 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashAndVerify(t *testing.T) {
+	hashers := map[string]PasswordHasher{
+		"bcrypt":   NewBcryptHasher(bcrypt.MinCost),
+		"argon2id": NewArgon2idHasher(Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}),
+	}
+
+	for name, h := range hashers {
+		t.Run(name, func(t *testing.T) {
+			hash, err := h.Hash("s3cret")
+			require.NoError(t, err)
+			assert.True(t, IsHashed(hash))
+			assert.False(t, h.NeedsRehash(hash))
+
+			ok, err := h.Verify(hash, "s3cret")
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = h.Verify(hash, "wrong")
+			require.NoError(t, err)
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestVerifyAcrossAlgorithms(t *testing.T) {
+	bc := NewBcryptHasher(bcrypt.MinCost)
+	ar := NewArgon2idHasher(Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32})
+
+	hash, err := bc.Hash("s3cret")
+	require.NoError(t, err)
+
+	// an argon2id hasher still accepts bcrypt hashes but wants them upgraded
+	ok, err := ar.Verify(hash, "s3cret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, ar.NeedsRehash(hash))
+}
+
+func TestVerifyLegacyPlaintext(t *testing.T) {
+	h := NewBcryptHasher(bcrypt.MinCost)
+
+	ok, err := h.Verify("plain", "plain")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, h.NeedsRehash("plain"))
+
+	ok, err = h.Verify("plain", "other")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func TestNeedsRehashOnCostChange(t *testing.T) {
+	hash, err := NewBcryptHasher(bcrypt.MinCost).Hash("s3cret")
+	require.NoError(t, err)
+	assert.True(t, NewBcryptHasher(bcrypt.MinCost+1).NeedsRehash(hash))
+}
+
+func TestNewPasswordHasher(t *testing.T) {
+	_, err := NewPasswordHasher(AlgorithmBcrypt)
+	assert.NoError(t, err)
+	_, err = NewPasswordHasher(AlgorithmArgon2id)
+	assert.NoError(t, err)
+	_, err = NewPasswordHasher("md5")
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+}
@@ -0,0 +1,150 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"sonartest_cart/pkg/validation"
+)
+
+// Policy is what a new password has to look like. Lengths count characters,
+// not bytes.
+type Policy struct {
+	MinLength int
+	MaxLength int
+	// MaxBytes caps the UTF-8 length for hashes that cut the password off,
+	// it is reported as password_max too. 0 means no limit.
+	MaxBytes      int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	// Breached rejects passwords known from data breaches, nil skips the check
+	Breached *BreachedList
+}
+
+// Check returns validation.Errors for every rule password breaks, field is
+// the name the client knows the password by. A password may never contain
+// the username.
+func (p Policy) Check(field, password, username string) error {
+	var errs validation.Errors
+	fail := func(rule, param string) {
+		errs = append(errs, validation.RuleError{Field: field, Rule: rule, Param: param})
+	}
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		fail("password_min", strconv.Itoa(p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		fail("password_max", strconv.Itoa(p.MaxLength))
+	} else if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		fail("password_max", strconv.Itoa(p.MaxBytes))
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsLetter(r):
+			symbol = true
+		}
+	}
+	if p.RequireUpper && !upper {
+		fail("password_upper", "")
+	}
+	if p.RequireLower && !lower {
+		fail("password_lower", "")
+	}
+	if p.RequireDigit && !digit {
+		fail("password_digit", "")
+	}
+	if p.RequireSymbol && !symbol {
+		fail("password_symbol", "")
+	}
+
+	if len(username) >= 3 && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		fail("password_username", "")
+	}
+	if p.Breached.Contains(password) {
+		fail("password_breached", "")
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// BreachedList is a set of passwords known from data breaches. Only SHA-1
+// hashes are kept in memory.
+type BreachedList struct {
+	hashes map[[sha1.Size]byte]struct{}
+}
+
+// LoadBreachedList reads one password per line. A line is either the
+// password itself or its hex SHA-1 hash, optionally followed by ":count" as
+// in the Have I Been Pwned downloads. Empty lines and lines starting with #
+// are skipped.
+func LoadBreachedList(path string) (*BreachedList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("breached password list: %w", err)
+	}
+	defer f.Close()
+
+	list := &BreachedList{hashes: make(map[[sha1.Size]byte]struct{})}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		list.hashes[lineHash(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("breached password list %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// lineHash returns the SHA-1 a line stands for
+func lineHash(line string) [sha1.Size]byte {
+	hexHash, _, _ := strings.Cut(line, ":")
+	if len(hexHash) == 2*sha1.Size {
+		var sum [sha1.Size]byte
+		if _, err := hex.Decode(sum[:], []byte(hexHash)); err == nil {
+			return sum
+		}
+	}
+	return sha1.Sum([]byte(line))
+}
+
+// Len is the number of passwords in the list
+func (l *BreachedList) Len() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.hashes)
+}
+
+// Contains tells whether password is in the list, a nil list contains nothing
+func (l *BreachedList) Contains(password string) bool {
+	if l == nil {
+		return false
+	}
+	_, ok := l.hashes[sha1.Sum([]byte(password))]
+	return ok
+}
@@ -0,0 +1,86 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"sonartest_cart/pkg/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	breached := &BreachedList{hashes: map[[sha1.Size]byte]struct{}{sha1.Sum([]byte("Password1")): {}}}
+	policy := Policy{MinLength: 8, MaxLength: 16, RequireUpper: true, RequireLower: true, RequireDigit: true, Breached: breached}
+
+	tests := []struct {
+		name     string
+		password string
+		username string
+		policy   Policy
+		want     []string
+	}{
+		{name: "valid", password: "Correct7Horse", username: "johndoe", policy: policy},
+		{name: "unicode_counts_characters", password: "Ünïcødé1", username: "johndoe", policy: policy},
+		{name: "too_short", password: "Ab1", policy: policy, want: []string{"password_min"}},
+		{name: "too_long", password: "Abcdefghijklmnop1", policy: policy, want: []string{"password_max"}},
+		{name: "missing_classes", password: "alllowercase", policy: policy, want: []string{"password_upper", "password_digit"}},
+		{name: "symbol_required", password: "Correct7Horse", policy: Policy{RequireSymbol: true}, want: []string{"password_symbol"}},
+		{name: "contains_username", password: "xJohnDoe9", username: "johndoe", policy: policy, want: []string{"password_username"}},
+		{name: "short_username_ignored", password: "Correct7Horse", username: "co", policy: policy},
+		{name: "breached", password: "Password1", policy: policy, want: []string{"password_breached"}},
+		{name: "zero_policy", password: "x", policy: Policy{}},
+		// 21 characters but 75 bytes, bcrypt would only see the first 72
+		{name: "too_many_bytes", password: "Ab1😀😀😀😀😀😀😀😀😀😀😀😀😀😀😀😀😀😀", policy: Policy{MaxLength: 64, MaxBytes: BcryptMaxBytes}, want: []string{"password_max"}},
+		{name: "multi_byte_within_bytes", password: "Ab1ééééééééééééééééééééééééééé", policy: Policy{MaxLength: 64, MaxBytes: BcryptMaxBytes}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check("password", tt.password, tt.username)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var errs validation.Errors
+			require.ErrorAs(t, err, &errs)
+			var rules []string
+			for _, e := range errs {
+				assert.Equal(t, "password", e.Field)
+				rules = append(rules, e.Rule)
+			}
+			assert.Equal(t, tt.want, rules)
+		})
+	}
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	hash := sha1.Sum([]byte("letmein"))
+	content := "# top passwords\n" +
+		"123456\n" +
+		"\n" +
+		"qwerty\r\n" +
+		hex.EncodeToString(hash[:]) + ":4213\n"
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	list, err := LoadBreachedList(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, list.Len())
+	assert.True(t, list.Contains("123456"))
+	assert.True(t, list.Contains("qwerty"))
+	assert.True(t, list.Contains("letmein"))
+	assert.False(t, list.Contains("# top passwords"))
+	assert.False(t, list.Contains("Correct7Horse"))
+
+	_, err = LoadBreachedList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+
+	var none *BreachedList
+	assert.Equal(t, 0, none.Len())
+	assert.False(t, none.Contains("123456"))
+}
@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Lockout locks an account after too many failed logins. Keys are
+// usernames as typed, unknown usernames lock just like real ones so a
+// lock tells nothing about whether the account exists. Usernames are case
+// sensitive, so "JohnDoe" and "johndoe" are locked apart.
+//
+//go:generate mockery --name Lockout --output mocks --outpkg mocks
+type Lockout interface {
+	// LockedFor returns how long key stays locked, 0 when it is not
+	LockedFor(ctx context.Context, key string) (time.Duration, error)
+	// Fail records a failed attempt and returns the lock duration when
+	// this attempt locked the key
+	Fail(ctx context.Context, key string) (time.Duration, error)
+	// Reset forgets the failures of key, e.g. after a successful login
+	Reset(ctx context.Context, key string) error
+}
+
+// LockoutPolicy locks a key for Duration after Threshold failures within Window
+type LockoutPolicy struct {
+	Threshold int
+	Window    time.Duration
+	Duration  time.Duration
+}
+
+type lockEntry struct {
+	failures    int
+	firstFailed time.Time
+	lockedUntil time.Time
+}
+
+// MemoryLockout keeps failures of this process in a map
+type MemoryLockout struct {
+	policy  LockoutPolicy
+	mu      sync.Mutex
+	entries map[string]*lockEntry
+	now     func() time.Time
+
+	lastSweep time.Time
+}
+
+func NewMemoryLockout(policy LockoutPolicy) *MemoryLockout {
+	return &MemoryLockout{
+		policy:  policy,
+		entries: make(map[string]*lockEntry),
+		now:     time.Now,
+	}
+}
+
+// normalizeKey keeps "johndoe" and "johndoe " from having separate counters,
+// the same way the username is trimmed before it is looked up
+func normalizeKey(key string) string {
+	return strings.TrimSpace(key)
+}
+
+func (l *MemoryLockout) LockedFor(_ context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[normalizeKey(key)]
+	if !ok {
+		return 0, nil
+	}
+	if remaining := entry.lockedUntil.Sub(l.now()); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+func (l *MemoryLockout) Fail(_ context.Context, key string) (time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	key = normalizeKey(key)
+	entry, ok := l.entries[key]
+	// failures older than the window don't count anymore
+	if !ok || now.Sub(entry.firstFailed) > l.policy.Window {
+		entry = &lockEntry{firstFailed: now}
+		l.entries[key] = entry
+	}
+
+	entry.failures++
+	if entry.failures < l.policy.Threshold {
+		return 0, nil
+	}
+
+	// a new window starts once the lock ends
+	entry.lockedUntil = now.Add(l.policy.Duration)
+	entry.failures = 0
+	entry.firstFailed = entry.lockedUntil
+	return l.policy.Duration, nil
+}
+
+func (l *MemoryLockout) Reset(_ context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.entries, normalizeKey(key))
+	return nil
+}
+
+// sweep drops entries that neither lock nor count anymore, must be called with the lock held
+func (l *MemoryLockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for key, entry := range l.entries {
+		if now.After(entry.lockedUntil) && now.Sub(entry.firstFailed) > l.policy.Window {
+			delete(l.entries, key)
+		}
+	}
+}
+
+// NoLockout never locks, used when rate limiting is disabled
+type NoLockout struct{}
+
+func (NoLockout) LockedFor(context.Context, string) (time.Duration, error) { return 0, nil }
+
+func (NoLockout) Fail(context.Context, string) (time.Duration, error) { return 0, nil }
+
+func (NoLockout) Reset(context.Context, string) error { return nil }
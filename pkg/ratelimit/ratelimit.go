@@ -0,0 +1,132 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket: up to Burst requests at once, refilled at
+// Requests per Per
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// perSecond is the refill rate of the bucket
+func (l Limit) perSecond() float64 {
+	if l.Per <= 0 {
+		return 0
+	}
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed bool
+	// RetryAfter is how long until the next token, set when not allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps the buckets. MemoryStore only limits a single process, a
+// shared store (e.g. redis) lets every instance enforce the same limit.
+type Store interface {
+	// Take removes one token from the bucket of key
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limiter applies one limit, keys are namespaced by the limiter name so
+// several limiters can share a store
+type Limiter struct {
+	Name  string
+	Limit Limit
+	Store Store
+}
+
+func NewLimiter(name string, limit Limit, store Store) *Limiter {
+	return &Limiter{Name: name, Limit: limit, Store: store}
+}
+
+// Take takes a token for key
+func (l *Limiter) Take(ctx context.Context, key string) (Result, error) {
+	return l.Store.Take(ctx, l.Name+":"+key, l.Limit)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	// full is when the bucket is back at its burst, it can be forgotten then
+	full time.Time
+}
+
+// MemoryStore keeps the buckets of this process in a map. Full buckets are
+// dropped now and then so the map doesn't grow with every client ever seen.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// sweepInterval is how often idle buckets are dropped
+const sweepInterval = time.Minute
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	rate := limit.perSecond()
+	burst := float64(limit.Burst)
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+
+	result := Result{Allowed: b.tokens >= 1}
+	if result.Allowed {
+		b.tokens--
+	}
+
+	// the config rejects a zero rate, don't divide by it anyway
+	if rate <= 0 {
+		return result, nil
+	}
+	b.full = now.Add(seconds((burst - b.tokens) / rate))
+	if !result.Allowed {
+		result.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	return result, nil
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// sweep drops the buckets that are full again, a new bucket for the same
+// key starts out full anyway. Must be called with the lock held.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.full) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a settable clock for the stores
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestMemoryStoreTake(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	store := NewMemoryStore()
+	store.now = clock.now
+	limiter := NewLimiter("login", Limit{Requests: 60, Per: time.Minute, Burst: 3}, store)
+	ctx := context.Background()
+
+	take := func(key string) Result {
+		t.Helper()
+		result, err := limiter.Take(ctx, key)
+		require.NoError(t, err)
+		return result
+	}
+
+	// the burst goes through at once
+	for i := 0; i < 3; i++ {
+		assert.True(t, take("1.2.3.4").Allowed, "request %d", i)
+	}
+	denied := take("1.2.3.4")
+	assert.False(t, denied.Allowed)
+	assert.Equal(t, time.Second, denied.RetryAfter)
+
+	// other keys have their own bucket
+	assert.True(t, take("5.6.7.8").Allowed)
+
+	// one token per second comes back
+	clock.advance(time.Second)
+	assert.True(t, take("1.2.3.4").Allowed)
+	assert.False(t, take("1.2.3.4").Allowed)
+
+	// the refill stops at the burst
+	clock.advance(time.Hour)
+	for i := 0; i < 3; i++ {
+		assert.True(t, take("1.2.3.4").Allowed, "request %d after refill", i)
+	}
+	assert.False(t, take("1.2.3.4").Allowed)
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	store := NewMemoryStore()
+	store.now = clock.now
+	limit := Limit{Requests: 1, Per: time.Second, Burst: 2}
+	ctx := context.Background()
+
+	_, err := store.Take(ctx, "idle", limit)
+	require.NoError(t, err)
+	clock.advance(sweepInterval)
+	_, err = store.Take(ctx, "busy", limit)
+	require.NoError(t, err)
+
+	// idle is full again and dropped, busy just took a token
+	assert.NotContains(t, store.buckets, "idle")
+	assert.Contains(t, store.buckets, "busy")
+}
+
+func TestMemoryLockout(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1700000000, 0)}
+	lockout := NewMemoryLockout(LockoutPolicy{Threshold: 3, Window: 10 * time.Minute, Duration: 15 * time.Minute})
+	lockout.now = clock.now
+	ctx := context.Background()
+
+	fail := func(key string) time.Duration {
+		t.Helper()
+		lockedFor, err := lockout.Fail(ctx, key)
+		require.NoError(t, err)
+		return lockedFor
+	}
+	lockedFor := func(key string) time.Duration {
+		t.Helper()
+		d, err := lockout.LockedFor(ctx, key)
+		require.NoError(t, err)
+		return d
+	}
+
+	assert.Zero(t, fail("johndoe"))
+	assert.Zero(t, fail("johndoe "))
+	assert.Zero(t, lockedFor("johndoe"))
+	assert.Equal(t, 15*time.Minute, fail("johndoe"), "third failure locks, whatever the spacing")
+	// usernames are case sensitive, failures against johndoe don't lock JohnDoe
+	assert.Zero(t, lockedFor("JohnDoe"))
+
+	clock.advance(5 * time.Minute)
+	assert.Equal(t, 10*time.Minute, lockedFor(" johndoe"))
+
+	clock.advance(10 * time.Minute)
+	assert.Zero(t, lockedFor("johndoe"))
+
+	// failures outside the window don't add up
+	assert.Zero(t, fail("janedoe"))
+	assert.Zero(t, fail("janedoe"))
+	clock.advance(11 * time.Minute)
+	assert.Zero(t, fail("janedoe"))
+
+	// a successful login forgets the failures
+	require.NoError(t, lockout.Reset(ctx, "janedoe"))
+	assert.Zero(t, fail("janedoe"))
+	assert.Zero(t, fail("janedoe"))
+	assert.Zero(t, lockedFor("janedoe"))
+}
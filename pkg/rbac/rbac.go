@@ -0,0 +1,60 @@
+// Package rbac names the roles and permissions the API checks. Which role
+// holds which permission is stored in the database, DefaultRoles is what a
+// new database is seeded with.
+package rbac
+
+// Permission allows a group of admin endpoints
+type Permission = string
+
+const (
+	// CatalogWrite creates and updates categories and brands
+	CatalogWrite Permission = "catalog:write"
+	// UsersRead lists users and their profiles
+	UsersRead Permission = "users:read"
+	// UsersWrite updates the profile of any user
+	UsersWrite Permission = "users:write"
+	// UsersBlock blocks and unblocks users
+	UsersBlock Permission = "users:block"
+	// OrdersRead shows the order history of any user
+	OrdersRead Permission = "orders:read"
+	// RolesAssign grants roles to users
+	RolesAssign Permission = "roles:assign"
+)
+
+const (
+	// RoleCustomer is every user who signed up, it has no admin permissions
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+	RoleSupport  = "support"
+	// RoleWarehouse keeps the catalog up to date and ships orders
+	RoleWarehouse = "warehouse"
+)
+
+// Permissions lists every permission there is
+var Permissions = []Permission{CatalogWrite, UsersRead, UsersWrite, UsersBlock, OrdersRead, RolesAssign}
+
+// Role is a named set of permissions
+type Role struct {
+	Name        string
+	Description string
+	Permissions []Permission
+}
+
+// DefaultRoles are the roles a new database starts with, the SQL migration
+// seeds the same grants
+var DefaultRoles = []Role{
+	{Name: RoleCustomer, Description: "Shops on the site"},
+	{Name: RoleAdmin, Description: "Manages everything, including roles", Permissions: Permissions},
+	{Name: RoleSupport, Description: "Helps customers with their accounts and orders", Permissions: []Permission{UsersRead, UsersBlock, OrdersRead}},
+	{Name: RoleWarehouse, Description: "Maintains the catalog and ships orders", Permissions: []Permission{CatalogWrite, OrdersRead}},
+}
+
+// Has tells whether permission is one of granted
+func Has(granted []Permission, permission Permission) bool {
+	for _, p := range granted {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
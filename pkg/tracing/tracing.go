@@ -0,0 +1,101 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"sonartest_cart/pkg/config"
+	"sonartest_cart/pkg/e"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrorCodeKey holds the e.WrapError code of a failed span
+const ErrorCodeKey = attribute.Key("app.error_code")
+
+// Shutdown flushes the spans still buffered and stops the exporter
+type Shutdown func(ctx context.Context) error
+
+// stdoutWriter is swapped in tests
+var stdoutWriter io.Writer = os.Stdout
+
+// Setup installs the global tracer provider and the W3C traceparent/baggage
+// propagator. With the none exporter spans are still created, so trace ids
+// reach the logs, they are just never sent anywhere.
+func Setup(ctx context.Context, cfg config.TracingConfig) (Shutdown, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("tracing: building resource: %w", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	}
+
+	switch cfg.Exporter {
+	case config.ExporterOTLP:
+		var clientOpts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			clientOpts = append(clientOpts, otlptracehttp.WithEndpoint(cfg.Endpoint))
+		}
+		if cfg.Insecure {
+			clientOpts = append(clientOpts, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(ctx, clientOpts...)
+		if err != nil {
+			return nil, fmt.Errorf("tracing: creating otlp exporter: %w", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+
+	case config.ExporterStdout:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(stdoutWriter))
+		if err != nil {
+			return nil, fmt.Errorf("tracing: creating stdout exporter: %w", err)
+		}
+		// no batching, spans show up as soon as they end
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+
+	case config.ExporterNone:
+
+	default:
+		return nil, fmt.Errorf("tracing: unknown exporter %q", cfg.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// End records err on the span, if any, and ends it. Meant to be deferred
+// with a named error result.
+func End(span trace.Span, err error) {
+	if err != nil {
+		var wrapErr *e.WrapError
+		if errors.As(err, &wrapErr) {
+			span.SetAttributes(ErrorCodeKey.Int(wrapErr.ErrorCode))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,76 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"sonartest_cart/pkg/config"
+	"sonartest_cart/pkg/e"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSetupStdout(t *testing.T) {
+	var buf bytes.Buffer
+	previous := stdoutWriter
+	stdoutWriter = &buf
+	t.Cleanup(func() { stdoutWriter = previous })
+
+	shutdown, err := Setup(context.Background(), config.TracingConfig{
+		Exporter:    config.ExporterStdout,
+		ServiceName: "cart-test",
+		SampleRatio: 1,
+	})
+	require.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "checkout")
+	span.End()
+	require.NoError(t, shutdown(context.Background()))
+
+	assert.Contains(t, buf.String(), `"Name":"checkout"`)
+	assert.Contains(t, buf.String(), "cart-test")
+
+	// W3C traceparent is propagated
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(
+		otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier{
+			"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		}), carrier)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", carrier["traceparent"])
+}
+
+func TestSetupNone(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: config.ExporterNone, ServiceName: "cart-test", SampleRatio: 1})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestSetupUnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), config.TracingConfig{Exporter: "zipkin"})
+	assert.EqualError(t, err, `tracing: unknown exporter "zipkin"`)
+}
+
+func TestEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("test")
+
+	_, ok := tracer.Start(context.Background(), "ok")
+	End(ok, nil)
+	_, failed := tracer.Start(context.Background(), "failed")
+	End(failed, e.NewError(e.ErrPlaceOrder, "cart is empty", errors.New("no items")))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Contains(t, spans[1].Attributes(), ErrorCodeKey.Int(e.ErrPlaceOrder))
+	require.Len(t, spans[1].Events(), 1, "the error is recorded as an event")
+}
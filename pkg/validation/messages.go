@@ -0,0 +1,79 @@
+package validation
+
+const (
+	// defaultKey is used for rules without a message of their own
+	defaultKey = "default"
+
+	stringSuffix = "-string"
+	itemsSuffix  = "-items"
+)
+
+// messages are the texts of the rules per locale, {0} is the field and {1} the
+// rule parameter
+var messages = map[string]map[string]string{
+	"en": {
+		defaultKey:           "{0} is invalid",
+		"required":           "{0} is required",
+		"email":              "{0} must be a valid email address",
+		"oneof":              "{0} must be one of [{1}]",
+		"len":                "{0} must be {1}",
+		"len" + stringSuffix: "{0} must be {1} characters long",
+		"len" + itemsSuffix:  "{0} must contain {1} items",
+		"min":                "{0} must be {1} or more",
+		"min" + stringSuffix: "{0} must be at least {1} characters long",
+		"min" + itemsSuffix:  "{0} must contain at least {1} items",
+		"max":                "{0} must be {1} or less",
+		"max" + stringSuffix: "{0} must be at most {1} characters long",
+		"max" + itemsSuffix:  "{0} must contain at most {1} items",
+		"gt":                 "{0} must be greater than {1}",
+		"gte":                "{0} must be {1} or more",
+		"lt":                 "{0} must be less than {1}",
+		"lte":                "{0} must be {1} or less",
+		"e164":               "{0} must be a phone number in international format, e.g. +919876543210",
+		"username":           "{0} must be 3 to 32 letters, digits, dots, dashes or underscores and start with a letter or digit",
+		"country":            "{0} must be a two letter ISO 3166 country code",
+		"postcode":           "{0} is not a valid postal code for the country",
+
+		// password policy, see pkg/password
+		"password_min":      "{0} must be at least {1} characters long",
+		"password_max":      "{0} must be at most {1} characters long",
+		"password_upper":    "{0} must contain an upper case letter",
+		"password_lower":    "{0} must contain a lower case letter",
+		"password_digit":    "{0} must contain a digit",
+		"password_symbol":   "{0} must contain a symbol",
+		"password_username": "{0} must not contain the username",
+		"password_breached": "{0} has appeared in a data breach, choose another one",
+	},
+	"fr": {
+		defaultKey:           "{0} n'est pas valide",
+		"required":           "{0} est obligatoire",
+		"email":              "{0} doit être une adresse e-mail valide",
+		"oneof":              "{0} doit être l'une des valeurs [{1}]",
+		"len":                "{0} doit être égal à {1}",
+		"len" + stringSuffix: "{0} doit contenir {1} caractères",
+		"len" + itemsSuffix:  "{0} doit contenir {1} éléments",
+		"min":                "{0} doit être supérieur ou égal à {1}",
+		"min" + stringSuffix: "{0} doit contenir au moins {1} caractères",
+		"min" + itemsSuffix:  "{0} doit contenir au moins {1} éléments",
+		"max":                "{0} doit être inférieur ou égal à {1}",
+		"max" + stringSuffix: "{0} doit contenir au plus {1} caractères",
+		"max" + itemsSuffix:  "{0} doit contenir au plus {1} éléments",
+		"gt":                 "{0} doit être supérieur à {1}",
+		"gte":                "{0} doit être supérieur ou égal à {1}",
+		"lt":                 "{0} doit être inférieur à {1}",
+		"lte":                "{0} doit être inférieur ou égal à {1}",
+		"e164":               "{0} doit être un numéro de téléphone au format international, par exemple +33612345678",
+		"username":           "{0} doit contenir de 3 à 32 lettres, chiffres, points, tirets ou tirets bas et commencer par une lettre ou un chiffre",
+		"country":            "{0} doit être un code pays ISO 3166 à deux lettres",
+		"postcode":           "{0} n'est pas un code postal valide pour ce pays",
+
+		"password_min":      "{0} doit contenir au moins {1} caractères",
+		"password_max":      "{0} doit contenir au plus {1} caractères",
+		"password_upper":    "{0} doit contenir une lettre majuscule",
+		"password_lower":    "{0} doit contenir une lettre minuscule",
+		"password_digit":    "{0} doit contenir un chiffre",
+		"password_symbol":   "{0} doit contenir un symbole",
+		"password_username": "{0} ne doit pas contenir le nom d'utilisateur",
+		"password_breached": "{0} figure dans une fuite de données, choisissez-en un autre",
+	},
+}
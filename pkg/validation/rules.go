@@ -0,0 +1,75 @@
+package validation
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+var (
+	// e164Regex is stricter than the validator's own rule, which lets a
+	// number start with +0
+	e164Regex     = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+	usernameRegex = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{2,31}$`)
+	countryRegex  = regexp.MustCompile(`^[A-Z]{2}$`)
+
+	// genericPostcode is used for countries without an entry in postcodes
+	genericPostcode = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,8}[A-Z0-9]$`)
+
+	// postcodes are the postal code formats of the countries we ship to most,
+	// codes are upper-cased before they are checked
+	postcodes = map[string]*regexp.Regexp{
+		"AU": regexp.MustCompile(`^[0-9]{4}$`),
+		"CA": regexp.MustCompile(`^[ABCEGHJ-NPRSTVXY][0-9][ABCEGHJ-NPRSTV-Z] ?[0-9][ABCEGHJ-NPRSTV-Z][0-9]$`),
+		"DE": regexp.MustCompile(`^[0-9]{5}$`),
+		"FR": regexp.MustCompile(`^[0-9]{5}$`),
+		"GB": regexp.MustCompile(`^[A-Z]{1,2}[0-9][A-Z0-9]? ?[0-9][A-Z]{2}$`),
+		"IN": regexp.MustCompile(`^[1-9][0-9]{5}$`),
+		"JP": regexp.MustCompile(`^[0-9]{3}-?[0-9]{4}$`),
+		"NL": regexp.MustCompile(`^[1-9][0-9]{3} ?[A-Z]{2}$`),
+		"SG": regexp.MustCompile(`^[0-9]{6}$`),
+		"US": regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`),
+	}
+)
+
+// registerRules adds the rules the validator doesn't have built in
+func registerRules(v *validator.Validate) error {
+	rules := map[string]validator.Func{
+		"e164":     matches(e164Regex),
+		"username": matches(usernameRegex),
+		"country":  matches(countryRegex),
+		"postcode": isPostcode,
+	}
+	for tag, fn := range rules {
+		if err := v.RegisterValidation(tag, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matches(re *regexp.Regexp) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		return re.MatchString(fl.Field().String())
+	}
+}
+
+// isPostcode checks a postal code against the format of the country in the
+// sibling field named by the rule parameter, e.g. `validate:"postcode=Country"`
+func isPostcode(fl validator.FieldLevel) bool {
+	parent := reflect.Indirect(fl.Parent())
+	if parent.Kind() != reflect.Struct {
+		return false
+	}
+	country := parent.FieldByName(fl.Param())
+	if country.Kind() != reflect.String {
+		return false
+	}
+	format, ok := postcodes[strings.ToUpper(country.String())]
+	if !ok {
+		format = genericPostcode
+	}
+	return format.MatchString(strings.ToUpper(fl.Field().String()))
+}
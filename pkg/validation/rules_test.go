@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type contact struct {
+	UserName string `json:"username" validate:"username"`
+	Country  string `json:"country" validate:"country"`
+	Pincode  string `json:"pincode" validate:"postcode=Country"`
+	Phone    string `json:"phonenumber" validate:"e164"`
+}
+
+func TestRules(t *testing.T) {
+	valid := contact{UserName: "john.doe", Country: "IN", Pincode: "560001", Phone: "+919876543210"}
+
+	tests := []struct {
+		name   string
+		modify func(c *contact)
+		want   []string
+	}{
+		{name: "valid", modify: func(c *contact) {}},
+		{name: "us_zip_plus_four", modify: func(c *contact) { c.Country, c.Pincode = "US", "94105-1234" }},
+		{name: "gb_postcode", modify: func(c *contact) { c.Country, c.Pincode = "GB", "SW1A 1AA" }},
+		{name: "unknown_country_generic_format", modify: func(c *contact) { c.Country, c.Pincode = "BR", "01310-100" }},
+		{name: "username_too_short", modify: func(c *contact) { c.UserName = "jo" }, want: []string{"username"}},
+		{name: "username_bad_charset", modify: func(c *contact) { c.UserName = "john doe" }, want: []string{"username"}},
+		{name: "username_leading_dot", modify: func(c *contact) { c.UserName = ".john" }, want: []string{"username"}},
+		{name: "lowercase_country", modify: func(c *contact) { c.Country = "in" }, want: []string{"country"}},
+		{name: "pincode_of_other_country", modify: func(c *contact) { c.Country = "US" }, want: []string{"postcode"}},
+		{name: "pincode_leading_zero_in_india", modify: func(c *contact) { c.Pincode = "060001" }, want: []string{"postcode"}},
+		{name: "phone_without_plus", modify: func(c *contact) { c.Phone = "919876543210" }, want: []string{"e164"}},
+		{name: "phone_leading_zero", modify: func(c *contact) { c.Phone = "+09876543210" }, want: []string{"e164"}},
+		{name: "phone_too_long", modify: func(c *contact) { c.Phone = "+1234567890123456" }, want: []string{"e164"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.modify(&c)
+
+			var rules []string
+			for _, f := range Fields(Struct(&c), Translator("")) {
+				rules = append(rules, f.Rule)
+			}
+			assert.Equal(t, tt.want, rules)
+		})
+	}
+}
+
+func TestFieldsRuleErrors(t *testing.T) {
+	err := Errors{
+		{Field: "password", Rule: "password_min", Param: "12"},
+		{Field: "password", Rule: "password_breached"},
+	}
+	assert.Equal(t, "password failed on the password_min rule; password failed on the password_breached rule", err.Error())
+
+	assert.Equal(t, []FieldError{
+		{Field: "password", Rule: "password_min", Param: "12", Message: "password doit contenir au moins 12 caractères"},
+		{Field: "password", Rule: "password_breached", Message: "password figure dans une fuite de données, choisissez-en un autre"},
+	}, Fields(err, Translator("fr")))
+}
@@ -0,0 +1,207 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator"
+)
+
+// FieldError is one failed rule of a request body, as sent to the client
+type FieldError struct {
+	// Field is the JSON path of the field, e.g. "items[0].quantity"
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// RuleError is a failed rule checked outside the validator, e.g. by the
+// password policy. It is reported and translated like a validator rule.
+type RuleError struct {
+	Field string
+	Rule  string
+	Param string
+}
+
+// Errors are rule failures found outside the validator
+type Errors []RuleError
+
+func (errs Errors) Error() string {
+	msgs := make([]string, len(errs))
+	for i, err := range errs {
+		msgs[i] = fmt.Sprintf("%s failed on the %s rule", err.Field, err.Rule)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+var (
+	once     sync.Once
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+)
+
+// setup builds the validator once, it caches the parsed struct tags so
+// every request after the first one skips the reflection
+func setup() {
+	once.Do(func() {
+		validate = validator.New()
+		// errors name fields the way the client sent them
+		validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return f.Name
+			}
+			return name
+		})
+		if err := registerRules(validate); err != nil {
+			panic("validation: " + err.Error())
+		}
+
+		uni = ut.New(en.New(), en.New(), fr.New())
+		for _, locale := range []locales.Translator{en.New(), fr.New()} {
+			trans, _ := uni.GetTranslator(locale.Locale())
+			for key, text := range messages[locale.Locale()] {
+				if err := trans.Add(key, text, false); err != nil {
+					panic("validation: " + err.Error())
+				}
+			}
+		}
+	})
+}
+
+// Validator returns the shared validator, for registering custom rules
+func Validator() *validator.Validate {
+	setup()
+	return validate
+}
+
+// Struct validates s with the shared validator. Rule failures come back as
+// validator.ValidationErrors, turn them into FieldErrors with Fields.
+func Struct(s interface{}) error {
+	setup()
+	return validate.Struct(s)
+}
+
+// Translator picks the best supported language of an Accept-Language
+// header, English when none of them is supported
+func Translator(acceptLanguage string) ut.Translator {
+	setup()
+	trans, _ := uni.FindTranslator(parseAcceptLanguage(acceptLanguage)...)
+	return trans
+}
+
+// Fields returns the field errors in err, nil when err is not (or does not
+// wrap) a validation failure
+func Fields(err error, trans ut.Translator) []FieldError {
+	var ruleErrs Errors
+	if errors.As(err, &ruleErrs) {
+		fields := make([]FieldError, 0, len(ruleErrs))
+		for _, re := range ruleErrs {
+			fields = append(fields, FieldError{
+				Field:   re.Field,
+				Rule:    re.Rule,
+				Param:   re.Param,
+				Message: translate(trans, []string{re.Rule, defaultKey}, re.Field, re.Param),
+			})
+		}
+		return fields
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return nil
+	}
+
+	fields := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		field := fieldPath(fe.Namespace())
+		fields = append(fields, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(trans, fe, field),
+		})
+	}
+	return fields
+}
+
+// fieldPath drops the struct name the namespace starts with
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// message translates the rule of fe. Length rules read differently for
+// text and lists, those have their own keys.
+func message(trans ut.Translator, fe validator.FieldError, field string) string {
+	keys := []string{fe.Tag(), defaultKey}
+	switch fe.Kind() {
+	case reflect.String:
+		keys = append([]string{fe.Tag() + stringSuffix}, keys...)
+	case reflect.Slice, reflect.Array, reflect.Map:
+		keys = append([]string{fe.Tag() + itemsSuffix}, keys...)
+	}
+
+	return translate(trans, keys, field, fe.Param())
+}
+
+// translate uses the first of keys with a message
+func translate(trans ut.Translator, keys []string, field, param string) string {
+	for _, key := range keys {
+		if msg, err := trans.T(key, field, param); err == nil {
+			return msg
+		}
+	}
+	return field + " is invalid"
+}
+
+type language struct {
+	tag string
+	q   float64
+}
+
+// parseAcceptLanguage returns the locales of the header by preference.
+// "fr-CH" is followed by "fr" so a regional variant falls back to the language.
+func parseAcceptLanguage(header string) []string {
+	var langs []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil || parsed <= 0 {
+				continue
+			}
+			q = parsed
+		}
+		langs = append(langs, language{tag: strings.ReplaceAll(tag, "-", "_"), q: q})
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	tags := make([]string, 0, len(langs)*2)
+	for _, l := range langs {
+		tags = append(tags, l.tag)
+		if base, _, regional := strings.Cut(l.tag, "_"); regional {
+			tags = append(tags, base)
+		}
+	}
+	return tags
+}
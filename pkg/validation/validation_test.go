@@ -0,0 +1,94 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type item struct {
+	Quantity int64 `json:"quantity" validate:"gt=0"`
+}
+
+type order struct {
+	Name    string `json:"name" validate:"required"`
+	Comment string `json:"comment,omitempty" validate:"max=5"`
+	Items   []item `json:"items" validate:"required,min=1,dive"`
+	Limit   int    `validate:"gte=1,lte=100"`
+}
+
+func TestFields(t *testing.T) {
+	err := Struct(&order{
+		Comment: "far too long",
+		Items:   []item{{Quantity: 1}, {Quantity: 0}},
+		Limit:   101,
+	})
+	require.Error(t, err)
+	// services wrap the error, the fields must still be found
+	wrapped := fmt.Errorf("error while validating: %w", err)
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		want           []FieldError
+	}{
+		{
+			name: "english_by_default",
+			want: []FieldError{
+				{Field: "name", Rule: "required", Message: "name is required"},
+				{Field: "comment", Rule: "max", Param: "5", Message: "comment must be at most 5 characters long"},
+				{Field: "items[1].quantity", Rule: "gt", Param: "0", Message: "items[1].quantity must be greater than 0"},
+				{Field: "Limit", Rule: "lte", Param: "100", Message: "Limit must be 100 or less"},
+			},
+		},
+		{
+			name:           "french_region_falls_back_to_language",
+			acceptLanguage: "de-DE;q=0.9, fr-CH, en;q=0.5",
+			want: []FieldError{
+				{Field: "name", Rule: "required", Message: "name est obligatoire"},
+				{Field: "comment", Rule: "max", Param: "5", Message: "comment doit contenir au plus 5 caractères"},
+				{Field: "items[1].quantity", Rule: "gt", Param: "0", Message: "items[1].quantity doit être supérieur à 0"},
+				{Field: "Limit", Rule: "lte", Param: "100", Message: "Limit doit être inférieur ou égal à 100"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Fields(wrapped, Translator(tt.acceptLanguage)))
+		})
+	}
+}
+
+func TestFieldsListMessages(t *testing.T) {
+	fields := Fields(Struct(&order{Name: "x", Items: []item{}, Limit: 1}), Translator("en"))
+	assert.Equal(t, []FieldError{
+		{Field: "items", Rule: "min", Param: "1", Message: "items must contain at least 1 items"},
+	}, fields)
+}
+
+func TestFieldsWithoutValidationError(t *testing.T) {
+	assert.Nil(t, Fields(errors.New("from must be before to"), Translator("")))
+	assert.Nil(t, Fields(nil, Translator("")))
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{header: "", want: []string{}},
+		{header: "fr", want: []string{"fr"}},
+		{header: "en-US,en;q=0.8,fr;q=0.9", want: []string{"en_US", "en", "fr", "en"}},
+		{header: "*, de;q=0, nl;q=abc, fr;q=0.1", want: []string{"fr"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseAcceptLanguage(tt.header))
+		})
+	}
+}